@@ -72,6 +72,8 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolCheckpointFlag,
+		utils.TxPoolRecheckpointFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
@@ -79,6 +81,11 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolFeePerByteFlag,
+		utils.TxPoolPrivateLifetimeFlag,
+		utils.TxPoolPolicyFlag,
+		utils.UserPoolEntryPointsFlag,
+		utils.UserPoolBundlerFlag,
 		utils.BlobPoolDataDirFlag,
 		utils.BlobPoolDataCapFlag,
 		utils.BlobPoolPriceBumpFlag,
@@ -90,6 +97,7 @@ var (
 		utils.TxLookupLimitFlag, // deprecated
 		utils.TransactionHistoryFlag,
 		utils.StateHistoryFlag,
+		utils.LogIndexFlag,
 		utils.LightServeFlag,    // deprecated
 		utils.LightIngressFlag,  // deprecated
 		utils.LightEgressFlag,   // deprecated
@@ -159,6 +167,7 @@ var (
 		utils.AuthPortFlag,
 		utils.AuthVirtualHostsFlag,
 		utils.JWTSecretFlag,
+		utils.JWTSecretsDirFlag,
 		utils.HTTPVirtualHostsFlag,
 		utils.GraphQLEnabledFlag,
 		utils.GraphQLCORSDomainFlag,
@@ -177,9 +186,26 @@ var (
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.RPCResponseCacheFlag,
 		utils.AllowUnprotectedTxs,
 		utils.BatchRequestLimit,
 		utils.BatchResponseMaxSize,
+		utils.ResponseMaxSize,
+		utils.RPCAllowFlag,
+		utils.RPCDenyFlag,
+		utils.AuthRPCAllowFlag,
+		utils.AuthRPCDenyFlag,
+		utils.RPCIPRateLimitFlag,
+		utils.RPCIPRateBurstFlag,
+		utils.RPCSubjectRateLimitFlag,
+		utils.RPCSubjectRateBurstFlag,
+		utils.RPCMethodWeightsFlag,
+		utils.RPCAuditFileFlag,
+		utils.RPCAuditJSONFlag,
+		utils.RPCAuditMaxSizeFlag,
+		utils.RPCAuditMaxBackupsFlag,
+		utils.RPCAuditSamplingFlag,
+		utils.RPCAuditRedactFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -223,6 +249,9 @@ func init() {
 		consoleCommand,
 		attachCommand,
 		javascriptCommand,
+		// See txpoolcmd.go:
+		exportTxPoolCommand,
+		replayTxPoolCommand,
 		// See misccmd.go:
 		versionCommand,
 		versionCheckCommand,
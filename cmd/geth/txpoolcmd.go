@@ -0,0 +1,187 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	replayTimestampFlag = &cli.Uint64Flag{
+		Name:  "timestamp",
+		Usage: "Timestamp the lists are built at (default: time of the snapshot)",
+	}
+	replayBeneficiaryFlag = &cli.StringFlag{
+		Name:  "beneficiary",
+		Usage: "Beneficiary of the built lists",
+	}
+	replayBaseFeeFlag = &flags.BigFlag{
+		Name:  "basefee",
+		Usage: "Base fee the lists are built with (default: base fee of the snapshot head)",
+	}
+	replayGasLimitFlag = &cli.Uint64Flag{
+		Name:  "gaslimit",
+		Usage: "Maximum gas used by a list",
+		Value: 240_000_000,
+	}
+	replayMaxBytesFlag = &cli.Uint64Flag{
+		Name:  "maxbytes",
+		Usage: "Maximum compressed size of a list",
+		Value: 128 * 1024,
+	}
+	replayMaxListsFlag = &cli.Uint64Flag{
+		Name:  "maxlists",
+		Usage: "Maximum number of lists to build",
+		Value: 1,
+	}
+	replayMinTipFlag = &cli.Uint64Flag{
+		Name:  "mintip",
+		Usage: "Minimum tip of the transactions in the lists",
+	}
+	replayLocalsFlag = &cli.StringSliceFlag{
+		Name:  "locals",
+		Usage: "Senders whose transactions are included first",
+	}
+
+	exportTxPoolCommand = &cli.Command{
+		Action:    exportTxPool,
+		Name:      "export-txpool",
+		Usage:     "Export the transaction pool of a running node into a file",
+		ArgsUsage: "<filename> [endpoint]",
+		Flags:     []cli.Flag{utils.DataDirFlag, utils.HttpHeaderFlag},
+		Description: `
+Requires a first argument of the file to write to, and optionally the endpoint
+of the node, which defaults to its IPC endpoint in the data directory. The
+pending and queued transactions are written along with their sender, arrival
+time and whether they are local, as JSON lines if the file name ends with
+".jsonl", or as an RLP stream otherwise. This is the format written by the
+txpool_export RPC method.`,
+	}
+	replayTxPoolCommand = &cli.Command{
+		Action:    replayTxPool,
+		Name:      "replay-txpool",
+		Usage:     "Build the transactions lists of an exported transaction pool",
+		ArgsUsage: "<filename>",
+		Flags: flags.Merge([]cli.Flag{
+			utils.CacheFlag,
+			utils.TxPoolPriceLimitFlag,
+			utils.TxPoolFeePerByteFlag,
+			replayTimestampFlag,
+			replayBeneficiaryFlag,
+			replayBaseFeeFlag,
+			replayGasLimitFlag,
+			replayMaxBytesFlag,
+			replayMaxListsFlag,
+			replayMinTipFlag,
+			replayLocalsFlag,
+		}, utils.DatabaseFlags),
+		Description: `
+Requires a first argument of a file written by export-txpool or txpool_export.
+The transactions are added to an empty pool on top of the state of the block
+the snapshot was taken at, which must be available in the data directory, and
+the transactions lists are built like taikoAuth_txPoolContent would. The lists
+are built at a fixed timestamp, so replaying a snapshot always builds the same
+lists.`,
+	}
+)
+
+// exportTxPool retrieves the pool snapshot of a running node over its txpool
+// API and writes it into a local file.
+func exportTxPool(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 || ctx.Args().Len() > 2 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	file := ctx.Args().First()
+	endpoint := ctx.Args().Get(1)
+	if endpoint == "" {
+		cfg := defaultNodeConfig()
+		utils.SetDataDir(ctx, &cfg)
+		endpoint = cfg.IPCEndpoint()
+	}
+	client, err := utils.DialRPCWithHeaders(endpoint, ctx.StringSlice(utils.HttpHeaderFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to remote geth: %v", err)
+	}
+	defer client.Close()
+
+	var snap txpool.Snapshot
+	if err := client.Call(&snap, "txpool_snapshot"); err != nil {
+		return fmt.Errorf("failed to retrieve the pool snapshot: %v", err)
+	}
+	if err := txpool.ExportSnapshot(file, &snap); err != nil {
+		return fmt.Errorf("failed to export the pool snapshot: %v", err)
+	}
+	fmt.Printf("Exported %d transactions at block %d into %s\n", len(snap.Txs), snap.Header.Number, file)
+	return nil
+}
+
+// replayTxPool builds the transactions lists of a pool snapshot against the
+// state of the local chain at the snapshot head.
+func replayTxPool(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	snap, err := txpool.ImportSnapshot(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("failed to import the pool snapshot: %v", err)
+	}
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, true)
+	defer db.Close()
+
+	replay := &miner.SnapshotReplay{
+		Timestamp:    snap.Header.Time,
+		Beneficiary:  common.HexToAddress(ctx.String(replayBeneficiaryFlag.Name)),
+		BaseFee:      flags.GlobalBig(ctx, replayBaseFeeFlag.Name),
+		GasLimit:     ctx.Uint64(replayGasLimitFlag.Name),
+		MaxBytes:     ctx.Uint64(replayMaxBytesFlag.Name),
+		LocalSenders: ctx.StringSlice(replayLocalsFlag.Name),
+		MaxLists:     ctx.Uint64(replayMaxListsFlag.Name),
+		MinTip:       ctx.Uint64(replayMinTipFlag.Name),
+	}
+	if ctx.IsSet(replayTimestampFlag.Name) {
+		replay.Timestamp = ctx.Uint64(replayTimestampFlag.Name)
+	}
+	if !ctx.IsSet(replayBaseFeeFlag.Name) {
+		head := chain.GetHeaderByHash(snap.Header.Hash)
+		if head == nil || head.BaseFee == nil {
+			utils.Fatalf("No base fee given and none known for block %d [%x]", snap.Header.Number, snap.Header.Hash)
+		}
+		replay.BaseFee = head.BaseFee
+	}
+	lists, err := miner.ReplayTxPoolSnapshot(chain, cfg.Eth.TxPool, snap, replay)
+	if err != nil {
+		return fmt.Errorf("failed to replay the pool snapshot: %v", err)
+	}
+	fmt.Printf("Replayed %d transactions at block %d\n", len(snap.Txs), snap.Header.Number)
+	for i, list := range lists {
+		fmt.Printf("List %d: %d transactions, %d gas, %d bytes\n", i, len(list.TxList), list.EstimatedGasUsed, list.BytesLength)
+		for _, tx := range list.TxList {
+			fmt.Printf("  %x\n", tx.Hash())
+		}
+	}
+	return nil
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/txpool/userpool"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
@@ -281,6 +282,11 @@ var (
 		Value:    ethconfig.Defaults.TransactionHistory,
 		Category: flags.StateCategory,
 	}
+	LogIndexFlag = &cli.BoolFlag{
+		Name:     "logindex",
+		Usage:    "Maintain an exact address/topic log index to serve log filters (replaces bloombits lookups)",
+		Category: flags.StateCategory,
+	}
 	// Transaction pool settings
 	TxPoolLocalsFlag = &cli.StringFlag{
 		Name:     "txpool.locals",
@@ -304,6 +310,18 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Rejournal,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolCheckpointFlag = &cli.StringFlag{
+		Name:     "txpool.checkpoint",
+		Usage:    "Disk checkpoint for all pooled transactions to survive node restarts (disabled if empty)",
+		Value:    ethconfig.Defaults.TxPool.Checkpoint,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolRecheckpointFlag = &cli.DurationFlag{
+		Name:     "txpool.recheckpoint",
+		Usage:    "Time interval to regenerate the transaction pool checkpoint",
+		Value:    ethconfig.Defaults.TxPool.Recheckpoint,
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolPriceLimitFlag = &cli.Uint64Flag{
 		Name:     "txpool.pricelimit",
 		Usage:    "Minimum gas price tip to enforce for acceptance into the pool",
@@ -346,6 +364,32 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolFeePerByteFlag = &cli.BoolFlag{
+		Name:     "txpool.feeperbyte",
+		Usage:    "Rank remote transactions by the fees paid per compressed byte for eviction and replacement",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolPrivateLifetimeFlag = &cli.Uint64Flag{
+		Name:     "txpool.private.lifetime",
+		Usage:    "Number of blocks after which privately submitted transactions are dropped if not included",
+		Value:    ethconfig.Defaults.PrivateTxLifetime,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolPolicyFlag = &cli.StringFlag{
+		Name:     "txpool.policy",
+		Usage:    "JSON file configuring transaction admission policies, reloaded when modified",
+		Category: flags.TxPoolCategory,
+	}
+	UserPoolEntryPointsFlag = &cli.StringFlag{
+		Name:     "userpool.entrypoints",
+		Usage:    "Comma separated ERC-4337 entry points to accept user operations for (disabled if empty)",
+		Category: flags.TxPoolCategory,
+	}
+	UserPoolBundlerFlag = &cli.StringFlag{
+		Name:     "userpool.bundler",
+		Usage:    "Unlocked account submitting pooled user operations as bundles to the transaction list builder",
+		Category: flags.TxPoolCategory,
+	}
 	// Blob transaction pool settings
 	BlobPoolDataDirFlag = &cli.StringFlag{
 		Name:     "blobpool.datadir",
@@ -516,6 +560,12 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	RPCResponseCacheFlag = &cli.IntFlag{
+		Name:     "rpc.response-cache",
+		Usage:    "Megabytes of memory allocated to caching RPC results of finalized blocks (0 = disabled)",
+		Value:    ethconfig.Defaults.RPCResponseCache,
+		Category: flags.APICategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -540,6 +590,11 @@ var (
 		Usage:    "Path to a JWT secret to use for authenticated RPC endpoints",
 		Category: flags.APICategory,
 	}
+	JWTSecretsDirFlag = &flags.DirectoryFlag{
+		Name:     "authrpc.jwtsecrets",
+		Usage:    "Directory of additional named JWT secrets (hex) and ES256/EdDSA public keys (PEM) for authenticated RPC endpoints, reloaded on change",
+		Category: flags.APICategory,
+	}
 
 	// Logging and debug settings
 	EthStatsURLFlag = &cli.StringFlag{
@@ -692,6 +747,88 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	ResponseMaxSize = &cli.IntFlag{
+		Name:     "rpc.response-max-size",
+		Usage:    "Maximum number of bytes returned from a single call (0 = unlimited)",
+		Value:    node.DefaultConfig.ResponseMaxSize,
+		Category: flags.APICategory,
+	}
+	RPCAllowFlag = &cli.StringFlag{
+		Name:     "rpc.allow",
+		Usage:    "Comma separated list of methods served over HTTP and WebSocket, supporting wildcards (e.g. eth_*,debug_traceTransaction)",
+		Category: flags.APICategory,
+	}
+	RPCDenyFlag = &cli.StringFlag{
+		Name:     "rpc.deny",
+		Usage:    "Comma separated list of methods refused over HTTP and WebSocket, supporting wildcards (e.g. debug_setHead,admin_*)",
+		Category: flags.APICategory,
+	}
+	AuthRPCAllowFlag = &cli.StringFlag{
+		Name:     "authrpc.allow",
+		Usage:    "Comma separated list of methods served over the authenticated endpoints, supporting wildcards (e.g. engine_*,eth_*)",
+		Category: flags.APICategory,
+	}
+	AuthRPCDenyFlag = &cli.StringFlag{
+		Name:     "authrpc.deny",
+		Usage:    "Comma separated list of methods refused over the authenticated endpoints, supporting wildcards (e.g. debug_*)",
+		Category: flags.APICategory,
+	}
+	RPCIPRateLimitFlag = &cli.Float64Flag{
+		Name:     "rpc.ratelimit.ip",
+		Usage:    "Requests per second allowed per client IP over HTTP and WebSocket (0 = unlimited)",
+		Category: flags.APICategory,
+	}
+	RPCIPRateBurstFlag = &cli.IntFlag{
+		Name:     "rpc.ratelimit.ip.burst",
+		Usage:    "Request burst allowed per client IP (0 = one second of requests)",
+		Category: flags.APICategory,
+	}
+	RPCSubjectRateLimitFlag = &cli.Float64Flag{
+		Name:     "rpc.ratelimit.subject",
+		Usage:    "Requests per second allowed per JWT subject on authenticated endpoints (0 = unlimited)",
+		Category: flags.APICategory,
+	}
+	RPCSubjectRateBurstFlag = &cli.IntFlag{
+		Name:     "rpc.ratelimit.subject.burst",
+		Usage:    "Request burst allowed per JWT subject (0 = one second of requests)",
+		Category: flags.APICategory,
+	}
+	RPCMethodWeightsFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.weights",
+		Usage:    "Comma separated list of method=weight pairs, the number of requests a call counts as (e.g. debug_traceTransaction=50)",
+		Category: flags.APICategory,
+	}
+	RPCAuditFileFlag = &cli.StringFlag{
+		Name:     "rpc.audit.file",
+		Usage:    "File to write the audit log of calls served over HTTP and WebSocket to, disabled if empty",
+		Category: flags.APICategory,
+	}
+	RPCAuditJSONFlag = &cli.BoolFlag{
+		Name:     "rpc.audit.json",
+		Usage:    "Write the audit log as JSON lines instead of logfmt",
+		Category: flags.APICategory,
+	}
+	RPCAuditMaxSizeFlag = &cli.IntFlag{
+		Name:     "rpc.audit.maxsize",
+		Usage:    "Size in megabytes at which the audit log file is rotated",
+		Value:    100,
+		Category: flags.APICategory,
+	}
+	RPCAuditMaxBackupsFlag = &cli.IntFlag{
+		Name:     "rpc.audit.maxbackups",
+		Usage:    "Maximum number of rotated audit log files to retain, all if zero",
+		Category: flags.APICategory,
+	}
+	RPCAuditSamplingFlag = &cli.StringFlag{
+		Name:     "rpc.audit.sampling",
+		Usage:    "Comma separated list of namespace=rate pairs, the fraction of calls audited, with * matching other namespaces (e.g. eth=0.01,*=1)",
+		Category: flags.APICategory,
+	}
+	RPCAuditRedactFlag = &cli.StringFlag{
+		Name:     "rpc.audit.redact",
+		Usage:    "Comma separated list of methods whose parameters are redacted from the audit log, besides the signing methods",
+		Category: flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
@@ -1145,6 +1282,90 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+
+	if ctx.IsSet(ResponseMaxSize.Name) {
+		cfg.ResponseMaxSize = ctx.Int(ResponseMaxSize.Name)
+	}
+	setRPCAccess(ctx, &cfg.RPCAccess, &cfg.AuthRPCAccess)
+	setRPCAudit(ctx, &cfg.RPCAudit)
+}
+
+// setRPCAccess configures the RPC access policies from the command line flags.
+// The authrpc allow and deny lists and the subject quota only apply to the
+// authenticated endpoints, all the other restrictions only to the public ones.
+func setRPCAccess(ctx *cli.Context, cfg *rpc.AccessPolicy, authCfg *rpc.AccessPolicy) {
+	if ctx.IsSet(RPCAllowFlag.Name) {
+		cfg.Allow = SplitAndTrim(ctx.String(RPCAllowFlag.Name))
+	}
+	if ctx.IsSet(RPCDenyFlag.Name) {
+		cfg.Deny = SplitAndTrim(ctx.String(RPCDenyFlag.Name))
+	}
+	if ctx.IsSet(AuthRPCAllowFlag.Name) {
+		authCfg.Allow = SplitAndTrim(ctx.String(AuthRPCAllowFlag.Name))
+	}
+	if ctx.IsSet(AuthRPCDenyFlag.Name) {
+		authCfg.Deny = SplitAndTrim(ctx.String(AuthRPCDenyFlag.Name))
+	}
+	if ctx.IsSet(RPCIPRateLimitFlag.Name) {
+		cfg.IPQuota.Rate = ctx.Float64(RPCIPRateLimitFlag.Name)
+	}
+	if ctx.IsSet(RPCIPRateBurstFlag.Name) {
+		cfg.IPQuota.Burst = ctx.Int(RPCIPRateBurstFlag.Name)
+	}
+	if ctx.IsSet(RPCSubjectRateLimitFlag.Name) {
+		authCfg.SubjectQuota.Rate = ctx.Float64(RPCSubjectRateLimitFlag.Name)
+	}
+	if ctx.IsSet(RPCSubjectRateBurstFlag.Name) {
+		authCfg.SubjectQuota.Burst = ctx.Int(RPCSubjectRateBurstFlag.Name)
+	}
+	if ctx.IsSet(RPCMethodWeightsFlag.Name) {
+		cfg.Weights = make(map[string]int)
+		for _, entry := range SplitAndTrim(ctx.String(RPCMethodWeightsFlag.Name)) {
+			method, weight, ok := strings.Cut(entry, "=")
+			if !ok {
+				Fatalf("Invalid RPC method weight %q, expected method=weight", entry)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(weight))
+			if err != nil {
+				Fatalf("Invalid RPC method weight %q: %v", entry, err)
+			}
+			cfg.Weights[strings.TrimSpace(method)] = n
+		}
+		authCfg.Weights = cfg.Weights
+	}
+}
+
+// setRPCAudit configures the RPC audit log from the command line flags.
+func setRPCAudit(ctx *cli.Context, cfg *rpc.AuditPolicy) {
+	if ctx.IsSet(RPCAuditFileFlag.Name) {
+		cfg.File = ctx.String(RPCAuditFileFlag.Name)
+	}
+	if ctx.IsSet(RPCAuditJSONFlag.Name) {
+		cfg.JSON = ctx.Bool(RPCAuditJSONFlag.Name)
+	}
+	if ctx.IsSet(RPCAuditMaxSizeFlag.Name) {
+		cfg.MaxSize = ctx.Int(RPCAuditMaxSizeFlag.Name)
+	}
+	if ctx.IsSet(RPCAuditMaxBackupsFlag.Name) {
+		cfg.MaxBackups = ctx.Int(RPCAuditMaxBackupsFlag.Name)
+	}
+	if ctx.IsSet(RPCAuditSamplingFlag.Name) {
+		cfg.Sampling = make(map[string]float64)
+		for _, entry := range SplitAndTrim(ctx.String(RPCAuditSamplingFlag.Name)) {
+			namespace, rate, ok := strings.Cut(entry, "=")
+			if !ok {
+				Fatalf("Invalid RPC audit sampling rate %q, expected namespace=rate", entry)
+			}
+			r, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+			if err != nil {
+				Fatalf("Invalid RPC audit sampling rate %q: %v", entry, err)
+			}
+			cfg.Sampling[strings.TrimSpace(namespace)] = r
+		}
+	}
+	if ctx.IsSet(RPCAuditRedactFlag.Name) {
+		cfg.Redact = SplitAndTrim(ctx.String(RPCAuditRedactFlag.Name))
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1289,6 +1510,27 @@ func setEtherbase(ctx *cli.Context, cfg *ethconfig.Config) {
 	cfg.Miner.Etherbase = common.BytesToAddress(b)
 }
 
+// setUserPool retrieves the user operation pool entry points and bundler from
+// the CLI flags.
+func setUserPool(ctx *cli.Context, cfg *userpool.Config) {
+	if ctx.IsSet(UserPoolEntryPointsFlag.Name) {
+		cfg.EntryPoints = nil
+		for _, addr := range SplitAndTrim(ctx.String(UserPoolEntryPointsFlag.Name)) {
+			if !common.IsHexAddress(addr) {
+				Fatalf("-%s: invalid entry point address %q", UserPoolEntryPointsFlag.Name, addr)
+			}
+			cfg.EntryPoints = append(cfg.EntryPoints, common.HexToAddress(addr))
+		}
+	}
+	if ctx.IsSet(UserPoolBundlerFlag.Name) {
+		addr := ctx.String(UserPoolBundlerFlag.Name)
+		if !common.IsHexAddress(addr) {
+			Fatalf("-%s: invalid bundler address %q", UserPoolBundlerFlag.Name, addr)
+		}
+		cfg.Bundler = common.HexToAddress(addr)
+	}
+}
+
 // MakePasswordList reads password lines from the file specified by the global --password flag.
 func MakePasswordList(ctx *cli.Context) []string {
 	path := ctx.Path(PasswordFileFlag.Name)
@@ -1364,6 +1606,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(JWTSecretFlag.Name) {
 		cfg.JWTSecret = ctx.String(JWTSecretFlag.Name)
 	}
+	if ctx.IsSet(JWTSecretsDirFlag.Name) {
+		cfg.JWTSecretsDir = ctx.String(JWTSecretsDirFlag.Name)
+	}
 
 	if ctx.IsSet(EnablePersonal.Name) {
 		cfg.EnablePersonal = true
@@ -1482,6 +1727,12 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 	if ctx.IsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.Duration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.IsSet(TxPoolCheckpointFlag.Name) {
+		cfg.Checkpoint = ctx.String(TxPoolCheckpointFlag.Name)
+	}
+	if ctx.IsSet(TxPoolRecheckpointFlag.Name) {
+		cfg.Recheckpoint = ctx.Duration(TxPoolRecheckpointFlag.Name)
+	}
 	if ctx.IsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.Uint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1503,6 +1754,9 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 	if ctx.IsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.Duration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.IsSet(TxPoolFeePerByteFlag.Name) {
+		cfg.FeePerByte = ctx.Bool(TxPoolFeePerByteFlag.Name)
+	}
 }
 
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
@@ -1602,6 +1856,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setEtherbase(ctx, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
+	if ctx.IsSet(TxPoolPrivateLifetimeFlag.Name) {
+		cfg.PrivateTxLifetime = ctx.Uint64(TxPoolPrivateLifetimeFlag.Name)
+	}
+	if ctx.IsSet(TxPoolPolicyFlag.Name) {
+		cfg.TxPolicy = ctx.String(TxPoolPolicyFlag.Name)
+	}
+	setUserPool(ctx, &cfg.UserPool)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
 	setLes(ctx, cfg)
@@ -1691,6 +1952,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheLogSizeFlag.Name) {
 		cfg.FilterLogCacheSize = ctx.Int(CacheLogSizeFlag.Name)
 	}
+	if ctx.IsSet(LogIndexFlag.Name) {
+		cfg.LogIndex = ctx.Bool(LogIndexFlag.Name)
+	}
 	if !ctx.Bool(SnapshotFlag.Name) || cfg.SnapshotCache == 0 {
 		// If snap-sync is requested, this flag is also required
 		if cfg.SyncMode == downloader.SnapSync {
@@ -1728,6 +1992,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(RPCResponseCacheFlag.Name) {
+		cfg.RPCResponseCache = ctx.Int(RPCResponseCacheFlag.Name)
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -1878,6 +2145,14 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		Fatalf("Failed to register the Ethereum service: %v", err)
 	}
 	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	// The pool admin API accesses the local file system, only serve it over the
+	// authenticated transports. It's registered here rather than by the service
+	// itself, so embedded nodes don't start the authenticated server for it.
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace:     "txpool",
+		Service:       eth.NewTxPoolAdminAPI(backend),
+		Authenticated: true,
+	}})
 	return backend.APIBackend, backend
 }
 
@@ -1903,7 +2178,7 @@ func RegisterFilterAPI(stack *node.Node, backend ethapi.Backend, ethcfg *ethconf
 	})
 	stack.RegisterAPIs([]rpc.API{{
 		Namespace: "eth",
-		Service:   filters.NewFilterAPI(filterSystem, false),
+		Service:   filters.NewStreamingFilterAPI(filters.NewFilterAPI(filterSystem, false)),
 	}})
 	return filterSystem
 }
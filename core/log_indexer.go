@@ -0,0 +1,120 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// LogIndexer implements a core.ChainIndexer, maintaining an exact index of the
+// logs in the canonical chain keyed by emitting address and by topic.
+type LogIndexer struct {
+	size    uint64         // section size to generate the log index for
+	db      ethdb.Database // database instance to write index data into
+	batch   ethdb.Batch    // batch accumulating the index data of the current section
+	section uint64         // section is the section number being processed currently
+}
+
+// NewLogIndexer returns a chain indexer that generates the log index for the
+// canonical chain, replacing the probabilistic bloombits lookups.
+func NewLogIndexer(db ethdb.Database, size, confirms uint64) *ChainIndexer {
+	backend := &LogIndexer{
+		db:   db,
+		size: size,
+	}
+	table := rawdb.NewTable(db, string(rawdb.LogIndexTablePrefix))
+
+	return NewChainIndexer(db, table, backend, size, confirms, 0, "logindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section
+// and dropping any stale index data left over from a reorged chain segment.
+func (b *LogIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.batch, b.section = b.db.NewBatch(), section
+	for number := section * b.size; number < (section+1)*b.size; number++ {
+		rawdb.DeleteLogIndex(b.db, b.batch, number)
+	}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the logs of a new header
+// into the index.
+func (b *LogIndexer) Process(ctx context.Context, header *types.Header) error {
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+	)
+	logs := rawdb.ReadLogs(b.db, hash, number)
+	if logs == nil && header.ReceiptHash != types.EmptyReceiptsHash {
+		return fmt.Errorf("receipts of block #%d [%x..] not found", number, hash[:4])
+	}
+	rawdb.WriteLogIndex(b.batch, hash, number, logs)
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the index data of the
+// section out into the database.
+func (b *LogIndexer) Commit() error {
+	return b.batch.Write()
+}
+
+// Prune returns an empty error since we don't support pruning here.
+func (b *LogIndexer) Prune(threshold uint64) error {
+	return nil
+}
+
+// RebuildLogIndex wipes the log index from the database and regenerates it for
+// the canonical chain up to the given head. The progress of the indexer is also
+// persisted, so a subsequently started node picks up from where it was left.
+func RebuildLogIndex(db ethdb.Database, head uint64) error {
+	if err := rawdb.PurgeLogIndex(db); err != nil {
+		return err
+	}
+	indexer := NewLogIndexer(db, params.LogIndexBlocks, params.LogIndexConfirms)
+	defer indexer.Close()
+
+	var (
+		sections = (head + 1) / params.LogIndexBlocks
+		start    = time.Now()
+		logged   = time.Now()
+		lastHead common.Hash
+		err      error
+	)
+	for section := uint64(0); section < sections; section++ {
+		if lastHead, err = indexer.processSection(section, lastHead); err != nil {
+			return err
+		}
+		indexer.setSectionHead(section, lastHead)
+		indexer.setValidSections(section + 1)
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Rebuilding log index", "section", section, "sections", sections, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Rebuilt log index", "sections", sections, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// LogIndexEntry lists the positions of the logs within a single block which
+// reference an indexed address or topic.
+type LogIndexEntry struct {
+	Number uint64      `rlp:"-"` // Block number of the entry, derived from the database key
+	Hash   common.Hash // Hash of the block the entry was generated from
+	Logs   []uint      // Block-wide indices of the referencing logs
+}
+
+// logIndexTopic is a position-aware topic reference written for a block.
+type logIndexTopic struct {
+	Position uint8
+	Topic    common.Hash
+}
+
+// logIndexBlock is the metadata stored for every indexed block, tracking all
+// the address and topic entries written so they can be removed on a reorg.
+type logIndexBlock struct {
+	Hash      common.Hash
+	Addresses []common.Address
+	Topics    []logIndexTopic
+}
+
+// WriteLogIndex indexes the logs of the given block by the emitting contract
+// address and by topic (including the topic position).
+func WriteLogIndex(db ethdb.KeyValueWriter, hash common.Hash, number uint64, logs [][]*types.Log) {
+	var (
+		addresses = make(map[common.Address][]uint)
+		topics    = make(map[logIndexTopic][]uint)
+		meta      = logIndexBlock{Hash: hash}
+		index     uint
+	)
+	for _, txLogs := range logs {
+		for _, l := range txLogs {
+			if _, ok := addresses[l.Address]; !ok {
+				meta.Addresses = append(meta.Addresses, l.Address)
+			}
+			addresses[l.Address] = append(addresses[l.Address], index)
+
+			for i, topic := range l.Topics {
+				key := logIndexTopic{Position: uint8(i), Topic: topic}
+				if _, ok := topics[key]; !ok {
+					meta.Topics = append(meta.Topics, key)
+				}
+				topics[key] = append(topics[key], index)
+			}
+			index++
+		}
+	}
+	for _, address := range meta.Addresses {
+		writeLogIndexEntry(db, logAddressIndexKey(address, number), &LogIndexEntry{Hash: hash, Logs: addresses[address]})
+	}
+	for _, topic := range meta.Topics {
+		writeLogIndexEntry(db, logTopicIndexKey(topic.Position, topic.Topic, number), &LogIndexEntry{Hash: hash, Logs: topics[topic]})
+	}
+	data, err := rlp.EncodeToBytes(&meta)
+	if err != nil {
+		log.Crit("Failed to encode log index metadata", "err", err)
+	}
+	if err := db.Put(logIndexBlockKey(number), data); err != nil {
+		log.Crit("Failed to store log index metadata", "err", err)
+	}
+}
+
+// writeLogIndexEntry stores a single address or topic entry of the log index.
+func writeLogIndexEntry(db ethdb.KeyValueWriter, key []byte, entry *LogIndexEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode log index entry", "err", err)
+	}
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store log index entry", "err", err)
+	}
+}
+
+// ReadLogIndexHash retrieves the hash of the block the log index was generated
+// from at the given height, or an empty hash if the height is not indexed.
+func ReadLogIndexHash(db ethdb.KeyValueReader, number uint64) common.Hash {
+	data, _ := db.Get(logIndexBlockKey(number))
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	var meta logIndexBlock
+	if err := rlp.DecodeBytes(data, &meta); err != nil {
+		log.Error("Invalid log index metadata RLP", "number", number, "err", err)
+		return common.Hash{}
+	}
+	return meta.Hash
+}
+
+// DeleteLogIndex removes all the log index entries written for the given block
+// height. The metadata is read from reader and the deletions are issued to
+// writer, allowing the removal to be batched.
+func DeleteLogIndex(reader ethdb.KeyValueReader, writer ethdb.KeyValueWriter, number uint64) {
+	data, _ := reader.Get(logIndexBlockKey(number))
+	if len(data) == 0 {
+		return
+	}
+	var meta logIndexBlock
+	if err := rlp.DecodeBytes(data, &meta); err != nil {
+		log.Error("Invalid log index metadata RLP", "number", number, "err", err)
+		return
+	}
+	for _, address := range meta.Addresses {
+		if err := writer.Delete(logAddressIndexKey(address, number)); err != nil {
+			log.Crit("Failed to delete log index entry", "err", err)
+		}
+	}
+	for _, topic := range meta.Topics {
+		if err := writer.Delete(logTopicIndexKey(topic.Position, topic.Topic, number)); err != nil {
+			log.Crit("Failed to delete log index entry", "err", err)
+		}
+	}
+	if err := writer.Delete(logIndexBlockKey(number)); err != nil {
+		log.Crit("Failed to delete log index metadata", "err", err)
+	}
+}
+
+// ReadLogIndexByAddress retrieves all the log index entries of the given address
+// within the inclusive [from, to] block range, in ascending block order.
+func ReadLogIndexByAddress(db ethdb.Iteratee, address common.Address, from, to uint64) ([]*LogIndexEntry, error) {
+	prefix := append(append([]byte{}, logAddressIndexPrefix...), address.Bytes()...)
+	return readLogIndexEntries(db, prefix, from, to)
+}
+
+// ReadLogIndexByTopic retrieves all the log index entries of the given topic at
+// the given position within the inclusive [from, to] block range, in ascending
+// block order.
+func ReadLogIndexByTopic(db ethdb.Iteratee, position uint8, topic common.Hash, from, to uint64) ([]*LogIndexEntry, error) {
+	prefix := append(append(append([]byte{}, logTopicIndexPrefix...), position), topic.Bytes()...)
+	return readLogIndexEntries(db, prefix, from, to)
+}
+
+// readLogIndexEntries iterates over the log index entries stored under the given
+// prefix, decoding the ones within the inclusive [from, to] block range.
+func readLogIndexEntries(db ethdb.Iteratee, prefix []byte, from, to uint64) ([]*LogIndexEntry, error) {
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var entries []*LogIndexEntry
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(prefix):])
+		if number > to {
+			break
+		}
+		entry := new(LogIndexEntry)
+		if err := rlp.DecodeBytes(it.Value(), entry); err != nil {
+			return nil, err
+		}
+		entry.Number = number
+		entries = append(entries, entry)
+	}
+	return entries, it.Error()
+}
+
+// PurgeLogIndex removes the entire log index from the database, including the
+// progress metadata of the chain indexer maintaining it.
+func PurgeLogIndex(db ethdb.Database) error {
+	for _, prefix := range [][]byte{logAddressIndexPrefix, logTopicIndexPrefix, logIndexBlockPrefix, LogIndexTablePrefix} {
+		it := db.NewIterator(prefix, nil)
+		batch := db.NewBatch()
+		for it.Next() {
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return err
+			}
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
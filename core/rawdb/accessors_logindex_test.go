@@ -0,0 +1,82 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that log index entries can be written, looked up by range and removed.
+func TestLogIndexStorage(t *testing.T) {
+	var (
+		db     = NewMemoryDatabase()
+		addr1  = common.Address{0x01}
+		addr2  = common.Address{0x02}
+		topic1 = common.Hash{0x11}
+		topic2 = common.Hash{0x22}
+	)
+	for number := uint64(1); number <= 3; number++ {
+		logs := [][]*types.Log{
+			{{Address: addr1, Topics: []common.Hash{topic1}}},
+			{{Address: addr2, Topics: []common.Hash{topic2, topic1}}, {Address: addr1}},
+		}
+		WriteLogIndex(db, common.Hash{byte(number)}, number, logs)
+	}
+	entries, err := ReadLogIndexByAddress(db, addr1, 2, 3)
+	if err != nil {
+		t.Fatalf("failed to read address entries: %v", err)
+	}
+	want := []*LogIndexEntry{
+		{Number: 2, Hash: common.Hash{0x02}, Logs: []uint{0, 2}},
+		{Number: 3, Hash: common.Hash{0x03}, Logs: []uint{0, 2}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("address entries mismatch: have %v, want %v", entries, want)
+	}
+	// Topics are indexed by position
+	if entries, _ := ReadLogIndexByTopic(db, 0, topic1, 1, 1); len(entries) != 1 || !reflect.DeepEqual(entries[0].Logs, []uint{0}) {
+		t.Fatalf("topic entries mismatch at position 0: %v", entries)
+	}
+	if entries, _ := ReadLogIndexByTopic(db, 1, topic1, 1, 1); len(entries) != 1 || !reflect.DeepEqual(entries[0].Logs, []uint{1}) {
+		t.Fatalf("topic entries mismatch at position 1: %v", entries)
+	}
+	if hash := ReadLogIndexHash(db, 2); hash != (common.Hash{0x02}) {
+		t.Fatalf("indexed hash mismatch: have %x, want %x", hash, common.Hash{0x02})
+	}
+	// Remove a block and ensure all its entries are gone
+	DeleteLogIndex(db, db, 2)
+	if entries, _ := ReadLogIndexByAddress(db, addr1, 1, 3); len(entries) != 2 {
+		t.Fatalf("unexpected address entries after deletion: %d", len(entries))
+	}
+	if entries, _ := ReadLogIndexByTopic(db, 0, topic2, 2, 2); len(entries) != 0 {
+		t.Fatalf("unexpected topic entries after deletion: %d", len(entries))
+	}
+	if hash := ReadLogIndexHash(db, 2); hash != (common.Hash{}) {
+		t.Fatalf("unexpected indexed hash after deletion: %x", hash)
+	}
+	// Purge everything
+	if err := PurgeLogIndex(db); err != nil {
+		t.Fatalf("failed to purge log index: %v", err)
+	}
+	if entries, _ := ReadLogIndexByAddress(db, addr1, 0, 10); len(entries) != 0 {
+		t.Fatalf("unexpected address entries after purge: %d", len(entries))
+	}
+}
@@ -134,6 +134,13 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
+	logAddressIndexPrefix = []byte("logidx-a") // logAddressIndexPrefix + address + num (uint64 big endian) -> log index entry
+	logTopicIndexPrefix   = []byte("logidx-t") // logTopicIndexPrefix + position (uint8) + topic + num (uint64 big endian) -> log index entry
+	logIndexBlockPrefix   = []byte("logidx-b") // logIndexBlockPrefix + num (uint64 big endian) -> log index block metadata
+
+	// LogIndexTablePrefix is the data table of a chain indexer to track the log index progress
+	LogIndexTablePrefix = []byte("logidxIndex-")
+
 	BestUpdateKey         = []byte("update-")    // bigEndian64(syncPeriod) -> RLP(types.LightClientUpdate)  (nextCommittee only referenced by root hash)
 	FixedCommitteeRootKey = []byte("fixedRoot-") // bigEndian64(syncPeriod) -> committee root hash
 	SyncCommitteeKey      = []byte("committee-") // bigEndian64(syncPeriod) -> serialized committee
@@ -226,6 +233,22 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// logAddressIndexKey = logAddressIndexPrefix + address + num (uint64 big endian)
+func logAddressIndexKey(address common.Address, number uint64) []byte {
+	return append(append(append([]byte{}, logAddressIndexPrefix...), address.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// logTopicIndexKey = logTopicIndexPrefix + position (uint8) + topic + num (uint64 big endian)
+func logTopicIndexKey(position uint8, topic common.Hash, number uint64) []byte {
+	key := append(append([]byte{}, logTopicIndexPrefix...), position)
+	return append(append(key, topic.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// logIndexBlockKey = logIndexBlockPrefix + num (uint64 big endian)
+func logIndexBlockKey(number uint64) []byte {
+	return append(append([]byte{}, logIndexBlockPrefix...), encodeBlockNumber(number)...)
+}
+
 // skeletonHeaderKey = skeletonHeaderPrefix + num (uint64 big endian)
 func skeletonHeaderKey(number uint64) []byte {
 	return append(skeletonHeaderPrefix, encodeBlockNumber(number)...)
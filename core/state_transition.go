@@ -526,8 +526,14 @@ func (st *StateTransition) blobGasUsed() uint64 {
 
 // CHANGE(taiko): returns the treasury address based on chain ID.
 func (st *StateTransition) getTreasuryAddress() common.Address {
+	return TreasuryAddress(st.evm.ChainConfig())
+}
+
+// TreasuryAddress returns the address receiving the non-shared part of the
+// base fee on a Taiko chain, which is derived from the chain ID.
+func TreasuryAddress(config *params.ChainConfig) common.Address {
 	var (
-		prefix = st.evm.ChainConfig().ChainID.String()
+		prefix = config.ChainID.String()
 		suffix = "10001"
 	)
 	return common.HexToAddress(
@@ -1090,6 +1090,11 @@ func (p *BlobPool) validateTx(tx *types.Transaction) error {
 	if err := txpool.ValidateTransaction(tx, p.head, p.signer, baseOpts); err != nil {
 		return err
 	}
+	// Ensure the transaction adheres to the operator's admission policies
+	from, _ := p.signer.Sender(tx) // already validated above
+	if err := p.config.Policies.Check(tx, from); err != nil {
+		return err
+	}
 	// Ensure the transaction adheres to the stateful pool filters (nonce, balance)
 	stateOpts := &txpool.ValidationOptionsWithState{
 		State: p.state,
@@ -1126,10 +1131,7 @@ func (p *BlobPool) validateTx(tx *types.Transaction) error {
 	}
 	// If the transaction replaces an existing one, ensure that price bumps are
 	// adhered to.
-	var (
-		from, _ = p.signer.Sender(tx) // already validated above
-		next    = p.state.GetNonce(from)
-	)
+	next := p.state.GetNonce(from)
 	if uint64(len(p.index[from])) > tx.Nonce()-next {
 		// Account can support the replacement, but the price bump must also be met
 		prev := p.index[from][int(tx.Nonce()-next)]
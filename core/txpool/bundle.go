@@ -0,0 +1,89 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Bundle is a list of transactions to be included atomically and in order in a
+// block within a target range. A bundle is only included if all its members
+// succeed, except for the ones which are allowed to revert.
+type Bundle struct {
+	Txs               []*types.Transaction // Transactions to include in order
+	MinBlock          uint64               // First block the bundle may be included in, any if zero
+	MaxBlock          uint64               // Last block the bundle may be included in
+	RevertingTxHashes []common.Hash        // Members which may revert without failing the bundle
+}
+
+// Hash returns the hash identifying the bundle, the hash of the concatenated
+// hashes of its transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// CanRevert reports whether the transaction may revert without failing the bundle.
+func (b *Bundle) CanRevert(hash common.Hash) bool {
+	for _, h := range b.RevertingTxHashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Targets reports whether the bundle may be included in the given block.
+func (b *Bundle) Targets(number uint64) bool {
+	return b.MinBlock <= number && number <= b.MaxBlock
+}
+
+// bundler is implemented by subpools which maintain bundles.
+type bundler interface {
+	// AddBundle validates the bundle and adds it to the pool.
+	AddBundle(bundle *Bundle) error
+
+	// Bundles retrieves the bundles which may be included in the given block, in
+	// the order they were added.
+	Bundles(number uint64) []*Bundle
+}
+
+// AddBundle adds a bundle to the subpool maintaining bundles.
+func (p *TxPool) AddBundle(bundle *Bundle) error {
+	for _, subpool := range p.subpools {
+		if bundler, ok := subpool.(bundler); ok {
+			return bundler.AddBundle(bundle)
+		}
+	}
+	return ErrBundlesUnsupported
+}
+
+// Bundles retrieves the bundles which may be included in the given block.
+func (p *TxPool) Bundles(number uint64) []*Bundle {
+	var bundles []*Bundle
+	for _, subpool := range p.subpools {
+		if bundler, ok := subpool.(bundler); ok {
+			bundles = append(bundles, bundler.Bundles(number)...)
+		}
+	}
+	return bundles
+}
@@ -0,0 +1,450 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bundlepool implements a subpool maintaining transaction bundles.
+package bundlepool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// txMaxSize is the maximum size of a bundled transaction, the same as that of
+// a transaction in the legacy pool.
+const txMaxSize = 128 * 1024
+
+var (
+	// ErrEmptyBundle is returned if a bundle has no transactions.
+	ErrEmptyBundle = errors.New("empty bundle")
+
+	// ErrBundleTooLarge is returned if a bundle has more transactions than allowed.
+	ErrBundleTooLarge = errors.New("bundle too large")
+
+	// ErrBundlePoolFull is returned if the pool holds the maximum number of bundles,
+	// none of which pays less than the new one.
+	ErrBundlePoolFull = errors.New("bundle pool is full")
+
+	// ErrSenderBundleLimit is returned if the sender of a bundle already has the
+	// maximum number of bundles in the pool.
+	ErrSenderBundleLimit = errors.New("too many bundles of sender")
+
+	// ErrInvalidBlockRange is returned if the target block range of a bundle is
+	// empty, in the past or too far in the future.
+	ErrInvalidBlockRange = errors.New("invalid bundle block range")
+
+	// ErrUnknownRevertingTx is returned if a transaction allowed to revert is not
+	// part of the bundle.
+	ErrUnknownRevertingTx = errors.New("reverting transaction not in bundle")
+)
+
+var (
+	bundleGauge   = metrics.NewRegisteredGauge("bundlepool/bundles", nil)
+	evictionMeter = metrics.NewRegisteredMeter("bundlepool/evicted", nil)
+)
+
+// BlockChain defines the minimal set of methods needed to back a bundle pool
+// with a chain. Exists to allow mocking the live chain out of tests.
+type BlockChain interface {
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// StateAt returns a state database for a given root hash (generally the head).
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// pooledBundle is a bundle maintained by the pool.
+type pooledBundle struct {
+	bundle *txpool.Bundle
+	hash   common.Hash
+	sender common.Address // Sender of the first transaction, the bundle is accounted to
+	price  *big.Int       // Average effective tip per gas at the current head
+}
+
+// BundlePool maintains the bundles submitted by searchers until they are either
+// included or leave their target block range. It accepts no individual
+// transactions, and the bundled ones are neither announced nor served to the
+// network: bundles are only handed out to the block builder.
+//
+// Bundles are validated against the head state, so only funded ones are accepted.
+// Each one is accounted to the sender of its first transaction, who may only hold
+// a limited number of them. Once the pool is full, the bundles paying the lowest
+// average tip are evicted in favour of better paying ones.
+type BundlePool struct {
+	config Config
+	chain  BlockChain
+	signer types.Signer
+
+	head    *types.Header                 // Current head of the chain
+	state   *state.StateDB                // Current state at the head of the chain
+	gasTip  *big.Int                      // Minimum average tip per gas of bundles
+	bundles []*pooledBundle               // Bundles in the order they were added
+	known   map[common.Hash]*pooledBundle // Bundles by hash
+	senders map[common.Address]int        // Number of bundles accounted to each sender
+
+	txFeed event.Feed // Never fired, bundles aren't announced
+	lock   sync.RWMutex
+}
+
+// New creates a new bundle pool. The pool is not usable until it is initialized
+// by the main transaction pool.
+func New(config Config, chain BlockChain) *BundlePool {
+	return &BundlePool{
+		config:  config.sanitize(),
+		chain:   chain,
+		signer:  types.LatestSigner(chain.Config()),
+		gasTip:  new(big.Int),
+		known:   make(map[common.Hash]*pooledBundle),
+		senders: make(map[common.Address]int),
+	}
+}
+
+// Filter returns false for all transactions, as bundles aren't assembled from
+// individually submitted ones.
+func (p *BundlePool) Filter(tx *types.Transaction) bool {
+	return false
+}
+
+// Init sets the head and the minimum tip the bundles are validated against.
+func (p *BundlePool) Init(gasTip uint64, head *types.Header, reserve txpool.AddressReserver) error {
+	statedb, err := p.chain.StateAt(head.Root)
+	if err != nil {
+		return err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head, p.state = head, statedb
+	p.gasTip = new(big.Int).SetUint64(gasTip)
+	return nil
+}
+
+// Close terminates the pool.
+func (p *BundlePool) Close() error {
+	return nil
+}
+
+// Reset drops the bundles whose target block range ended or whose transactions
+// can't be included anymore at the new head, and reprices the others.
+func (p *BundlePool) Reset(oldHead, newHead *types.Header) {
+	statedb, err := p.chain.StateAt(newHead.Root)
+	if err != nil {
+		log.Error("Failed to reset bundlepool state", "err", err)
+		statedb = nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.head = newHead
+	if statedb != nil {
+		p.state = statedb
+	}
+	number := newHead.Number.Uint64()
+	bundles := p.bundles[:0]
+	for _, pb := range p.bundles {
+		if pb.bundle.MaxBlock > number && (statedb == nil || p.validateState(pb.bundle, statedb) == nil) {
+			pb.price = bundlePrice(pb.bundle, newHead.BaseFee)
+			bundles = append(bundles, pb)
+			continue
+		}
+		p.untrack(pb)
+	}
+	for i := len(bundles); i < len(p.bundles); i++ {
+		p.bundles[i] = nil
+	}
+	if dropped := len(p.bundles) - len(bundles); dropped > 0 {
+		log.Debug("Dropped stale bundles", "count", dropped, "head", number)
+	}
+	p.bundles = bundles
+	bundleGauge.Update(int64(len(p.bundles)))
+}
+
+// SetGasTip updates the minimum average tip per gas required of new bundles.
+func (p *BundlePool) SetGasTip(tip *big.Int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.gasTip = new(big.Int).Set(tip)
+}
+
+// Has returns false for all transactions, bundled ones are not exposed to the
+// network.
+func (p *BundlePool) Has(hash common.Hash) bool {
+	return false
+}
+
+// Get returns nil for all transactions, bundled ones are not exposed to the
+// network.
+func (p *BundlePool) Get(hash common.Hash) *types.Transaction {
+	return nil
+}
+
+// Add rejects all transactions, bundles are added through AddBundle.
+func (p *BundlePool) Add(txs []*types.Transaction, local bool, sync bool) []error {
+	errs := make([]error, len(txs))
+	for i := range errs {
+		errs[i] = core.ErrTxTypeNotSupported
+	}
+	return errs
+}
+
+// AddBundle validates the bundle against the current head and adds it to the
+// pool. If the pool is full, the lowest paying bundle is evicted for it, unless
+// the new one doesn't pay more.
+func (p *BundlePool) AddBundle(bundle *txpool.Bundle) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err := p.validateBundle(bundle); err != nil {
+		return err
+	}
+	hash := bundle.Hash()
+	if p.known[hash] != nil {
+		return txpool.ErrAlreadyKnown
+	}
+	price := bundlePrice(bundle, p.head.BaseFee)
+	if price.Cmp(p.gasTip) < 0 {
+		return fmt.Errorf("%w: average tip %v, minimum needed %v", txpool.ErrUnderpriced, price, p.gasTip)
+	}
+	sender, _ := types.Sender(p.signer, bundle.Txs[0]) // already validated
+	if p.senders[sender] >= p.config.MaxBundlesPerSender {
+		return fmt.Errorf("%w: %d bundles of %x", ErrSenderBundleLimit, p.senders[sender], sender)
+	}
+	if len(p.bundles) >= p.config.MaxBundles {
+		cheapest := p.cheapest()
+		if cheapest.price.Cmp(price) >= 0 {
+			return fmt.Errorf("%w: average tip %v, lowest pooled %v", ErrBundlePoolFull, price, cheapest.price)
+		}
+		p.remove(cheapest)
+		evictionMeter.Mark(1)
+		log.Debug("Evicted underpriced bundle", "hash", cheapest.hash, "price", cheapest.price)
+	}
+	pb := &pooledBundle{bundle: bundle, hash: hash, sender: sender, price: price}
+	p.bundles = append(p.bundles, pb)
+	p.known[hash] = pb
+	p.senders[sender]++
+	bundleGauge.Update(int64(len(p.bundles)))
+
+	log.Debug("Added bundle", "hash", hash, "txs", len(bundle.Txs), "minBlock", bundle.MinBlock, "maxBlock", bundle.MaxBlock, "price", price)
+	return nil
+}
+
+// cheapest returns the bundle paying the lowest average tip, the oldest one of
+// those paying the same.
+func (p *BundlePool) cheapest() *pooledBundle {
+	var cheapest *pooledBundle
+	for _, pb := range p.bundles {
+		if cheapest == nil || pb.price.Cmp(cheapest.price) < 0 {
+			cheapest = pb
+		}
+	}
+	return cheapest
+}
+
+// remove drops a bundle from the pool.
+func (p *BundlePool) remove(pb *pooledBundle) {
+	for i, b := range p.bundles {
+		if b == pb {
+			p.bundles = append(p.bundles[:i], p.bundles[i+1:]...)
+			break
+		}
+	}
+	p.untrack(pb)
+}
+
+// untrack drops a bundle from the lookups of the pool.
+func (p *BundlePool) untrack(pb *pooledBundle) {
+	delete(p.known, pb.hash)
+	if p.senders[pb.sender]--; p.senders[pb.sender] <= 0 {
+		delete(p.senders, pb.sender)
+	}
+}
+
+// validateBundle checks the bundle and its transactions against the consensus
+// rules, the pool limits and the head state.
+func (p *BundlePool) validateBundle(bundle *txpool.Bundle) error {
+	if len(bundle.Txs) == 0 {
+		return ErrEmptyBundle
+	}
+	if len(bundle.Txs) > p.config.MaxTxs {
+		return fmt.Errorf("%w: %d transactions, maximum %d", ErrBundleTooLarge, len(bundle.Txs), p.config.MaxTxs)
+	}
+	next := p.head.Number.Uint64() + 1
+	if bundle.MaxBlock < next || bundle.MaxBlock < bundle.MinBlock || bundle.MaxBlock > next+p.config.MaxLifetime {
+		return fmt.Errorf("%w: [%d, %d] at block %d", ErrInvalidBlockRange, bundle.MinBlock, bundle.MaxBlock, next)
+	}
+	opts := &txpool.ValidationOptions{
+		Config: p.chain.Config(),
+		Accept: 0 |
+			1<<types.LegacyTxType |
+			1<<types.AccessListTxType |
+			1<<types.DynamicFeeTxType,
+		MaxSize: txMaxSize,
+		MinTip:  new(big.Int),
+	}
+	members := make(map[common.Hash]bool, len(bundle.Txs))
+	for i, tx := range bundle.Txs {
+		if err := txpool.ValidateTransaction(tx, p.head, p.signer, opts); err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+		// Bundles must not smuggle in transactions the other subpools would reject
+		from, _ := types.Sender(p.signer, tx) // already validated
+		if err := p.config.Policies.Check(tx, from); err != nil {
+			return fmt.Errorf("transaction %d: %w", i, err)
+		}
+		members[tx.Hash()] = true
+	}
+	for _, hash := range bundle.RevertingTxHashes {
+		if !members[hash] {
+			return fmt.Errorf("%w: %x", ErrUnknownRevertingTx, hash)
+		}
+	}
+	return p.validateState(bundle, p.state)
+}
+
+// validateState checks that the bundled transactions can be executed on top of
+// the given state: the first transaction of every sender may not be obsoleted by
+// an included one, the following ones must continue its nonce, and the sender
+// must be able to afford all of them.
+func (p *BundlePool) validateState(bundle *txpool.Bundle, statedb *state.StateDB) error {
+	var (
+		nonces = make(map[common.Address]uint64)
+		costs  = make(map[common.Address]*big.Int)
+	)
+	for i, tx := range bundle.Txs {
+		from, _ := types.Sender(p.signer, tx) // already validated
+		next, seen := nonces[from]
+		switch {
+		case !seen:
+			if next = statedb.GetNonce(from); tx.Nonce() < next {
+				return fmt.Errorf("transaction %d: %w: next nonce %v, tx nonce %v", i, core.ErrNonceTooLow, next, tx.Nonce())
+			}
+		case tx.Nonce() < next:
+			return fmt.Errorf("transaction %d: %w: next nonce %v, tx nonce %v", i, core.ErrNonceTooLow, next, tx.Nonce())
+		case tx.Nonce() > next:
+			return fmt.Errorf("transaction %d: %w: next nonce %v, tx nonce %v", i, core.ErrNonceTooHigh, next, tx.Nonce())
+		}
+		nonces[from] = tx.Nonce() + 1
+
+		cost, ok := costs[from]
+		if !ok {
+			cost = new(big.Int)
+			costs[from] = cost
+		}
+		cost.Add(cost, tx.Cost())
+		if balance := statedb.GetBalance(from).ToBig(); balance.Cmp(cost) < 0 {
+			return fmt.Errorf("transaction %d: %w: balance %v, bundle cost %v", i, core.ErrInsufficientFunds, balance, cost)
+		}
+	}
+	return nil
+}
+
+// bundlePrice returns the average effective tip per gas the bundled transactions
+// pay at the given base fee, weighted by their gas limits.
+func bundlePrice(bundle *txpool.Bundle, baseFee *big.Int) *big.Int {
+	var (
+		tips = new(big.Int)
+		gas  = new(big.Int)
+	)
+	for _, tx := range bundle.Txs {
+		tip, err := tx.EffectiveGasTip(baseFee)
+		if err != nil {
+			tip = new(big.Int) // fee cap below the base fee, pays nothing for now
+		}
+		limit := new(big.Int).SetUint64(tx.Gas())
+		tips.Add(tips, tip.Mul(tip, limit))
+		gas.Add(gas, limit)
+	}
+	if gas.Sign() == 0 {
+		return tips
+	}
+	return tips.Div(tips, gas)
+}
+
+// Bundles retrieves the bundles which may be included in the given block, the
+// best paying ones first.
+func (p *BundlePool) Bundles(number uint64) []*txpool.Bundle {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var targeting []*pooledBundle
+	for _, pb := range p.bundles {
+		if pb.bundle.Targets(number) {
+			targeting = append(targeting, pb)
+		}
+	}
+	sort.SliceStable(targeting, func(i, j int) bool {
+		return targeting[i].price.Cmp(targeting[j].price) > 0
+	})
+	bundles := make([]*txpool.Bundle, len(targeting))
+	for i, pb := range targeting {
+		bundles[i] = pb.bundle
+	}
+	return bundles
+}
+
+// Pending returns no transactions, bundles are retrieved through Bundles.
+func (p *BundlePool) Pending(filter txpool.PendingFilter) map[common.Address][]*txpool.LazyTransaction {
+	return nil
+}
+
+// SubscribeTransactions subscribes to new transaction events, which the bundle
+// pool never fires.
+func (p *BundlePool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription {
+	return p.txFeed.Subscribe(ch)
+}
+
+// Nonce returns zero, bundled transactions don't advance the pool nonces.
+func (p *BundlePool) Nonce(addr common.Address) uint64 {
+	return 0
+}
+
+// Stats returns zero counts, bundled transactions are not tracked individually.
+func (p *BundlePool) Stats() (int, int) {
+	return 0, 0
+}
+
+// Content returns no transactions, bundled ones are not exposed.
+func (p *BundlePool) Content() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	return make(map[common.Address][]*types.Transaction), make(map[common.Address][]*types.Transaction)
+}
+
+// ContentFrom returns no transactions, bundled ones are not exposed.
+func (p *BundlePool) ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
+	return []*types.Transaction{}, []*types.Transaction{}
+}
+
+// Locals returns no accounts, the bundle pool has no notion of locality.
+func (p *BundlePool) Locals() []common.Address {
+	return nil
+}
+
+// Status returns unknown for all transactions, bundled ones are not exposed.
+func (p *BundlePool) Status(hash common.Hash) txpool.TxStatus {
+	return txpool.TxStatusUnknown
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundlepool
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// testBlockChain is a mock of the live chain for testing the pool.
+type testBlockChain struct {
+	statedb *state.StateDB
+}
+
+func (bc *testBlockChain) Config() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return bc.statedb, nil
+}
+
+// makeTx creates a signed transfer with the given nonce.
+func makeTx(nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	return pricedTx(nonce, 1, key)
+}
+
+// pricedTx creates a signed transfer with the given nonce and tip.
+func pricedTx(nonce uint64, tip int64, key *ecdsa.PrivateKey) *types.Transaction {
+	return types.MustSignNewTx(key, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(params.InitialBaseFee + tip),
+		Gas:       params.TxGas,
+		To:        &common.Address{},
+	})
+}
+
+// makeHeader creates a header with the given number.
+func makeHeader(number int64) *types.Header {
+	return &types.Header{Number: big.NewInt(number), GasLimit: 30_000_000, BaseFee: big.NewInt(params.InitialBaseFee)}
+}
+
+// newTestPool creates a pool at block 10 with the given config, and funded keys.
+func newTestPool(t *testing.T, config Config, keys int) (*BundlePool, *state.StateDB, []*ecdsa.PrivateKey) {
+	t.Helper()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	funded := make([]*ecdsa.PrivateKey, keys)
+	for i := range funded {
+		funded[i], _ = crypto.GenerateKey()
+		statedb.AddBalance(crypto.PubkeyToAddress(funded[i].PublicKey), uint256.NewInt(params.Ether))
+	}
+	pool := New(config, &testBlockChain{statedb})
+	if err := pool.Init(0, makeHeader(10), nil); err != nil {
+		t.Fatal(err)
+	}
+	return pool, statedb, funded
+}
+
+func TestAddBundle(t *testing.T) {
+	pool, statedb, keys := newTestPool(t, Config{MaxBundles: 2, MaxBundlesPerSender: 4, MaxTxs: 2, MaxLifetime: 10}, 1)
+	key := keys[0]
+	tx0, tx1, tx2 := makeTx(0, key), makeTx(1, key), makeTx(2, key)
+
+	for i, test := range []struct {
+		bundle *txpool.Bundle
+		err    error
+	}{
+		{&txpool.Bundle{MaxBlock: 11}, ErrEmptyBundle},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx0, tx1, tx2}, MaxBlock: 11}, ErrBundleTooLarge},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx0}, MaxBlock: 10}, ErrInvalidBlockRange},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx0}, MinBlock: 12, MaxBlock: 11}, ErrInvalidBlockRange},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx0}, MaxBlock: 22}, ErrInvalidBlockRange},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx0}, MaxBlock: 11, RevertingTxHashes: []common.Hash{tx1.Hash()}}, ErrUnknownRevertingTx},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx0, tx1}, MaxBlock: 11, RevertingTxHashes: []common.Hash{tx1.Hash()}}, nil},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx0, tx1}, MaxBlock: 11}, txpool.ErrAlreadyKnown},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx1}, MinBlock: 12, MaxBlock: 13}, nil},
+		{&txpool.Bundle{Txs: []*types.Transaction{tx2}, MaxBlock: 13}, ErrBundlePoolFull},
+	} {
+		if err := pool.AddBundle(test.bundle); !errors.Is(err, test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+	if bundles := pool.Bundles(11); len(bundles) != 1 || len(bundles[0].Txs) != 2 {
+		t.Fatalf("wrong bundles targeting block 11: %v", bundles)
+	}
+	if bundles := pool.Bundles(12); len(bundles) != 1 || bundles[0].Txs[0] != tx1 {
+		t.Fatalf("wrong bundles targeting block 12: %v", bundles)
+	}
+	// Include the first transaction, obsoleting the second bundle, and move past
+	// the range of the first one
+	statedb.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 1)
+	pool.Reset(makeHeader(10), makeHeader(11))
+	if bundles := pool.Bundles(12); len(bundles) != 1 || bundles[0].Txs[0] != tx1 {
+		t.Fatalf("wrong bundles after reset: %v", bundles)
+	}
+	statedb.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 2)
+	pool.Reset(makeHeader(11), makeHeader(12))
+	if bundles := pool.Bundles(13); len(bundles) != 0 {
+		t.Fatalf("obsolete bundle not dropped: %v", bundles)
+	}
+}
+
+func TestAddBundleState(t *testing.T) {
+	pool, statedb, keys := newTestPool(t, Config{MaxBundles: 4, MaxBundlesPerSender: 4, MaxTxs: 2, MaxLifetime: 10}, 1)
+	statedb.SetNonce(crypto.PubkeyToAddress(keys[0].PublicKey), 1)
+	unfunded, _ := crypto.GenerateKey()
+
+	for i, test := range []struct {
+		bundle *txpool.Bundle
+		err    error
+	}{
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(0, unfunded)}, MaxBlock: 11}, core.ErrInsufficientFunds},
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(0, keys[0])}, MaxBlock: 11}, core.ErrNonceTooLow},
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(1, keys[0]), makeTx(1, keys[0])}, MaxBlock: 11}, core.ErrNonceTooLow},
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(1, keys[0]), makeTx(3, keys[0])}, MaxBlock: 11}, core.ErrNonceTooHigh},
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(1, keys[0]), makeTx(2, keys[0])}, MaxBlock: 11}, nil},
+	} {
+		if err := pool.AddBundle(test.bundle); !errors.Is(err, test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+	// Drain the account, the bundle can't be paid for anymore
+	statedb.SetBalance(crypto.PubkeyToAddress(keys[0].PublicKey), new(uint256.Int))
+	pool.Reset(makeHeader(10), makeHeader(10))
+	if bundles := pool.Bundles(11); len(bundles) != 0 {
+		t.Fatalf("unfunded bundle not dropped: %v", bundles)
+	}
+}
+
+// Tests that bundles containing transactions rejected by the admission policies
+// are rejected as a whole.
+func TestAddBundlePolicies(t *testing.T) {
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	allowed, _ := crypto.GenerateKey()
+	blocked, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(allowed.PublicKey), uint256.NewInt(params.Ether))
+	statedb.AddBalance(crypto.PubkeyToAddress(blocked.PublicKey), uint256.NewInt(params.Ether))
+
+	policies, err := txpool.NewPolicySet("", (&txpool.PolicyConfig{BlockedSenders: []common.Address{crypto.PubkeyToAddress(blocked.PublicKey)}}).Policies()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer policies.Close()
+
+	pool := New(Config{MaxBundles: 4, MaxBundlesPerSender: 4, MaxTxs: 2, MaxLifetime: 10, Policies: policies}, &testBlockChain{statedb})
+	if err := pool.Init(0, makeHeader(10), nil); err != nil {
+		t.Fatal(err)
+	}
+	err = pool.AddBundle(&txpool.Bundle{Txs: []*types.Transaction{makeTx(0, allowed), makeTx(0, blocked)}, MaxBlock: 11})
+	var perr *txpool.PolicyError
+	if !errors.As(err, &perr) || perr.Policy != "blocked-senders" || !errors.Is(err, txpool.ErrPolicyRejected) {
+		t.Fatalf("bundle with blocked sender not rejected: %v", err)
+	}
+	if err := pool.AddBundle(&txpool.Bundle{Txs: []*types.Transaction{makeTx(0, allowed)}, MaxBlock: 11}); err != nil {
+		t.Fatalf("bundle of allowed sender rejected: %v", err)
+	}
+	if bundles := pool.Bundles(11); len(bundles) != 1 || len(bundles[0].Txs) != 1 {
+		t.Fatalf("wrong bundles targeting block 11: %v", bundles)
+	}
+}
+
+func TestBundleSenderLimit(t *testing.T) {
+	pool, _, keys := newTestPool(t, Config{MaxBundles: 4, MaxBundlesPerSender: 2, MaxTxs: 2, MaxLifetime: 10}, 2)
+
+	for i, test := range []struct {
+		bundle *txpool.Bundle
+		err    error
+	}{
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(0, keys[0])}, MaxBlock: 11}, nil},
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(1, keys[0])}, MaxBlock: 11}, nil},
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(2, keys[0])}, MaxBlock: 11}, ErrSenderBundleLimit},
+		{&txpool.Bundle{Txs: []*types.Transaction{makeTx(0, keys[1]), makeTx(2, keys[0])}, MaxBlock: 11}, nil},
+	} {
+		if err := pool.AddBundle(test.bundle); !errors.Is(err, test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestBundleEviction(t *testing.T) {
+	pool, _, keys := newTestPool(t, Config{MaxBundles: 2, MaxBundlesPerSender: 2, MaxTxs: 2, MaxLifetime: 10}, 3)
+
+	var (
+		cheap  = &txpool.Bundle{Txs: []*types.Transaction{pricedTx(0, 1, keys[0])}, MaxBlock: 11}
+		medium = &txpool.Bundle{Txs: []*types.Transaction{pricedTx(0, 2, keys[1])}, MaxBlock: 11}
+		dear   = &txpool.Bundle{Txs: []*types.Transaction{pricedTx(0, 3, keys[2])}, MaxBlock: 11}
+	)
+	if err := pool.AddBundle(medium); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.AddBundle(cheap); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.AddBundle(&txpool.Bundle{Txs: []*types.Transaction{pricedTx(1, 1, keys[0])}, MaxBlock: 11}); !errors.Is(err, ErrBundlePoolFull) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrBundlePoolFull)
+	}
+	if err := pool.AddBundle(dear); err != nil {
+		t.Fatal(err)
+	}
+	bundles := pool.Bundles(11)
+	if len(bundles) != 2 || bundles[0] != dear || bundles[1] != medium {
+		t.Fatalf("wrong bundles after eviction: %v", bundles)
+	}
+	pool.SetGasTip(big.NewInt(4))
+	if err := pool.AddBundle(&txpool.Bundle{Txs: []*types.Transaction{pricedTx(1, 3, keys[2])}, MaxBlock: 11}); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("error mismatch: have %v, want %v", err, txpool.ErrUnderpriced)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundlepool
+
+import (
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Config are the configuration parameters of the bundle pool.
+type Config struct {
+	MaxBundles          int    // Maximum number of bundles maintained by the pool
+	MaxBundlesPerSender int    // Maximum number of bundles accounted to a single sender
+	MaxTxs              int    // Maximum number of transactions in a single bundle
+	MaxLifetime         uint64 // Maximum number of blocks a bundle may target
+
+	Policies *txpool.PolicySet `toml:"-"` // Operator defined admission policies, shared with the other subpools
+}
+
+// DefaultConfig contains the default configurations for the bundle pool.
+var DefaultConfig = Config{
+	MaxBundles:          1024,
+	MaxBundlesPerSender: 16,
+	MaxTxs:              16,
+	MaxLifetime:         256,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *Config) sanitize() Config {
+	conf := *config
+	if conf.MaxBundles < 1 {
+		log.Warn("Sanitizing invalid bundlepool capacity", "provided", conf.MaxBundles, "updated", DefaultConfig.MaxBundles)
+		conf.MaxBundles = DefaultConfig.MaxBundles
+	}
+	if conf.MaxBundlesPerSender < 1 {
+		log.Warn("Sanitizing invalid bundlepool sender limit", "provided", conf.MaxBundlesPerSender, "updated", DefaultConfig.MaxBundlesPerSender)
+		conf.MaxBundlesPerSender = DefaultConfig.MaxBundlesPerSender
+	}
+	if conf.MaxTxs < 1 {
+		log.Warn("Sanitizing invalid bundlepool bundle size", "provided", conf.MaxTxs, "updated", DefaultConfig.MaxTxs)
+		conf.MaxTxs = DefaultConfig.MaxTxs
+	}
+	if conf.MaxLifetime < 1 {
+		log.Warn("Sanitizing invalid bundlepool lifetime", "provided", conf.MaxLifetime, "updated", DefaultConfig.MaxLifetime)
+		conf.MaxLifetime = DefaultConfig.MaxLifetime
+	}
+	return conf
+}
@@ -60,4 +60,20 @@ var (
 	// input transaction of non-blob type when a blob transaction from this sender
 	// remains pending (and vice-versa).
 	ErrAlreadyReserved = errors.New("address already reserved")
+
+	// ErrPrivateUnsupported is returned if a transaction is submitted privately,
+	// but the subpool handling its type can't expire it.
+	ErrPrivateUnsupported = errors.New("private submission not supported for transaction type")
+
+	// ErrBundlesUnsupported is returned if a bundle is submitted, but the pool
+	// has no subpool maintaining bundles.
+	ErrBundlesUnsupported = errors.New("bundles not supported")
+
+	// ErrBlobTxsDisabled is returned if a blob transaction is submitted to a pool
+	// without a blob subpool, as the network doesn't include blob transactions.
+	ErrBlobTxsDisabled = errors.New("blob transactions are not supported on this network")
+
+	// ErrPolicyRejected is returned if a transaction is rejected by one of the
+	// operator configured admission policies.
+	ErrPolicyRejected = errors.New("rejected by txpool policy")
 )
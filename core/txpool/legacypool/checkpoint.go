@@ -0,0 +1,186 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+// checkpointMagic prefixes checkpoint files, followed by the format version.
+var checkpointMagic = []byte("gtxp\x01")
+
+// errInvalidCheckpoint is returned if a file is not a transaction checkpoint.
+var errInvalidCheckpoint = errors.New("invalid transaction checkpoint")
+
+// checkpointBatch is the number of transactions added to the pool at once when
+// importing a checkpoint.
+const checkpointBatch = 1024
+
+// exportTransactions writes the transactions into a checkpoint file, replacing
+// it atomically. The transactions are written as a snappy compressed stream of
+// their RLP encodings, in the given order.
+func exportTransactions(path string, txs []*types.Transaction) error {
+	output, err := os.OpenFile(path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := snappy.NewBufferedWriter(output)
+	if _, err = writer.Write(checkpointMagic); err == nil {
+		for _, tx := range txs {
+			if err = rlp.Encode(writer, tx); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".new")
+		return err
+	}
+	return os.Rename(path+".new", path)
+}
+
+// importTransactions reads a checkpoint file written by exportTransactions and
+// feeds the transactions to add in batches, returning the number of read and
+// rejected transactions. A missing file is not an error.
+func importTransactions(path string, add func([]*types.Transaction) []error) (total int, dropped int, err error) {
+	input, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	defer input.Close()
+
+	reader := bufio.NewReader(snappy.NewReader(input))
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic, checkpointMagic) {
+		return 0, 0, errInvalidCheckpoint
+	}
+	var (
+		stream = rlp.NewStream(reader, 0)
+		batch  types.Transactions
+	)
+	flush := func() {
+		for _, err := range add(batch) {
+			if err != nil {
+				log.Trace("Failed to add checkpointed transaction", "err", err)
+				dropped++
+			}
+		}
+		batch = batch[:0]
+	}
+	for {
+		tx := new(types.Transaction)
+		if err = stream.Decode(tx); err != nil {
+			if err == io.EOF {
+				err = nil
+			} else {
+				err = fmt.Errorf("%w: %v", errInvalidCheckpoint, err)
+			}
+			break
+		}
+		total++
+		if batch = append(batch, tx); len(batch) >= checkpointBatch {
+			flush()
+		}
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+	return total, dropped, err
+}
+
+// SetCheckpointFilter sets a filter excluding transactions from the checkpoints,
+// e.g. ones which must not reappear as regular transactions after a restart.
+func (pool *LegacyPool) SetCheckpointFilter(exclude func(hash common.Hash) bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.checkpointFilter = exclude
+}
+
+// checkpointTxs returns all pending and queued transactions, each account's ones
+// ordered by nonce. The pool lock must be held.
+func (pool *LegacyPool) checkpointTxs() []*types.Transaction {
+	var txs []*types.Transaction
+	for addr, list := range pool.pending {
+		txs = append(txs, list.Flatten()...)
+		if queued := pool.queue[addr]; queued != nil {
+			txs = append(txs, queued.Flatten()...)
+		}
+	}
+	for addr, list := range pool.queue {
+		if pool.pending[addr] == nil {
+			txs = append(txs, list.Flatten()...)
+		}
+	}
+	return txs
+}
+
+// checkpoint writes all transactions of the pool into the checkpoint file.
+func (pool *LegacyPool) checkpoint() {
+	pool.mu.Lock()
+	txs, exclude := pool.checkpointTxs(), pool.checkpointFilter
+	pool.mu.Unlock()
+
+	// Filter outside of the pool lock, the filter may call back into the pool
+	if exclude != nil {
+		filtered := txs[:0]
+		for _, tx := range txs {
+			if !exclude(tx.Hash()) {
+				filtered = append(filtered, tx)
+			}
+		}
+		txs = filtered
+	}
+	if err := exportTransactions(pool.config.Checkpoint, txs); err != nil {
+		log.Warn("Failed to checkpoint transaction pool", "err", err)
+		return
+	}
+	log.Debug("Checkpointed transaction pool", "transactions", len(txs))
+}
+
+// loadCheckpoint adds the transactions of the checkpoint file to the pool as
+// remote ones, validating them against the current head.
+func (pool *LegacyPool) loadCheckpoint() {
+	total, dropped, err := importTransactions(pool.config.Checkpoint, pool.addRemotes)
+	if err != nil {
+		log.Warn("Failed to load transaction checkpoint", "err", err)
+	}
+	if total > 0 {
+		log.Info("Loaded transaction pool checkpoint", "transactions", total, "dropped", dropped)
+	}
+}
@@ -126,6 +126,9 @@ type Config struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	Checkpoint   string        // Checkpoint of all transactions to survive node restarts, disabled if empty
+	Recheckpoint time.Duration // Time interval to regenerate the checkpoint
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -135,6 +138,10 @@ type Config struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	FeePerByte bool // Whether remote transactions are priced per compressed byte instead of per gas
+
+	Policies *txpool.PolicySet `toml:"-"` // Operator defined admission policies, shared with the other subpools
 }
 
 // DefaultConfig contains the default configurations for the transaction pool.
@@ -142,6 +149,8 @@ var DefaultConfig = Config{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	Recheckpoint: 5 * time.Minute,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -161,6 +170,10 @@ func (config *Config) sanitize() Config {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.Checkpoint != "" && conf.Recheckpoint < time.Second {
+		log.Warn("Sanitizing invalid txpool checkpoint time", "provided", conf.Recheckpoint, "updated", time.Second)
+		conf.Recheckpoint = time.Second
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultConfig.PriceLimit)
 		conf.PriceLimit = DefaultConfig.PriceLimit
@@ -215,6 +228,10 @@ type LegacyPool struct {
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *journal    // Journal of local transaction to back up to disk
 
+	checkpointFilter func(hash common.Hash) bool // Excludes transactions from the checkpoints
+	eventFunc        txpool.TxEventFunc          // Callback to report lifecycle events through
+	included         map[common.Hash]struct{}    // Transactions included by the last reset, not reported as dropped
+
 	reserve txpool.AddressReserver       // Address reserver to ensure exclusivity across subpools
 	pending map[common.Address]*list     // All currently processable transactions
 	queue   map[common.Address]*list     // Queued but non-processable transactions
@@ -265,7 +282,7 @@ func New(config Config, chain BlockChain) *LegacyPool {
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
-	pool.priced = newPricedList(pool.all)
+	pool.priced = newPricedList(pool.all, pool.config.FeePerByte)
 
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
@@ -323,6 +340,10 @@ func (pool *LegacyPool) Init(gasTip uint64, head *types.Header, reserve txpool.A
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If checkpointing is enabled, restore the remaining transactions from disk
+	if pool.config.Checkpoint != "" {
+		pool.loadCheckpoint()
+	}
 	pool.wg.Add(1)
 	go pool.loop()
 	return nil
@@ -341,11 +362,19 @@ func (pool *LegacyPool) loop() {
 		report  = time.NewTicker(statsReportInterval)
 		evict   = time.NewTicker(evictionInterval)
 		journal = time.NewTicker(pool.config.Rejournal)
+
+		checkpoint <-chan time.Time
 	)
 	defer report.Stop()
 	defer evict.Stop()
 	defer journal.Stop()
 
+	if pool.config.Checkpoint != "" {
+		ticker := time.NewTicker(pool.config.Recheckpoint)
+		defer ticker.Stop()
+		checkpoint = ticker.C
+	}
+
 	// Notify tests that the init phase is done
 	close(pool.initDoneCh)
 	for {
@@ -380,6 +409,7 @@ func (pool *LegacyPool) loop() {
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true, true)
 					}
+					pool.reportDropped(list, txpool.ReasonLifetime)
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
 			}
@@ -394,6 +424,10 @@ func (pool *LegacyPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+
+		// Handle full pool checkpointing
+		case <-checkpoint:
+			pool.checkpoint()
 		}
 	}
 }
@@ -407,6 +441,9 @@ func (pool *LegacyPool) Close() error {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.config.Checkpoint != "" {
+		pool.checkpoint()
+	}
 	log.Info("Transaction pool stopped")
 	return nil
 }
@@ -446,6 +483,7 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 		for _, tx := range drop {
 			pool.removeTx(tx.Hash(), false, true)
 		}
+		pool.reportDropped(drop, txpool.ReasonTipTooLow)
 		pool.priced.Removed(len(drop))
 	}
 	log.Info("Legacy pool tip threshold updated", "tip", newTip)
@@ -657,6 +695,11 @@ func (pool *LegacyPool) validateTx(tx *types.Transaction, local bool) error {
 	if err := txpool.ValidateTransactionWithState(tx, pool.signer, opts); err != nil {
 		return err
 	}
+	// Ensure the transaction adheres to the operator's admission policies
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if err := pool.config.Policies.Check(tx, from); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -685,6 +728,11 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// Compress the transaction once on admission, so the price heaps comparing
+	// transactions per byte only read the cached size
+	if pool.config.FeePerByte {
+		tx.CompressedSize()
+	}
 	// already validated by this point
 	from, _ := types.Sender(pool.signer, tx)
 
@@ -770,6 +818,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 
 			pool.changesSinceReorg += dropped
 		}
+		pool.reportDropped(drop, txpool.ReasonUnderpriced)
 	}
 
 	// Try to replace an existing transaction in the pending pool
@@ -784,6 +833,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		if old != nil {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
+			pool.reportReplaced(old, tx)
 			pendingReplaceMeter.Mark(1)
 		}
 		pool.all.Add(tx, isLocal)
@@ -847,7 +897,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 	// Try to insert the transaction into the future queue
 	from, _ := types.Sender(pool.signer, tx) // already validated
 	if pool.queue[from] == nil {
-		pool.queue[from] = newList(false)
+		pool.queue[from] = newList(false, pool.config.FeePerByte)
 	}
 	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
 	if !inserted {
@@ -859,6 +909,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
+		pool.reportReplaced(old, tx)
 		queuedReplaceMeter.Mark(1)
 	} else {
 		// Nothing was replaced, bump the queued counter
@@ -892,6 +943,50 @@ func (pool *LegacyPool) journalTx(from common.Address, tx *types.Transaction) {
 	}
 }
 
+// SetEventFunc sets the callback to report transaction lifecycle events through.
+// It must be called before the pool is initialized.
+func (pool *LegacyPool) SetEventFunc(fn txpool.TxEventFunc) {
+	pool.eventFunc = fn
+}
+
+// report reports a lifecycle event of the transactions, if a callback is set.
+func (pool *LegacyPool) report(txs []*types.Transaction, event txpool.TxEvent) {
+	if pool.eventFunc == nil {
+		return
+	}
+	for _, tx := range txs {
+		event.Hash, event.Nonce = tx.Hash(), tx.Nonce()
+		event.From, _ = types.Sender(pool.signer, tx) // already validated
+		pool.eventFunc(event)
+	}
+}
+
+// reportDropped reports the transactions as dropped from the pool for the given
+// reason.
+func (pool *LegacyPool) reportDropped(txs []*types.Transaction, reason string) {
+	pool.report(txs, txpool.TxEvent{Kind: txpool.TxEventDropped, Reason: reason})
+}
+
+// reportObsoleted reports the transactions obsoleted by the nonce of the new head
+// as dropped, except those included by the new chain segment.
+func (pool *LegacyPool) reportObsoleted(txs []*types.Transaction) {
+	if len(pool.included) > 0 {
+		stale := make([]*types.Transaction, 0, len(txs))
+		for _, tx := range txs {
+			if _, ok := pool.included[tx.Hash()]; !ok {
+				stale = append(stale, tx)
+			}
+		}
+		txs = stale
+	}
+	pool.reportDropped(txs, txpool.ReasonNonceTooLow)
+}
+
+// reportReplaced reports a transaction as replaced by another with the same nonce.
+func (pool *LegacyPool) reportReplaced(old *types.Transaction, tx *types.Transaction) {
+	pool.report([]*types.Transaction{old}, txpool.TxEvent{Kind: txpool.TxEventReplaced, Reason: "replaced by a better paying transaction", ReplacedBy: tx.Hash()})
+}
+
 // promoteTx adds a transaction to the pending (processable) list of transactions
 // and returns whether it was inserted or an older was better.
 //
@@ -899,7 +994,7 @@ func (pool *LegacyPool) journalTx(from common.Address, tx *types.Transaction) {
 func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *types.Transaction) bool {
 	// Try to insert the transaction into the pending queue
 	if pool.pending[addr] == nil {
-		pool.pending[addr] = newList(true)
+		pool.pending[addr] = newList(true, pool.config.FeePerByte)
 	}
 	list := pool.pending[addr]
 
@@ -915,6 +1010,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
+		pool.reportReplaced(old, tx)
 		pendingReplaceMeter.Mark(1)
 	} else {
 		// Nothing was replaced, bump the pending counter
@@ -1082,6 +1178,20 @@ func (pool *LegacyPool) Has(hash common.Hash) bool {
 	return pool.all.Get(hash) != nil
 }
 
+// RemoveTx drops a single transaction from the pool, moving all subsequent
+// transactions of the account back to the future queue. It reports whether the
+// transaction was found.
+func (pool *LegacyPool) RemoveTx(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all.Get(hash) == nil {
+		return false
+	}
+	pool.removeTx(hash, true, true)
+	return true
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 //
@@ -1296,6 +1406,7 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	// because of another transaction (e.g. higher gas price).
 	if reset != nil {
 		pool.demoteUnexecutables()
+		pool.included = nil
 		if reset.newHead != nil {
 			if pool.chainconfig.IsLondon(new(big.Int).Add(reset.newHead.Number, big.NewInt(1))) {
 				pendingBaseFee := eip1559.CalcBaseFee(pool.chainconfig, reset.newHead)
@@ -1343,6 +1454,15 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	// If we're reorging an old state, reinject all dropped transactions
 	var reinject types.Transactions
 
+	// Track the transactions included by the new head, so they aren't reported as
+	// dropped for their nonce. This costs a block retrieval, only do it if the
+	// events are recorded at all.
+	pool.included = nil
+	if pool.eventFunc != nil && oldHead != nil && newHead != nil && oldHead.Hash() == newHead.ParentHash {
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			pool.included = txHashSet(block.Transactions())
+		}
+	}
 	if oldHead != nil && oldHead.Hash() != newHead.ParentHash {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
 		oldNum := oldHead.Number.Uint64()
@@ -1407,6 +1527,9 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 						return
 					}
 				}
+				if pool.eventFunc != nil {
+					pool.included = txHashSet(included)
+				}
 				lost := make([]*types.Transaction, 0, len(discarded))
 				for _, tx := range types.TxDifference(discarded, included) {
 					if pool.Filter(tx) {
@@ -1436,6 +1559,15 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	pool.addTxsLocked(reinject, false)
 }
 
+// txHashSet returns the set of the hashes of the transactions.
+func txHashSet(txs types.Transactions) map[common.Hash]struct{} {
+	set := make(map[common.Hash]struct{}, len(txs))
+	for _, tx := range txs {
+		set[tx.Hash()] = struct{}{}
+	}
+	return set
+}
+
 // promoteExecutables moves transactions that have become processable from the
 // future queue to the set of pending transactions. During this process, all
 // invalidated transactions (low nonce, low balance) are deleted.
@@ -1456,6 +1588,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.reportObsoleted(forwards)
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), gasLimit)
@@ -1463,6 +1596,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.reportDropped(drops, txpool.ReasonUnpayable)
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
@@ -1486,6 +1620,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
+			pool.reportDropped(caps, txpool.ReasonAccountQueue)
 			queuedRateLimitMeter.Mark(int64(len(caps)))
 		}
 		// Mark all the items dropped as removed
@@ -1503,6 +1638,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			}
 		}
 	}
+	pool.report(promoted, txpool.TxEvent{Kind: txpool.TxEventPromoted})
 	return promoted
 }
 
@@ -1554,6 +1690,7 @@ func (pool *LegacyPool) truncatePending() {
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
+					pool.reportDropped(caps, txpool.ReasonGlobalSlots)
 					pool.priced.Removed(len(caps))
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
@@ -1581,6 +1718,7 @@ func (pool *LegacyPool) truncatePending() {
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
+				pool.reportDropped(caps, txpool.ReasonGlobalSlots)
 				pool.priced.Removed(len(caps))
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
@@ -1621,9 +1759,11 @@ func (pool *LegacyPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true, true)
 			}
+			pool.reportDropped(txs, txpool.ReasonGlobalQueue)
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
 			continue
@@ -1632,6 +1772,7 @@ func (pool *LegacyPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true, true)
+			pool.reportDropped(txs[i:i+1], txpool.ReasonGlobalQueue)
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1658,6 +1799,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
+		pool.reportObsoleted(olds)
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), gasLimit)
 		for _, tx := range drops {
@@ -1665,6 +1807,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 		}
+		pool.reportDropped(drops, txpool.ReasonUnpayable)
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
 		for _, tx := range invalids {
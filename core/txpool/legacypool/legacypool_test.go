@@ -24,6 +24,8 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -2457,6 +2459,87 @@ func testJournaling(t *testing.T, nolocals bool) {
 	pool.Close()
 }
 
+// Tests that remote transactions are checkpointed to disk and revalidated when
+// restored after a restart, skipping the filtered ones.
+func TestCheckpointing(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.Checkpoint = filepath.Join(t.TempDir(), "txpool.snappy")
+	config.Recheckpoint = time.Second
+
+	pool := New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+
+	remote, _ := crypto.GenerateKey()
+	excluded, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(excluded.PublicKey), big.NewInt(1000000000))
+
+	// Add two pending and a queued remote transactions, plus an excluded one
+	filtered := transaction(0, 100000, excluded)
+	pool.SetCheckpointFilter(func(hash common.Hash) bool { return hash == filtered.Hash() })
+
+	txs := []*types.Transaction{
+		transaction(0, 100000, remote),
+		transaction(1, 100000, remote),
+		transaction(3, 100000, remote),
+		filtered,
+	}
+	for i, err := range pool.addRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", i, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("pool stats mismatched: have %d/%d, want 3/1", pending, queued)
+	}
+	// Terminate the old pool, bump the nonce and ensure only the still valid
+	// transactions survive the restart
+	pool.Close()
+	statedb.SetNonce(crypto.PubkeyToAddress(remote.PublicKey), 1)
+	blockchain = newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	pool = New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	defer pool.Close()
+
+	<-pool.requestReset(nil, nil)
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool stats mismatched after restart: have %d/%d, want 1/1", pending, queued)
+	}
+	if pool.Has(filtered.Hash()) {
+		t.Fatalf("excluded transaction restored from checkpoint")
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Export the pool explicitly and ensure it imports into a fresh pool
+	path := filepath.Join(t.TempDir(), "export.snappy")
+	pool.mu.Lock()
+	exported := pool.checkpointTxs()
+	pool.mu.Unlock()
+	if err := exportTransactions(path, exported); err != nil {
+		t.Fatalf("failed to export transactions: %v", err)
+	}
+	var imported []*types.Transaction
+	total, dropped, err := importTransactions(path, func(txs []*types.Transaction) []error {
+		imported = append(imported, txs...)
+		return make([]error, len(txs))
+	})
+	if err != nil || total != 2 || dropped != 0 {
+		t.Fatalf("import mismatched: have %d/%d/%v, want 2/0/nil", total, dropped, err)
+	}
+	for i, tx := range imported {
+		if tx.Hash() != exported[i].Hash() {
+			t.Errorf("imported transaction %d mismatched: have %x, want %x", i, tx.Hash(), exported[i].Hash())
+		}
+	}
+}
+
 // TestStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestStatusCheck(t *testing.T) {
@@ -2530,6 +2613,78 @@ func TestSlotCount(t *testing.T) {
 	}
 }
 
+// Tests that privately submitted transactions are tracked by the main pool, and
+// dropped from the legacy pool once they expire.
+func TestPrivateTransactions(t *testing.T) {
+	t.Parallel()
+
+	// Disable London, as the heads of the test chain carry no base fee
+	config := *params.TestChainConfig
+	config.LondonBlock = nil
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(&config, 1000000, statedb, new(event.Feed))
+
+	privKey, _ := crypto.GenerateKey()
+	publicKey, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(privKey.PublicKey), uint256.NewInt(1000000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(publicKey.PublicKey), uint256.NewInt(1000000000))
+
+	legacy := New(testTxPoolConfig, blockchain)
+	pool, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{legacy})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	var (
+		private = transaction(0, 100000, privKey)
+		public  = transaction(0, 100000, publicKey)
+	)
+	if errs := pool.AddPrivate([]*types.Transaction{private}, 2); errs[0] != nil {
+		t.Fatalf("failed to add private transaction: %v", errs[0])
+	}
+	if errs := pool.AddPrivate([]*types.Transaction{private}, 2); !errors.Is(errs[0], txpool.ErrAlreadyKnown) {
+		t.Fatalf("duplicate private transaction: have %v, want %v", errs[0], txpool.ErrAlreadyKnown)
+	}
+	if errs := pool.Add([]*types.Transaction{public}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add public transaction: %v", errs[0])
+	}
+	if err := pool.Sync(); err != nil {
+		t.Fatalf("failed to sync pool: %v", err)
+	}
+	if !pool.IsPrivate(private.Hash()) || pool.IsPrivate(public.Hash()) {
+		t.Fatalf("private marker mismatch")
+	}
+	if info := pool.PrivateTx(private.Hash()); info == nil || info.Expiry != 2 || info.Status != txpool.TxStatusPending {
+		t.Fatalf("private transaction info mismatch: %+v", info)
+	}
+	// Advance the chain and ensure the private transaction expires in time
+	head := blockchain.CurrentBlock()
+	for number := int64(1); number <= 2; number++ {
+		if number == 2 && legacy.Get(private.Hash()) == nil {
+			t.Fatalf("private transaction dropped before expiry")
+		}
+		head = &types.Header{Number: big.NewInt(number), ParentHash: head.Hash(), GasLimit: head.GasLimit}
+		blockchain.chainHeadFeed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(head)})
+		if err := pool.Sync(); err != nil {
+			t.Fatalf("failed to sync pool: %v", err)
+		}
+	}
+	if legacy.Get(private.Hash()) != nil {
+		t.Fatalf("expired private transaction still in pool")
+	}
+	if legacy.Get(public.Hash()) == nil {
+		t.Fatalf("public transaction dropped")
+	}
+	if info := pool.PrivateTx(private.Hash()); info == nil || !info.Expired || info.Status != txpool.TxStatusUnknown {
+		t.Fatalf("expired private transaction info mismatch: %+v", info)
+	}
+	if pool.IsPrivate(private.Hash()) {
+		t.Fatalf("expired private transaction still tracked")
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
@@ -2670,3 +2825,293 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.addRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// headBlockChain is a test chain serving the transactions of its head block.
+type headBlockChain struct {
+	*testBlockChain
+	head *types.Block
+}
+
+func (bc *headBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	if bc.head != nil && bc.head.Hash() == hash {
+		return bc.head
+	}
+	return bc.testBlockChain.GetBlock(hash, number)
+}
+
+// Tests that the reasons of transactions leaving the pool are retained for
+// status queries.
+func TestLifecycleEvents(t *testing.T) {
+	t.Parallel()
+
+	// Disable London, as the heads of the test chain carry no base fee
+	config := *params.TestChainConfig
+	config.LondonBlock = nil
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &headBlockChain{testBlockChain: newTestBlockChain(&config, 1000000, statedb, new(event.Feed))}
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	statedb.AddBalance(addr, uint256.NewInt(1000000000))
+
+	pool, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{New(testTxPoolConfig, blockchain)})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	var (
+		cheap     = pricedTransaction(0, 100000, big.NewInt(1), key)
+		expensive = pricedTransaction(0, 100000, big.NewInt(2), key)
+		next      = pricedTransaction(1, 100000, big.NewInt(1), key)
+	)
+	for _, tx := range []*types.Transaction{cheap, expensive, next} {
+		if errs := pool.Add([]*types.Transaction{tx}, false, true); errs[0] != nil {
+			t.Fatalf("failed to add transaction: %v", errs[0])
+		}
+		if err := pool.Sync(); err != nil {
+			t.Fatalf("failed to sync pool: %v", err)
+		}
+	}
+	if event := pool.TxEvent(cheap.Hash()); event == nil || event.Kind != txpool.TxEventReplaced || event.ReplacedBy != expensive.Hash() {
+		t.Fatalf("replacement event mismatch: %+v", event)
+	}
+	// Skips reported by block builders must neither shadow drops and replacements,
+	// nor push them out of the buffer, whatever their number
+	pool.RecordSkipped(cheap.Hash(), "test")
+	for i := 0; i < 16384; i++ {
+		pool.RecordSkipped(common.Hash{byte(i), byte(i >> 8), 0xff}, "test")
+	}
+	if event := pool.TxEvent(cheap.Hash()); event == nil || event.Kind != txpool.TxEventReplaced {
+		t.Fatalf("replacement event lost to skips: %+v", event)
+	}
+	pool.RecordSkipped(next.Hash(), "test")
+	if event := pool.TxEvent(next.Hash()); event == nil || event.Kind != txpool.TxEventSkipped || event.Reason != "test" {
+		t.Fatalf("skip event mismatch: %+v", event)
+	}
+	// Include the first pending transaction and move the nonce past the second one,
+	// ensure only the latter is reported as dropped
+	statedb.SetNonce(addr, 2)
+	head := blockchain.CurrentBlock()
+	head = &types.Header{Number: big.NewInt(1), ParentHash: head.Hash(), GasLimit: head.GasLimit}
+	blockchain.head = types.NewBlock(head, types.Transactions{expensive}, nil, nil, trie.NewStackTrie(nil))
+	blockchain.chainHeadFeed.Send(core.ChainHeadEvent{Block: blockchain.head})
+	if err := pool.Sync(); err != nil {
+		t.Fatalf("failed to sync pool: %v", err)
+	}
+	if event := pool.TxEvent(expensive.Hash()); event != nil {
+		t.Fatalf("unexpected event for included transaction: %+v", event)
+	}
+	if event := pool.TxEvent(next.Hash()); event == nil || event.Kind != txpool.TxEventDropped || event.Reason != txpool.ReasonNonceTooLow {
+		t.Fatalf("drop event mismatch: %+v", event)
+	}
+	if event := pool.TxEvent(common.Hash{}); event != nil {
+		t.Fatalf("unexpected event for unknown transaction: %+v", event)
+	}
+}
+
+// Tests that transactions violating the configured admission policies are
+// rejected and that the policies can be reloaded from their file.
+func TestAdmissionPolicies(t *testing.T) {
+	t.Parallel()
+
+	var (
+		blocked, _ = crypto.GenerateKey()
+		allowed, _ = crypto.GenerateKey()
+		recipient  = common.Address{0xbb}
+		path       = filepath.Join(t.TempDir(), "policy.json")
+	)
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(`{"blockedSenders": ["%s"], "denyContractCreation": true}`, crypto.PubkeyToAddress(blocked.PublicKey).Hex())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := txpool.NewPolicySet(path)
+	if err != nil {
+		t.Fatalf("failed to load policies: %v", err)
+	}
+	defer policies.Close()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.Policies = policies
+	pool := New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	defer pool.Close()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(blocked.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(allowed.PublicKey), big.NewInt(1000000000))
+
+	create := types.MustSignNewTx(allowed, types.HomesteadSigner{}, &types.LegacyTx{Gas: 100000, GasPrice: big.NewInt(1)})
+	large, _ := types.SignTx(types.NewTransaction(0, recipient, big.NewInt(0), 100000, big.NewInt(1), make([]byte, 64)), types.HomesteadSigner{}, allowed)
+
+	for i, test := range []struct {
+		tx     *types.Transaction
+		policy string
+	}{
+		{transaction(0, 100000, blocked), "blocked-senders"},
+		{create, "no-contract-creation"},
+		{large, ""},
+	} {
+		err := pool.addRemoteSync(test.tx)
+		var perr *txpool.PolicyError
+		switch {
+		case test.policy == "" && err != nil:
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		case test.policy != "" && (!errors.As(err, &perr) || perr.Policy != test.policy || !errors.Is(err, txpool.ErrPolicyRejected)):
+			t.Errorf("test %d: error mismatch: have %v, want policy %s", i, err, test.policy)
+		}
+	}
+	// Replace the policies and ensure the new ones are enforced after a reload
+	if err := os.WriteFile(path, []byte(`{"maxCalldataSize": 32}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := policies.Reload(); err != nil {
+		t.Fatalf("failed to reload policies: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(0, 100000, blocked)); err != nil {
+		t.Fatalf("unblocked sender rejected: %v", err)
+	}
+	replacement, _ := types.SignTx(types.NewTransaction(0, recipient, big.NewInt(0), 100000, big.NewInt(2), make([]byte, 64)), types.HomesteadSigner{}, allowed)
+	if err := pool.addRemoteSync(replacement); !errors.Is(err, txpool.ErrPolicyRejected) {
+		t.Fatalf("oversized calldata error mismatch: have %v, want %v", err, txpool.ErrPolicyRejected)
+	}
+}
+
+// Tests that underpriced eviction ranks transactions per gas by default and per
+// compressed byte in the fee per byte mode, leading to different pool contents.
+func TestUnderpricingFeePerByte(t *testing.T) {
+	t.Parallel()
+
+	for _, perByte := range []bool{false, true} {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+		config := testTxPoolConfig
+		config.GlobalSlots = 2
+		config.GlobalQueue = 1
+		config.FeePerByte = perByte
+
+		pool := New(config, blockchain)
+		pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+
+		keys := make([]*ecdsa.PrivateKey, 4)
+		for i := range keys {
+			keys[i], _ = crypto.GenerateKey()
+			testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+		}
+		// Fill the pool with a large well paying transaction per gas and two small
+		// ones, the cheaper of which pays much more per byte than the large one
+		var (
+			large = pricedDataTransaction(0, 100000, big.NewInt(3), keys[0], 1024)
+			cheap = pricedTransaction(0, 100000, big.NewInt(2), keys[1])
+			rich  = pricedTransaction(0, 100000, big.NewInt(4), keys[2])
+		)
+		for _, err := range pool.addRemotesSync([]*types.Transaction{large, cheap, rich}) {
+			if err != nil {
+				t.Fatalf("perByte %v: failed to add transaction: %v", perByte, err)
+			}
+		}
+		// Add a new transaction and ensure the mode's worst one is evicted
+		if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(3), keys[3])); err != nil {
+			t.Fatalf("perByte %v: failed to add transaction: %v", perByte, err)
+		}
+		evicted, kept := cheap, large
+		if perByte {
+			evicted, kept = large, cheap
+		}
+		if pool.Has(evicted.Hash()) || !pool.Has(kept.Hash()) || !pool.Has(rich.Hash()) {
+			t.Errorf("perByte %v: wrong transaction evicted", perByte)
+		}
+		if err := validatePoolInternals(pool); err != nil {
+			t.Errorf("perByte %v: pool internal state corrupted: %v", perByte, err)
+		}
+		pool.Close()
+	}
+}
+
+// Tests that the nonce gaps blocking queued transactions are reported, and that
+// promotions and drops are posted to event subscribers.
+func TestQueuedDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	// Disable London, as the heads of the test chain carry no base fee
+	config := *params.TestChainConfig
+	config.LondonBlock = nil
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(&config, 1000000, statedb, new(event.Feed))
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	statedb.AddBalance(addr, uint256.NewInt(1000000000))
+
+	legacy := New(testTxPoolConfig, blockchain)
+	pool, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{legacy})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	events := make(chan txpool.TxEvent, 16)
+	sub := pool.SubscribeTxEvents(events)
+	defer sub.Unsubscribe()
+
+	// Add an executable transaction and a few queued ones with nonce gaps
+	var txs []*types.Transaction
+	for _, nonce := range []uint64{0, 2, 3, 6} {
+		txs = append(txs, transaction(nonce, 100000, key))
+	}
+	for i, err := range pool.Add(txs, false, true) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if err := pool.Sync(); err != nil {
+		t.Fatalf("failed to sync pool: %v", err)
+	}
+	accounts := pool.Queued(nil)
+	if len(accounts) != 1 || accounts[0].Address != addr {
+		t.Fatalf("queued accounts mismatch: %v", accounts)
+	}
+	account := accounts[0]
+	if account.NextNonce != 1 || len(account.Queued) != 3 {
+		t.Fatalf("queued account mismatch: next nonce %d, queued %d", account.NextNonce, len(account.Queued))
+	}
+	if want := []txpool.NonceRange{{From: 1, To: 1}, {From: 4, To: 5}}; !reflect.DeepEqual(account.Missing, want) {
+		t.Fatalf("missing nonces mismatch: have %v, want %v", account.Missing, want)
+	}
+	if account.Eviction == nil || !account.Eviction.Equal(account.Heartbeat.Add(testTxPoolConfig.Lifetime)) {
+		t.Fatalf("eviction time mismatch: %v", account.Eviction)
+	}
+	select {
+	case event := <-events:
+		if event.Kind != txpool.TxEventPromoted || event.Hash != txs[0].Hash() || event.From != addr {
+			t.Fatalf("promotion event mismatch: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("promotion event not posted")
+	}
+	// Fill the first gap and ensure the unblocked transactions get promoted
+	if errs := pool.Add([]*types.Transaction{transaction(1, 100000, key)}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add transaction: %v", errs[0])
+	}
+	if err := pool.Sync(); err != nil {
+		t.Fatalf("failed to sync pool: %v", err)
+	}
+	if accounts := pool.Queued([]common.Address{addr}); len(accounts) != 1 || accounts[0].NextNonce != 4 || len(accounts[0].Queued) != 1 {
+		t.Fatalf("queued account mismatch after filling the gap: %v", accounts)
+	}
+	promoted := make(map[uint64]bool)
+	for len(promoted) < 3 {
+		select {
+		case event := <-events:
+			if event.Kind == txpool.TxEventPromoted {
+				promoted[event.Nonce] = true
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("promotion events missing, have %v", promoted)
+		}
+	}
+}
@@ -270,8 +270,9 @@ func (m *sortedMap) LastElement() *types.Transaction {
 // the executable/pending queue; and for storing gapped transactions for the non-
 // executable/future queue, with minor behavioral changes.
 type list struct {
-	strict bool       // Whether nonces are strictly continuous or not
-	txs    *sortedMap // Heap indexed sorted hash map of the transactions
+	strict  bool       // Whether nonces are strictly continuous or not
+	perByte bool       // Whether replacements are priced per compressed byte
+	txs     *sortedMap // Heap indexed sorted hash map of the transactions
 
 	costcap   *uint256.Int // Price of the highest costing transaction (reset only if exceeds balance)
 	gascap    uint64       // Gas limit of the highest spending transaction (reset only if exceeds block limit)
@@ -280,9 +281,10 @@ type list struct {
 
 // newList creates a new transaction list for maintaining nonce-indexable fast,
 // gapped, sortable transaction lists.
-func newList(strict bool) *list {
+func newList(strict bool, perByte bool) *list {
 	return &list{
 		strict:    strict,
+		perByte:   perByte,
 		txs:       newSortedMap(),
 		costcap:   new(uint256.Int),
 		totalcost: new(uint256.Int),
@@ -303,7 +305,13 @@ func (l *list) Contains(nonce uint64) bool {
 func (l *list) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transaction) {
 	// If there's an older better transaction, abort
 	old := l.txs.Get(tx.Nonce())
-	if old != nil {
+	if old != nil && l.perByte {
+		if !replacesPerByte(old, tx, priceBump) {
+			return false, nil
+		}
+		// Old is being replaced, subtract old cost
+		l.subTotalCost([]*types.Transaction{old})
+	} else if old != nil {
 		if old.GasFeeCapCmp(tx) >= 0 || old.GasTipCapCmp(tx) >= 0 {
 			return false, nil
 		}
@@ -476,6 +484,7 @@ func (l *list) subTotalCost(txs []*types.Transaction) {
 // If baseFee is nil then the sorting is based on gasFeeCap.
 type priceHeap struct {
 	baseFee *big.Int // heap should always be re-sorted after baseFee is changed
+	perByte bool     // whether prices are compared per compressed byte
 	list    []*types.Transaction
 }
 
@@ -494,6 +503,17 @@ func (h *priceHeap) Less(i, j int) bool {
 }
 
 func (h *priceHeap) cmp(a, b *types.Transaction) int {
+	if h.perByte {
+		// Compare the fees paid per compressed byte, effective tips if baseFee is
+		// specified and fee caps otherwise
+		priceA, priceB := a.GasFeeCap(), b.GasFeeCap()
+		if h.baseFee != nil {
+			priceA, priceB = a.EffectiveGasTipValue(h.baseFee), b.EffectiveGasTipValue(h.baseFee)
+		}
+		if c := cmpPerByte(a, priceA, b, priceB); c != 0 {
+			return c
+		}
+	}
 	if h.baseFee != nil {
 		// Compare effective tips if baseFee is specified
 		if c := a.EffectiveGasTipCmp(b, h.baseFee); c != 0 {
@@ -548,10 +568,14 @@ const (
 	floatingRatio = 1
 )
 
-// newPricedList creates a new price-sorted transaction heap.
-func newPricedList(all *lookup) *pricedList {
+// newPricedList creates a new price-sorted transaction heap. If perByte is set,
+// transactions are ranked by the fees they pay per compressed byte instead of
+// per gas.
+func newPricedList(all *lookup, perByte bool) *pricedList {
 	return &pricedList{
-		all: all,
+		all:      all,
+		urgent:   priceHeap{perByte: perByte},
+		floating: priceHeap{perByte: perByte},
 	}
 }
 
@@ -685,3 +709,33 @@ func (l *pricedList) SetBaseFee(baseFee *big.Int) {
 	l.urgent.baseFee = baseFee
 	l.Reheap()
 }
+
+// cmpPerByte compares two prices scaled by the gas limit of their transactions
+// per compressed byte the transactions occupy in an L2 transaction list.
+func cmpPerByte(a *types.Transaction, priceA *big.Int, b *types.Transaction, priceB *big.Int) int {
+	x := new(big.Int).Mul(priceA, new(big.Int).SetUint64(a.Gas()))
+	x.Mul(x, new(big.Int).SetUint64(b.CompressedSize()))
+
+	y := new(big.Int).Mul(priceB, new(big.Int).SetUint64(b.Gas()))
+	y.Mul(y, new(big.Int).SetUint64(a.CompressedSize()))
+
+	return x.Cmp(y)
+}
+
+// replacesPerByte checks whether a transaction pays enough per compressed byte
+// to replace an old one: both its fee cap and tip per byte must exceed the old
+// ones by the price bump percentage.
+func replacesPerByte(old *types.Transaction, tx *types.Transaction, priceBump uint64) bool {
+	if cmpPerByte(tx, tx.GasFeeCap(), old, old.GasFeeCap()) <= 0 || cmpPerByte(tx, tx.GasTipCap(), old, old.GasTipCap()) <= 0 {
+		return false
+	}
+	var (
+		a = big.NewInt(100 + int64(priceBump))
+		b = big.NewInt(100)
+	)
+	thresholdFeeCap := new(big.Int).Mul(a, old.GasFeeCap())
+	thresholdTip := new(big.Int).Mul(a, old.GasTipCap())
+
+	return cmpPerByte(tx, new(big.Int).Mul(b, tx.GasFeeCap()), old, thresholdFeeCap) >= 0 &&
+		cmpPerByte(tx, new(big.Int).Mul(b, tx.GasTipCap()), old, thresholdTip) >= 0
+}
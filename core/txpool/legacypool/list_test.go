@@ -38,7 +38,7 @@ func TestStrictListAdd(t *testing.T) {
 		txs[i] = transaction(uint64(i), 0, key)
 	}
 	// Insert the transactions in a random order
-	list := newList(true)
+	list := newList(true, false)
 	for _, v := range rand.Perm(len(txs)) {
 		list.Add(txs[v], DefaultConfig.PriceBump)
 	}
@@ -57,7 +57,7 @@ func TestStrictListAdd(t *testing.T) {
 // expected that the list does not panic.
 func TestListAddVeryExpensive(t *testing.T) {
 	key, _ := crypto.GenerateKey()
-	list := newList(true)
+	list := newList(true, false)
 	for i := 0; i < 3; i++ {
 		value := big.NewInt(100)
 		gasprice, _ := new(big.Int).SetString("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 0)
@@ -68,6 +68,38 @@ func TestListAddVeryExpensive(t *testing.T) {
 	}
 }
 
+// Tests that replacements are priced per gas or per compressed byte depending on
+// the pricing mode of the list.
+func TestListReplacementPricing(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	var (
+		small = pricedTransaction(0, 100000, big.NewInt(10), key)
+		large = pricedDataTransaction(0, 100000, big.NewInt(12), key, 1024)
+	)
+	for _, test := range []struct {
+		perByte  bool
+		old, tx  *types.Transaction
+		replaces bool
+	}{
+		// A better paying transaction per gas can be much larger and thus worse
+		// paying per byte
+		{false, small, large, true},
+		{true, small, large, false},
+
+		// A cheaper transaction per gas can be much smaller and thus better paying
+		// per byte
+		{false, large, small, false},
+		{true, large, small, true},
+	} {
+		list := newList(true, test.perByte)
+		list.Add(test.old, DefaultConfig.PriceBump)
+		if inserted, _ := list.Add(test.tx, DefaultConfig.PriceBump); inserted != test.replaces {
+			t.Errorf("perByte %v: replacement mismatch: have %v, want %v", test.perByte, inserted, test.replaces)
+		}
+	}
+}
+
 func BenchmarkListAdd(b *testing.B) {
 	// Generate a list of transactions to insert
 	key, _ := crypto.GenerateKey()
@@ -80,7 +112,7 @@ func BenchmarkListAdd(b *testing.B) {
 	priceLimit := uint256.NewInt(DefaultConfig.PriceLimit)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		list := newList(true)
+		list := newList(true, false)
 		for _, v := range rand.Perm(len(txs)) {
 			list.Add(txs[v], DefaultConfig.PriceBump)
 			list.Filter(priceLimit, DefaultConfig.PriceBump)
@@ -99,7 +131,7 @@ func BenchmarkListCapOneTx(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		list := newList(true)
+		list := newList(true, false)
 		// Insert the transactions in a random order
 		for _, v := range rand.Perm(len(txs)) {
 			list.Add(txs[v], DefaultConfig.PriceBump)
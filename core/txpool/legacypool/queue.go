@@ -0,0 +1,75 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+)
+
+// Queued returns the queued transactions of the given accounts, or of all
+// accounts with queued transactions if none are given, along with the nonce
+// gaps blocking their promotion and the time they are due for eviction.
+func (pool *LegacyPool) Queued(addrs []common.Address) []*txpool.QueuedAccount {
+	// Flattening the lists updates their caches, the write lock is needed
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if len(addrs) == 0 {
+		for addr := range pool.queue {
+			addrs = append(addrs, addr)
+		}
+		sort.Slice(addrs, func(i, j int) bool {
+			return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+		})
+	}
+	var accounts []*txpool.QueuedAccount
+	for _, addr := range addrs {
+		list := pool.queue[addr]
+		if list == nil {
+			continue
+		}
+		account := &txpool.QueuedAccount{
+			Address:   addr,
+			Local:     pool.locals.contains(addr),
+			NextNonce: pool.pendingNonces.get(addr),
+			Heartbeat: pool.beats[addr],
+		}
+		if !account.Local {
+			eviction := account.Heartbeat.Add(pool.config.Lifetime)
+			account.Eviction = &eviction
+		}
+		next := account.NextNonce
+		for _, tx := range list.Flatten() {
+			account.Queued = append(account.Queued, txpool.QueuedTx{Hash: tx.Hash(), Nonce: tx.Nonce(), Since: tx.Time()})
+
+			// Transactions below the next nonce are stale, awaiting removal
+			if tx.Nonce() < next {
+				continue
+			}
+			if tx.Nonce() > next {
+				account.Missing = append(account.Missing, txpool.NonceRange{From: next, To: tx.Nonce() - 1})
+			}
+			next = tx.Nonce() + 1
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
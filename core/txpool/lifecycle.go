@@ -0,0 +1,241 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// txEventsLimit is the number of recent drops and replacements retained for
+// status queries, and separately the number of recent skips.
+const txEventsLimit = 8192
+
+// txEventsBacklog is the number of lifecycle events buffered for subscribers,
+// beyond which events are not delivered to not block the subpools.
+const txEventsBacklog = 1024
+
+// TxEventKind is the kind of a transaction lifecycle event.
+type TxEventKind uint
+
+const (
+	// TxEventDropped is recorded when a transaction is evicted from the pool.
+	TxEventDropped TxEventKind = iota + 1
+
+	// TxEventReplaced is recorded when a transaction is replaced by another one
+	// with the same nonce.
+	TxEventReplaced
+
+	// TxEventSkipped is recorded when a pending transaction is passed over while
+	// building a block or a transaction list.
+	TxEventSkipped
+
+	// TxEventPromoted is posted when a queued transaction becomes executable. It
+	// is only delivered to subscribers and not retained.
+	TxEventPromoted
+)
+
+// String implements fmt.Stringer.
+func (k TxEventKind) String() string {
+	switch k {
+	case TxEventDropped:
+		return "dropped"
+	case TxEventReplaced:
+		return "replaced"
+	case TxEventSkipped:
+		return "skipped"
+	case TxEventPromoted:
+		return "promoted"
+	default:
+		return "unknown"
+	}
+}
+
+// Reasons for transactions leaving the pool or being skipped, shared by the
+// subpools and the block builders.
+const (
+	ReasonUnderpriced   = "underpriced, evicted by a better paying transaction"
+	ReasonNonceTooLow   = "nonce too low"
+	ReasonUnpayable     = "insufficient funds or gas limit exceeded"
+	ReasonAccountQueue  = "evicted by the per-account queue limit"
+	ReasonGlobalSlots   = "evicted by the global pending limit (GlobalSlots)"
+	ReasonGlobalQueue   = "evicted by the global queue limit (GlobalQueue)"
+	ReasonLifetime      = "queued lifetime expired"
+	ReasonTipTooLow     = "tip below the pool minimum"
+	ReasonPrivateExpiry = "private lifetime expired"
+)
+
+// TxEvent is a lifecycle event of a transaction explaining its promotion, or its
+// absence from the pool or from the blocks built off it.
+type TxEvent struct {
+	Hash       common.Hash
+	From       common.Address // Sender of the transaction, unset for skips
+	Nonce      uint64         // Nonce of the transaction, unset for skips
+	Kind       TxEventKind
+	Reason     string
+	ReplacedBy common.Hash // Hash of the replacement, only set for TxEventReplaced
+	Time       time.Time
+}
+
+// TxEventFunc is the callback subpools report lifecycle events through.
+type TxEventFunc func(event TxEvent)
+
+// eventReporter is implemented by subpools reporting lifecycle events.
+type eventReporter interface {
+	// SetEventFunc sets the callback to report lifecycle events through.
+	SetEventFunc(fn TxEventFunc)
+}
+
+// txEvents retains the latest lifecycle event of recent transactions and posts
+// all events to subscribers. Skips are retained apart from the drops and
+// replacements: block builders report them on every attempt, and they must not
+// push the reasons of transactions leaving the pool out of the buffer.
+type txEvents struct {
+	terminal *eventRing // Drops and replacements
+	skips    *eventRing // Skips by block builders
+	lock     sync.RWMutex
+
+	feed    event.Feed   // Feed delivering the events to subscribers
+	backlog chan TxEvent // Events waiting to be posted to the feed
+}
+
+func newTxEvents(limit int) *txEvents {
+	return &txEvents{
+		terminal: newEventRing(limit),
+		skips:    newEventRing(limit),
+		backlog:  make(chan TxEvent, txEventsBacklog),
+	}
+}
+
+// record stores an event of a transaction, superseding any earlier one of the
+// same kind, and discarding its skips on drops and replacements. The event is
+// posted to subscribers asynchronously, as it's reported with subpool
+// locks held.
+func (e *txEvents) record(event TxEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	select {
+	case e.backlog <- event:
+	default:
+		// Subscribers fell behind, drop the event rather than block the pool
+	}
+	if event.Kind == TxEventPromoted {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if event.Kind == TxEventSkipped {
+		e.skips.add(event)
+		return
+	}
+	e.skips.remove(event.Hash)
+	e.terminal.add(event)
+}
+
+// get returns the retained event of a transaction, or nil if none. Drops and
+// replacements take precedence over skips, which are transient by nature.
+func (e *txEvents) get(hash common.Hash) *TxEvent {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if event := e.terminal.get(hash); event != nil {
+		return event
+	}
+	return e.skips.get(hash)
+}
+
+// eventRing is a bounded ring buffer of events, retaining the latest event per
+// transaction.
+type eventRing struct {
+	hashes []common.Hash
+	events []TxEvent
+	index  map[common.Hash]int // Slot of the latest event of each transaction
+	next   int                 // Slot to write the next event into
+}
+
+func newEventRing(limit int) *eventRing {
+	return &eventRing{
+		hashes: make([]common.Hash, limit),
+		events: make([]TxEvent, limit),
+		index:  make(map[common.Hash]int),
+	}
+}
+
+// add stores an event, superseding any earlier one of the same transaction and
+// overwriting the oldest retained event if the buffer is full.
+func (r *eventRing) add(event TxEvent) {
+	r.remove(event.Hash)
+	if old := r.hashes[r.next]; old != (common.Hash{}) {
+		delete(r.index, old)
+	}
+	r.hashes[r.next], r.events[r.next] = event.Hash, event
+	r.index[event.Hash] = r.next
+	r.next = (r.next + 1) % len(r.hashes)
+}
+
+// remove forgets the event of a transaction, if any.
+func (r *eventRing) remove(hash common.Hash) {
+	if slot, ok := r.index[hash]; ok {
+		r.hashes[slot] = common.Hash{}
+		delete(r.index, hash)
+	}
+}
+
+// get returns the retained event of a transaction, or nil if none.
+func (r *eventRing) get(hash common.Hash) *TxEvent {
+	slot, ok := r.index[hash]
+	if !ok {
+		return nil
+	}
+	event := r.events[slot]
+	return &event
+}
+
+// loop posts the recorded events to the subscribers until the pool terminates.
+func (e *txEvents) loop(term chan struct{}) {
+	for {
+		select {
+		case event := <-e.backlog:
+			e.feed.Send(event)
+		case <-term:
+			return
+		}
+	}
+}
+
+// TxEvent returns the latest retained lifecycle event of a transaction, or nil
+// if none was recorded or it was already forgotten.
+func (p *TxPool) TxEvent(hash common.Hash) *TxEvent {
+	return p.events.get(hash)
+}
+
+// RecordSkipped records that a pending transaction was passed over by a block
+// builder, along with the reason.
+func (p *TxPool) RecordSkipped(hash common.Hash, reason string) {
+	p.events.record(TxEvent{Hash: hash, Kind: TxEventSkipped, Reason: reason})
+}
+
+// SubscribeTxEvents registers a subscription for the lifecycle events of
+// transactions: promotions, drops, replacements and skips.
+func (p *TxPool) SubscribeTxEvents(ch chan<- TxEvent) event.Subscription {
+	return p.subs.Track(p.events.feed.Subscribe(ch))
+}
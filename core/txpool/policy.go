@@ -0,0 +1,303 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// policyReloadInterval is the interval at which the policy file is checked for
+// modifications.
+const policyReloadInterval = 5 * time.Second
+
+// Policy is an operator defined admission rule, applied by the subpools on top
+// of the consensus and resource validation rules.
+type Policy interface {
+	// Name returns the unique name of the policy, used in errors and metrics.
+	Name() string
+
+	// Check returns an error if the transaction is not to be admitted. The
+	// sender is already recovered from the signature.
+	Check(tx *types.Transaction, from common.Address) error
+}
+
+// PolicyError is returned if a transaction is rejected by an admission policy.
+type PolicyError struct {
+	Policy string // Name of the rejecting policy
+	Reason string // Reason of the rejection
+}
+
+// Error implements error.
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", ErrPolicyRejected, e.Policy, e.Reason)
+}
+
+// Unwrap allows matching any policy rejection against ErrPolicyRejected.
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyRejected
+}
+
+// ErrorCode returns the JSON-RPC error code of "transaction rejected".
+func (e *PolicyError) ErrorCode() int {
+	return -32003
+}
+
+// ErrorData returns the name of the rejecting policy.
+func (e *PolicyError) ErrorData() interface{} {
+	return e.Policy
+}
+
+// PolicyConfig is the operator configuration of the built-in admission policies,
+// loaded from a JSON file. Zero values disable the respective policy.
+type PolicyConfig struct {
+	BlockedSenders       []common.Address `json:"blockedSenders"`       // Senders whose transactions are rejected
+	BlockedRecipients    []common.Address `json:"blockedRecipients"`    // Recipients transactions to which are rejected
+	DenyContractCreation bool             `json:"denyContractCreation"` // Whether contract creations are rejected
+	MaxCalldataSize      uint64           `json:"maxCalldataSize"`      // Maximum size of the calldata in bytes
+	MinFeePerByte        uint64           `json:"minFeePerByte"`        // Minimum fee cap times gas limit per encoded byte, in wei
+}
+
+// Policies builds the admission policies enabled by the configuration.
+func (config *PolicyConfig) Policies() []Policy {
+	var policies []Policy
+	if len(config.BlockedSenders) > 0 {
+		policies = append(policies, newAddressPolicy("blocked-senders", config.BlockedSenders, false))
+	}
+	if len(config.BlockedRecipients) > 0 {
+		policies = append(policies, newAddressPolicy("blocked-recipients", config.BlockedRecipients, true))
+	}
+	if config.DenyContractCreation {
+		policies = append(policies, noCreationPolicy{})
+	}
+	if config.MaxCalldataSize > 0 {
+		policies = append(policies, calldataPolicy(config.MaxCalldataSize))
+	}
+	if config.MinFeePerByte > 0 {
+		policies = append(policies, feePerBytePolicy(config.MinFeePerByte))
+	}
+	return policies
+}
+
+// addressPolicy rejects transactions from or to a set of accounts.
+type addressPolicy struct {
+	name      string
+	addrs     map[common.Address]struct{}
+	recipient bool // Whether to match the recipient instead of the sender
+}
+
+func newAddressPolicy(name string, addrs []common.Address, recipient bool) *addressPolicy {
+	policy := &addressPolicy{name: name, addrs: make(map[common.Address]struct{}, len(addrs)), recipient: recipient}
+	for _, addr := range addrs {
+		policy.addrs[addr] = struct{}{}
+	}
+	return policy
+}
+
+func (p *addressPolicy) Name() string { return p.name }
+
+func (p *addressPolicy) Check(tx *types.Transaction, from common.Address) error {
+	if p.recipient {
+		if to := tx.To(); to != nil {
+			if _, ok := p.addrs[*to]; ok {
+				return fmt.Errorf("recipient %v is blocked", *to)
+			}
+		}
+		return nil
+	}
+	if _, ok := p.addrs[from]; ok {
+		return fmt.Errorf("sender %v is blocked", from)
+	}
+	return nil
+}
+
+// noCreationPolicy rejects contract creations.
+type noCreationPolicy struct{}
+
+func (noCreationPolicy) Name() string { return "no-contract-creation" }
+
+func (noCreationPolicy) Check(tx *types.Transaction, from common.Address) error {
+	if tx.To() == nil {
+		return fmt.Errorf("contract creation not allowed")
+	}
+	return nil
+}
+
+// calldataPolicy rejects transactions with calldata above a size limit.
+type calldataPolicy uint64
+
+func (calldataPolicy) Name() string { return "max-calldata-size" }
+
+func (p calldataPolicy) Check(tx *types.Transaction, from common.Address) error {
+	if size := uint64(len(tx.Data())); size > uint64(p) {
+		return fmt.Errorf("calldata size %d, limit %d", size, uint64(p))
+	}
+	return nil
+}
+
+// feePerBytePolicy rejects transactions paying too little for the bytes they
+// occupy in the L2 transaction lists.
+type feePerBytePolicy uint64
+
+func (feePerBytePolicy) Name() string { return "min-fee-per-byte" }
+
+func (p feePerBytePolicy) Check(tx *types.Transaction, from common.Address) error {
+	var (
+		fee  = new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+		size = tx.Size()
+	)
+	if fee.Div(fee, new(big.Int).SetUint64(size)).Cmp(new(big.Int).SetUint64(uint64(p))) < 0 {
+		return fmt.Errorf("fee per byte %v, minimum needed %d", fee, uint64(p))
+	}
+	return nil
+}
+
+// policy is an admission policy along with its rejection meter.
+type policy struct {
+	Policy
+	rejected metrics.Meter
+}
+
+// PolicySet is a hot-reloadable set of admission policies shared by the subpools.
+// A nil set admits every transaction.
+type PolicySet struct {
+	path     string
+	modified time.Time
+	policies atomic.Pointer[[]policy]
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+	lock sync.Mutex // Serializes reloads
+}
+
+// NewPolicySet creates a set of admission policies. If path is not empty, the
+// policies are loaded from the JSON configuration file at path, which is watched
+// for modifications and reloaded until the set is closed.
+func NewPolicySet(path string, policies ...Policy) (*PolicySet, error) {
+	set := &PolicySet{path: path, quit: make(chan struct{})}
+	set.store(policies)
+
+	if path != "" {
+		if err := set.Reload(); err != nil {
+			return nil, err
+		}
+		set.wg.Add(1)
+		go set.loop()
+	}
+	return set, nil
+}
+
+// store replaces the active policies.
+func (s *PolicySet) store(policies []Policy) {
+	active := make([]policy, len(policies))
+	for i, p := range policies {
+		active[i] = policy{Policy: p, rejected: metrics.GetOrRegisterMeter("txpool/policy/"+p.Name()+"/rejected", nil)}
+	}
+	s.policies.Store(&active)
+}
+
+// Reload reloads the policies from the configuration file. On failure, the
+// previous policies stay active.
+func (s *PolicySet) Reload() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.path == "" {
+		return nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	blob, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var config PolicyConfig
+	if err := json.Unmarshal(blob, &config); err != nil {
+		return fmt.Errorf("invalid txpool policy file %s: %v", s.path, err)
+	}
+	policies := config.Policies()
+	s.store(policies)
+	s.modified = info.ModTime()
+
+	log.Info("Loaded transaction admission policies", "path", s.path, "policies", len(policies))
+	return nil
+}
+
+// loop reloads the policies whenever the configuration file is modified.
+func (s *PolicySet) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(policyReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(s.path)
+			if err != nil {
+				continue
+			}
+			s.lock.Lock()
+			modified := !info.ModTime().Equal(s.modified)
+			s.lock.Unlock()
+
+			if modified {
+				if err := s.Reload(); err != nil {
+					log.Warn("Failed to reload transaction admission policies", "err", err)
+				}
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// Close stops watching the configuration file for modifications.
+func (s *PolicySet) Close() {
+	if s == nil {
+		return
+	}
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// Check runs the transaction through all active policies, returning a PolicyError
+// from the first one rejecting it.
+func (s *PolicySet) Check(tx *types.Transaction, from common.Address) error {
+	if s == nil {
+		return nil
+	}
+	for _, p := range *s.policies.Load() {
+		if err := p.Check(tx, from); err != nil {
+			p.rejected.Mark(1)
+			return &PolicyError{Policy: p.Name(), Reason: err.Error()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// privateHistoryLimit is the number of private transactions which left the pool
+// that are remembered for status queries.
+const privateHistoryLimit = 4096
+
+// txRemover is implemented by subpools which can drop a single transaction on
+// request, a prerequisite for accepting private transactions.
+type txRemover interface {
+	// RemoveTx drops a transaction from the pool, reporting whether it was found.
+	RemoveTx(hash common.Hash) bool
+}
+
+// PrivateTx is the tracking information of a private transaction.
+type PrivateTx struct {
+	Expiry  uint64   // Block number at which the transaction is dropped
+	Status  TxStatus // Status of the transaction in the pool, unknown once it left
+	Expired bool     // Whether the transaction was dropped due to its expiry
+}
+
+// privateTxs tracks the transactions submitted privately. They are held in the
+// subpools like any other, but are never announced to the network and expire
+// after a number of blocks.
+type privateTxs struct {
+	live    map[common.Hash]uint64                // Expiry blocks of the private transactions in the pool
+	history lru.BasicLRU[common.Hash, *PrivateTx] // Private transactions which left the pool
+	head    uint64                                // Number of the head the pool was last reset to
+	lock    sync.RWMutex
+}
+
+func newPrivateTxs(head uint64) *privateTxs {
+	return &privateTxs{
+		live:    make(map[common.Hash]uint64),
+		history: lru.NewBasicLRU[common.Hash, *PrivateTx](privateHistoryLimit),
+		head:    head,
+	}
+}
+
+// AddPrivate enqueues a batch of transactions into the pool like Add, marking
+// them private. Private transactions are only handed out for block building and
+// are dropped if not included within lifetime blocks.
+//
+// Transactions are added as remote ones, so they aren't journaled and can't come
+// back as public ones after a restart.
+func (p *TxPool) AddPrivate(txs []*types.Transaction, lifetime uint64) []error {
+	var (
+		errs  = make([]error, len(txs))
+		added []*types.Transaction
+		index []int
+	)
+	p.private.lock.Lock()
+	expiry := p.private.head + lifetime
+	for i, tx := range txs {
+		hash := tx.Hash()
+		switch {
+		case p.Has(hash):
+			errs[i] = ErrAlreadyKnown
+		case !p.removable(tx):
+			errs[i] = ErrPrivateUnsupported
+		default:
+			p.private.live[hash] = expiry
+			p.private.history.Remove(hash)
+			added = append(added, tx)
+			index = append(index, i)
+		}
+	}
+	p.private.lock.Unlock()
+
+	for i, err := range p.Add(added, false, false) {
+		errs[index[i]] = err
+		if err != nil {
+			p.private.lock.Lock()
+			delete(p.private.live, added[i].Hash())
+			p.private.lock.Unlock()
+		}
+	}
+	return errs
+}
+
+// removable reports whether the subpool accepting the transaction can drop it
+// once it expires.
+func (p *TxPool) removable(tx *types.Transaction) bool {
+	for _, subpool := range p.subpools {
+		if subpool.Filter(tx) {
+			_, ok := subpool.(txRemover)
+			return ok
+		}
+	}
+	return false
+}
+
+// IsPrivate reports whether the transaction was submitted privately and is thus
+// not to be propagated.
+func (p *TxPool) IsPrivate(hash common.Hash) bool {
+	p.private.lock.RLock()
+	defer p.private.lock.RUnlock()
+
+	_, ok := p.private.live[hash]
+	return ok
+}
+
+// PrivateTx returns the tracking information of a private transaction, or nil if
+// the transaction is unknown or was forgotten.
+func (p *TxPool) PrivateTx(hash common.Hash) *PrivateTx {
+	p.private.lock.RLock()
+	expiry, live := p.private.live[hash]
+	info, left := p.private.history.Peek(hash)
+	p.private.lock.RUnlock()
+
+	switch {
+	case live:
+		return &PrivateTx{Expiry: expiry, Status: p.Status(hash)}
+	case left:
+		cpy := *info
+		return &cpy
+	default:
+		return nil
+	}
+}
+
+// expirePrivate drops the private transactions which expired at the given head
+// and forgets the ones which left the pool otherwise, either by inclusion or by
+// eviction.
+func (p *TxPool) expirePrivate(head *types.Header) {
+	p.private.lock.Lock()
+	defer p.private.lock.Unlock()
+
+	number := head.Number.Uint64()
+	p.private.head = number
+
+	var expired int
+	for hash, expiry := range p.private.live {
+		switch {
+		case expiry <= number:
+			for _, subpool := range p.subpools {
+				if remover, ok := subpool.(txRemover); ok && remover.RemoveTx(hash) {
+					p.events.record(TxEvent{Hash: hash, Kind: TxEventDropped, Reason: ReasonPrivateExpiry})
+					expired++
+					break
+				}
+			}
+			p.private.history.Add(hash, &PrivateTx{Expiry: expiry, Expired: true})
+		case !p.Has(hash):
+			p.private.history.Add(hash, &PrivateTx{Expiry: expiry})
+		default:
+			continue
+		}
+		delete(p.private.live, hash)
+	}
+	if expired > 0 {
+		log.Debug("Dropped expired private transactions", "count", expired, "head", number)
+	}
+}
+
+// SubscribePublicTransactions registers a subscription for new transaction
+// events like SubscribeTransactions, but omits private transactions.
+func (p *TxPool) SubscribePublicTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription {
+	return p.subs.Track(event.NewSubscription(func(quit <-chan struct{}) error {
+		// Subscribe to the subpools directly, as a subscription tracked by the
+		// pool's scope could not be torn down while the scope is being closed.
+		events := make(chan core.NewTxsEvent)
+		subs := make([]event.Subscription, len(p.subpools))
+		for i, subpool := range p.subpools {
+			subs[i] = subpool.SubscribeTransactions(events, reorgs)
+		}
+		sub := event.JoinSubscriptions(subs...)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				txs := make([]*types.Transaction, 0, len(ev.Txs))
+				for _, tx := range ev.Txs {
+					if !p.IsPrivate(tx.Hash()) {
+						txs = append(txs, tx)
+					}
+				}
+				if len(txs) == 0 {
+					continue
+				}
+				select {
+				case ch <- core.NewTxsEvent{Txs: txs}:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}))
+}
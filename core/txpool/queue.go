@@ -0,0 +1,69 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceRange is an inclusive range of nonces.
+type NonceRange struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// QueuedTx is a transaction waiting for promotion.
+type QueuedTx struct {
+	Hash  common.Hash `json:"hash"`
+	Nonce uint64      `json:"nonce"`
+	Since time.Time   `json:"since"` // Time the transaction was first seen
+}
+
+// QueuedAccount describes why the queued transactions of an account are not
+// promoted to executable ones, and when they are evicted if that doesn't happen.
+type QueuedAccount struct {
+	Address   common.Address `json:"address"`
+	Local     bool           `json:"local"`
+	NextNonce uint64         `json:"nextNonce"` // Nonce of the next executable transaction
+	Missing   []NonceRange   `json:"missing"`   // Nonces missing to promote the queued transactions
+	Queued    []QueuedTx     `json:"queued"`
+
+	Heartbeat time.Time  `json:"heartbeat"`          // Last activity of the account, which the eviction is timed from
+	Eviction  *time.Time `json:"eviction,omitempty"` // Time the queue is evicted if not promoted, unset for locals
+}
+
+// queueReporter is implemented by subpools holding non-executable transactions.
+type queueReporter interface {
+	// Queued returns the queued transactions of the given accounts, or of all
+	// accounts if none are given.
+	Queued(addrs []common.Address) []*QueuedAccount
+}
+
+// Queued returns the queued transactions of the given accounts, or of all the
+// accounts with queued transactions if none are given, along with the nonces
+// blocking their promotion.
+func (p *TxPool) Queued(addrs []common.Address) []*QueuedAccount {
+	var accounts []*QueuedAccount
+	for _, subpool := range p.subpools {
+		if reporter, ok := subpool.(queueReporter); ok {
+			accounts = append(accounts, reporter.Queued(addrs)...)
+		}
+	}
+	return accounts
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//go:generate go run github.com/fjl/gencodec -type SnapshotHeader -field-override snapshotHeaderMarshaling -out gen_snapshot_header_json.go
+//go:generate go run github.com/fjl/gencodec -type SnapshotTx -field-override snapshotTxMarshaling -out gen_snapshot_tx_json.go
+
+// SnapshotHeader identifies the moment a pool snapshot was taken at.
+type SnapshotHeader struct {
+	Number uint64      `json:"number" gencodec:"required"` // Number of the chain head
+	Hash   common.Hash `json:"hash"   gencodec:"required"` // Hash of the chain head
+	Time   uint64      `json:"time"   gencodec:"required"` // Creation time of the snapshot, unix seconds
+}
+
+type snapshotHeaderMarshaling struct {
+	Number hexutil.Uint64
+	Time   hexutil.Uint64
+}
+
+// SnapshotTx is a transaction of a pool snapshot, along with the metadata the
+// pool maintains about it.
+type SnapshotTx struct {
+	Tx      *types.Transaction `json:"tx"      gencodec:"required"`
+	Sender  common.Address     `json:"sender"  gencodec:"required"`
+	Pending bool               `json:"pending"`                     // Whether the transaction is executable, or queued
+	Local   bool               `json:"local"`                       // Whether the sender is a local account
+	Time    uint64             `json:"time"    gencodec:"required"` // Arrival time, unix nanoseconds
+}
+
+type snapshotTxMarshaling struct {
+	Time hexutil.Uint64
+}
+
+// ErrSnapshotExists is returned if a snapshot would overwrite an existing file.
+// Allowing overwrites could be a DoS vector, since the file may be requested over
+// RPC and point to arbitrary paths on the drive.
+var ErrSnapshotExists = errors.New("location would overwrite an existing file")
+
+// Snapshot is the full content of the pool at a given moment, for analysing or
+// replaying it offline.
+type Snapshot struct {
+	Header SnapshotHeader `json:"header"`
+	Txs    []*SnapshotTx  `json:"txs"` // Transactions ordered by sender and nonce
+}
+
+// Snapshot returns the content of all subpools, taken while the pool is at the
+// given head. Privately submitted transactions are not included.
+func (p *TxPool) Snapshot(head *types.Header) *Snapshot {
+	snap := &Snapshot{
+		Header: SnapshotHeader{
+			Number: head.Number.Uint64(),
+			Hash:   head.Hash(),
+			Time:   uint64(time.Now().Unix()),
+		},
+		Txs: []*SnapshotTx{},
+	}
+	locals := make(map[common.Address]bool)
+	for _, local := range p.Locals() {
+		locals[local] = true
+	}
+	pending, queued := p.Content()
+
+	add := func(content map[common.Address][]*types.Transaction, executable bool) {
+		for addr, txs := range content {
+			for _, tx := range txs {
+				if p.IsPrivate(tx.Hash()) {
+					continue
+				}
+				var arrival uint64
+				if t := tx.Time(); !t.IsZero() {
+					arrival = uint64(t.UnixNano())
+				}
+				snap.Txs = append(snap.Txs, &SnapshotTx{
+					Tx:      tx,
+					Sender:  addr,
+					Pending: executable,
+					Local:   locals[addr],
+					Time:    arrival,
+				})
+			}
+		}
+	}
+	add(pending, true)
+	add(queued, false)
+
+	sort.Slice(snap.Txs, func(i, j int) bool {
+		a, b := snap.Txs[i], snap.Txs[j]
+		if a.Sender != b.Sender {
+			return bytes.Compare(a.Sender[:], b.Sender[:]) < 0
+		}
+		return a.Tx.Nonce() < b.Tx.Nonce()
+	})
+	return snap
+}
+
+// isJSONLines returns whether a snapshot file is written as JSON lines, rather
+// than as an RLP stream.
+func isJSONLines(path string) bool {
+	return strings.HasSuffix(path, ".jsonl")
+}
+
+// ExportSnapshot writes the snapshot into a file, as JSON lines if its name ends
+// with ".jsonl", or as an RLP stream otherwise. Both formats consist of the
+// header followed by the transactions, one item each. Existing files are never
+// overwritten.
+func ExportSnapshot(path string, snap *Snapshot) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return ErrSnapshotExists
+	}
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(out)
+
+	encode := func(v interface{}) error { return rlp.Encode(writer, v) }
+	if isJSONLines(path) {
+		encode = json.NewEncoder(writer).Encode
+	}
+	if err = encode(&snap.Header); err == nil {
+		for _, tx := range snap.Txs {
+			if err = encode(tx); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ImportSnapshot reads a snapshot file written by ExportSnapshot. The arrival
+// times of the transactions are restored, so that they are ordered the same as
+// they were in the pool.
+func ImportSnapshot(path string) (*Snapshot, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	var decode func(v interface{}) error
+	if isJSONLines(path) {
+		decode = json.NewDecoder(bufio.NewReader(in)).Decode
+	} else {
+		decode = rlp.NewStream(bufio.NewReader(in), 0).Decode
+	}
+	snap := &Snapshot{Txs: []*SnapshotTx{}}
+	if err := decode(&snap.Header); err != nil {
+		return nil, fmt.Errorf("invalid snapshot header: %v", err)
+	}
+	for {
+		tx := new(SnapshotTx)
+		if err := decode(tx); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("transaction %d: invalid snapshot entry: %v", len(snap.Txs), err)
+		}
+		if tx.Time != 0 {
+			tx.Tx.SetTime(time.Unix(0, int64(tx.Time)))
+		}
+		snap.Txs = append(snap.Txs, tx)
+	}
+	return snap, nil
+}
@@ -70,6 +70,9 @@ type TxPool struct {
 	reservations map[common.Address]SubPool // Map with the account to pool reservations
 	reserveLock  sync.Mutex                 // Lock protecting the account reservations
 
+	private *privateTxs // Transactions submitted privately, never to be propagated
+	events  *txEvents   // Recent lifecycle events of transactions leaving the pool
+
 	subs event.SubscriptionScope // Subscription scope to unsubscribe all on shutdown
 	quit chan chan error         // Quit channel to tear down the head updater
 	term chan struct{}           // Termination channel to detect a closed pool
@@ -88,11 +91,16 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 	pool := &TxPool{
 		subpools:     subpools,
 		reservations: make(map[common.Address]SubPool),
+		private:      newPrivateTxs(head.Number.Uint64()),
+		events:       newTxEvents(txEventsLimit),
 		quit:         make(chan chan error),
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
 	}
 	for i, subpool := range subpools {
+		if reporter, ok := subpool.(eventReporter); ok {
+			reporter.SetEventFunc(pool.events.record)
+		}
 		if err := subpool.Init(gasTip, head, pool.reserver(i, subpool)); err != nil {
 			for j := i - 1; j >= 0; j-- {
 				subpools[j].Close()
@@ -101,6 +109,7 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 		}
 	}
 	go pool.loop(head, chain)
+	go pool.events.loop(pool.term)
 	return pool, nil
 }
 
@@ -223,6 +232,7 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 					for _, subpool := range p.subpools {
 						subpool.Reset(oldHead, newHead)
 					}
+					p.expirePrivate(newHead)
 					resetDone <- newHead
 				}(oldHead, newHead)
 
@@ -342,6 +352,9 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 		// If the transaction was rejected by all subpools, mark it unsupported
 		if split == -1 {
 			errs[i] = core.ErrTxTypeNotSupported
+			if txs[i].Type() == types.BlobTxType {
+				errs[i] = ErrBlobTxsDisabled
+			}
 			continue
 		}
 		// Find which subpool handled it and pull in the corresponding error
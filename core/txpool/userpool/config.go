@@ -0,0 +1,84 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package userpool
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Config are the configuration parameters of the user operation pool.
+type Config struct {
+	EntryPoints []common.Address // Entry points operations are accepted for, the pool is disabled if empty
+
+	MaxOps             int           // Maximum number of operations maintained by the pool
+	MaxOpsPerSender    int           // Maximum number of operations of a single sender
+	MaxVerificationGas uint64        // Maximum verification gas limit of an operation
+	PriceBump          uint64        // Minimum fee bump percentage to replace an operation
+	Lifetime           time.Duration // Maximum amount of time an operation waits for inclusion
+
+	Bundler      common.Address // Account submitting the operations as bundles to the block builder, disabled if unset
+	MaxBundleOps int            // Maximum number of operations in a single bundle
+}
+
+// DefaultConfig contains the default configurations for the user operation pool.
+var DefaultConfig = Config{
+	MaxOps:             4096,
+	MaxOpsPerSender:    4,
+	MaxVerificationGas: 5_000_000,
+	PriceBump:          10,
+	Lifetime:           30 * time.Minute,
+	MaxBundleOps:       16,
+}
+
+// Enabled reports whether the pool accepts operations.
+func (config *Config) Enabled() bool {
+	return len(config.EntryPoints) > 0
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *Config) sanitize() Config {
+	conf := *config
+	if conf.MaxOps < 1 {
+		log.Warn("Sanitizing invalid userpool capacity", "provided", conf.MaxOps, "updated", DefaultConfig.MaxOps)
+		conf.MaxOps = DefaultConfig.MaxOps
+	}
+	if conf.MaxOpsPerSender < 1 {
+		log.Warn("Sanitizing invalid userpool sender limit", "provided", conf.MaxOpsPerSender, "updated", DefaultConfig.MaxOpsPerSender)
+		conf.MaxOpsPerSender = DefaultConfig.MaxOpsPerSender
+	}
+	if conf.MaxVerificationGas < 1 {
+		log.Warn("Sanitizing invalid userpool verification gas limit", "provided", conf.MaxVerificationGas, "updated", DefaultConfig.MaxVerificationGas)
+		conf.MaxVerificationGas = DefaultConfig.MaxVerificationGas
+	}
+	if conf.PriceBump < 1 {
+		log.Warn("Sanitizing invalid userpool price bump", "provided", conf.PriceBump, "updated", DefaultConfig.PriceBump)
+		conf.PriceBump = DefaultConfig.PriceBump
+	}
+	if conf.Lifetime < 1 {
+		log.Warn("Sanitizing invalid userpool lifetime", "provided", conf.Lifetime, "updated", DefaultConfig.Lifetime)
+		conf.Lifetime = DefaultConfig.Lifetime
+	}
+	if conf.MaxBundleOps < 1 {
+		log.Warn("Sanitizing invalid userpool bundle size", "provided", conf.MaxBundleOps, "updated", DefaultConfig.MaxBundleOps)
+		conf.MaxBundleOps = DefaultConfig.MaxBundleOps
+	}
+	return conf
+}
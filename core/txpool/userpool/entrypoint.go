@@ -0,0 +1,205 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package userpool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// entryPointABI is the subset of the v0.6 EntryPoint interface used by the pool.
+const entryPointABI = `[
+	{"type":"function","name":"handleOps","inputs":[{"name":"ops","type":"tuple[]","components":[{"name":"sender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"initCode","type":"bytes"},{"name":"callData","type":"bytes"},{"name":"callGasLimit","type":"uint256"},{"name":"verificationGasLimit","type":"uint256"},{"name":"preVerificationGas","type":"uint256"},{"name":"maxFeePerGas","type":"uint256"},{"name":"maxPriorityFeePerGas","type":"uint256"},{"name":"paymasterAndData","type":"bytes"},{"name":"signature","type":"bytes"}]},{"name":"beneficiary","type":"address"}],"outputs":[]},
+	{"type":"function","name":"simulateValidation","inputs":[{"name":"userOp","type":"tuple","components":[{"name":"sender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"initCode","type":"bytes"},{"name":"callData","type":"bytes"},{"name":"callGasLimit","type":"uint256"},{"name":"verificationGasLimit","type":"uint256"},{"name":"preVerificationGas","type":"uint256"},{"name":"maxFeePerGas","type":"uint256"},{"name":"maxPriorityFeePerGas","type":"uint256"},{"name":"paymasterAndData","type":"bytes"},{"name":"signature","type":"bytes"}]}],"outputs":[]},
+	{"type":"error","name":"ValidationResult","inputs":[{"name":"returnInfo","type":"tuple","components":[{"name":"preOpGas","type":"uint256"},{"name":"prefund","type":"uint256"},{"name":"sigFailed","type":"bool"},{"name":"validAfter","type":"uint48"},{"name":"validUntil","type":"uint48"},{"name":"paymasterContext","type":"bytes"}]},{"name":"senderInfo","type":"tuple","components":[{"name":"stake","type":"uint256"},{"name":"unstakeDelaySec","type":"uint256"}]},{"name":"factoryInfo","type":"tuple","components":[{"name":"stake","type":"uint256"},{"name":"unstakeDelaySec","type":"uint256"}]},{"name":"paymasterInfo","type":"tuple","components":[{"name":"stake","type":"uint256"},{"name":"unstakeDelaySec","type":"uint256"}]}]},
+	{"type":"error","name":"FailedOp","inputs":[{"name":"opIndex","type":"uint256"},{"name":"reason","type":"string"}]},
+	{"type":"event","name":"UserOperationEvent","anonymous":false,"inputs":[{"name":"userOpHash","type":"bytes32","indexed":true},{"name":"sender","type":"address","indexed":true},{"name":"paymaster","type":"address","indexed":true},{"name":"nonce","type":"uint256","indexed":false},{"name":"success","type":"bool","indexed":false},{"name":"actualGasCost","type":"uint256","indexed":false},{"name":"actualGasUsed","type":"uint256","indexed":false}]},
+	{"type":"event","name":"BeforeExecution","anonymous":false,"inputs":[]}
+]`
+
+var (
+	// entryPoint is the parsed EntryPoint interface.
+	entryPoint = func() abi.ABI {
+		parsed, err := abi.JSON(strings.NewReader(entryPointABI))
+		if err != nil {
+			panic(err)
+		}
+		return parsed
+	}()
+
+	// UserOperationEventTopic is the topic of the event the entry point emits for
+	// every executed operation.
+	UserOperationEventTopic = entryPoint.Events["UserOperationEvent"].ID
+
+	// BeforeExecutionTopic is the topic of the event the entry point emits once
+	// all operations of a bundle are validated, before executing them.
+	BeforeExecutionTopic = entryPoint.Events["BeforeExecution"].ID
+)
+
+// ValidationResult is the outcome of a successful EntryPoint.simulateValidation.
+type ValidationResult struct {
+	PreOpGas   *big.Int // Gas used by the validation, including the pre-verification gas
+	Prefund    *big.Int // Amount to be prefunded by the sender or the paymaster
+	SigFailed  bool     // Whether the signature of the operation is invalid
+	ValidAfter uint64   // Timestamp the operation is valid from
+	ValidUntil uint64   // Timestamp the operation is valid until, unbounded if zero
+}
+
+// Expired reports whether the operation is no longer valid at the given time.
+func (r *ValidationResult) Expired(time uint64) bool {
+	return r.ValidUntil != 0 && r.ValidUntil <= time
+}
+
+// FailedOpError is returned if the entry point rejects an operation.
+type FailedOpError struct {
+	Reason string
+}
+
+func (e *FailedOpError) Error() string {
+	return fmt.Sprintf("entry point rejected operation: %s", e.Reason)
+}
+
+// ErrorCode returns the ERC-4337 error code of operations rejected by the entry
+// point.
+func (e *FailedOpError) ErrorCode() int { return -32500 }
+
+// packUserOperation ABI encodes the operation as an argument of the entry point.
+func packUserOperation(op *UserOperation) ([]byte, error) {
+	args := entryPoint.Methods["simulateValidation"].Inputs
+	return args.Pack(op)
+}
+
+// PackSimulateValidation returns the calldata of EntryPoint.simulateValidation.
+func PackSimulateValidation(op *UserOperation) ([]byte, error) {
+	return entryPoint.Pack("simulateValidation", op)
+}
+
+// PackHandleOps returns the calldata of EntryPoint.handleOps, paying the bundle
+// compensation to the beneficiary.
+func PackHandleOps(ops []*UserOperation, beneficiary common.Address) ([]byte, error) {
+	args := make([]UserOperation, len(ops))
+	for i, op := range ops {
+		args[i] = *op
+	}
+	return entryPoint.Pack("handleOps", args, beneficiary)
+}
+
+// returnInfo is the validation outcome of the ValidationResult error.
+type returnInfo struct {
+	PreOpGas         *big.Int
+	Prefund          *big.Int
+	SigFailed        bool
+	ValidAfter       *big.Int
+	ValidUntil       *big.Int
+	PaymasterContext []byte
+}
+
+// UnpackValidationResult decodes the revert data of EntryPoint.simulateValidation,
+// which always reverts, either with the result of the validation or with the
+// reason the operation was rejected.
+func UnpackValidationResult(data []byte) (*ValidationResult, error) {
+	if len(data) < 4 {
+		return nil, errors.New("simulateValidation did not revert with a result")
+	}
+	var id [4]byte
+	copy(id[:], data)
+	failed := entryPoint.Errors["FailedOp"]
+	if id == [4]byte(failed.ID[:4]) {
+		values, err := failed.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		return nil, &FailedOpError{Reason: values[1].(string)}
+	}
+	result := entryPoint.Errors["ValidationResult"]
+	if id != [4]byte(result.ID[:4]) {
+		return nil, fmt.Errorf("unexpected simulateValidation revert %#x", data)
+	}
+	values, err := result.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	info := abi.ConvertType(values[0], new(returnInfo)).(*returnInfo)
+	return &ValidationResult{
+		PreOpGas:   info.PreOpGas,
+		Prefund:    info.Prefund,
+		SigFailed:  info.SigFailed,
+		ValidAfter: info.ValidAfter.Uint64(),
+		ValidUntil: info.ValidUntil.Uint64(),
+	}, nil
+}
+
+// OperationEvent is a decoded UserOperationEvent of the entry point.
+type OperationEvent struct {
+	Hash          common.Hash
+	EntryPoint    common.Address
+	Sender        common.Address
+	Paymaster     common.Address
+	Nonce         *big.Int
+	Success       bool
+	ActualGasCost *big.Int
+	ActualGasUsed *big.Int
+
+	Logs []*types.Log // Logs emitted by the execution of the operation
+	Log  *types.Log   // The event itself
+}
+
+// UnpackOperationEvents decodes the UserOperationEvents emitted by the given entry
+// points in a transaction receipt, along with the logs emitted while executing
+// each operation.
+func UnpackOperationEvents(receipt *types.Receipt, entryPoints map[common.Address]bool) []*OperationEvent {
+	var (
+		events []*OperationEvent
+		start  int // Index of the first log of the operation being executed
+	)
+	for i, log := range receipt.Logs {
+		if !entryPoints[log.Address] || len(log.Topics) == 0 {
+			continue
+		}
+		if log.Topics[0] == BeforeExecutionTopic {
+			start = i + 1
+			continue
+		}
+		if log.Topics[0] != UserOperationEventTopic || len(log.Topics) != 4 {
+			continue
+		}
+		values, err := entryPoint.Events["UserOperationEvent"].Inputs.NonIndexed().Unpack(log.Data)
+		if err != nil {
+			continue
+		}
+		events = append(events, &OperationEvent{
+			Hash:          log.Topics[1],
+			EntryPoint:    log.Address,
+			Sender:        common.BytesToAddress(log.Topics[2][:]),
+			Paymaster:     common.BytesToAddress(log.Topics[3][:]),
+			Nonce:         values[0].(*big.Int),
+			Success:       values[1].(bool),
+			ActualGasCost: values[2].(*big.Int),
+			ActualGasUsed: values[3].(*big.Int),
+			Logs:          receipt.Logs[start:i],
+			Log:           log,
+		})
+		start = i + 1
+	}
+	return events
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package userpool
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+//go:generate go run github.com/fjl/gencodec -type UserOperation -field-override userOperationMarshaling -out gen_useroperation_json.go
+
+// UserOperation is an ERC-4337 user operation, in the unpacked form of the v0.6
+// EntryPoint contract.
+type UserOperation struct {
+	Sender               common.Address `json:"sender"               gencodec:"required"`
+	Nonce                *big.Int       `json:"nonce"                gencodec:"required"`
+	InitCode             []byte         `json:"initCode"             gencodec:"required"`
+	CallData             []byte         `json:"callData"             gencodec:"required"`
+	CallGasLimit         *big.Int       `json:"callGasLimit"         gencodec:"required"`
+	VerificationGasLimit *big.Int       `json:"verificationGasLimit" gencodec:"required"`
+	PreVerificationGas   *big.Int       `json:"preVerificationGas"   gencodec:"required"`
+	MaxFeePerGas         *big.Int       `json:"maxFeePerGas"         gencodec:"required"`
+	MaxPriorityFeePerGas *big.Int       `json:"maxPriorityFeePerGas" gencodec:"required"`
+	PaymasterAndData     []byte         `json:"paymasterAndData"     gencodec:"required"`
+	Signature            []byte         `json:"signature"            gencodec:"required"`
+}
+
+type userOperationMarshaling struct {
+	Nonce                *hexutil.Big
+	InitCode             hexutil.Bytes
+	CallData             hexutil.Bytes
+	CallGasLimit         *hexutil.Big
+	VerificationGasLimit *hexutil.Big
+	PreVerificationGas   *hexutil.Big
+	MaxFeePerGas         *hexutil.Big
+	MaxPriorityFeePerGas *hexutil.Big
+	PaymasterAndData     hexutil.Bytes
+	Signature            hexutil.Bytes
+}
+
+// Hash returns the hash identifying the operation on the given entry point and
+// chain, as computed by EntryPoint.getUserOpHash.
+func (op *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) common.Hash {
+	packed := crypto.Keccak256(
+		common.LeftPadBytes(op.Sender[:], 32),
+		common.LeftPadBytes(op.Nonce.Bytes(), 32),
+		crypto.Keccak256(op.InitCode),
+		crypto.Keccak256(op.CallData),
+		common.LeftPadBytes(op.CallGasLimit.Bytes(), 32),
+		common.LeftPadBytes(op.VerificationGasLimit.Bytes(), 32),
+		common.LeftPadBytes(op.PreVerificationGas.Bytes(), 32),
+		common.LeftPadBytes(op.MaxFeePerGas.Bytes(), 32),
+		common.LeftPadBytes(op.MaxPriorityFeePerGas.Bytes(), 32),
+		crypto.Keccak256(op.PaymasterAndData),
+	)
+	return crypto.Keccak256Hash(
+		packed,
+		common.LeftPadBytes(entryPoint[:], 32),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+	)
+}
+
+// Factory returns the factory deploying the sender, or nil if it is deployed.
+func (op *UserOperation) Factory() *common.Address {
+	if len(op.InitCode) < common.AddressLength {
+		return nil
+	}
+	factory := common.BytesToAddress(op.InitCode[:common.AddressLength])
+	return &factory
+}
+
+// Paymaster returns the paymaster sponsoring the operation, or nil if the sender
+// pays for itself.
+func (op *UserOperation) Paymaster() *common.Address {
+	if len(op.PaymasterAndData) < common.AddressLength {
+		return nil
+	}
+	paymaster := common.BytesToAddress(op.PaymasterAndData[:common.AddressLength])
+	return &paymaster
+}
+
+// GasLimit returns the maximum gas the entry point may spend on the operation:
+// the verification gas is charged up to three times if a paymaster is used, for
+// its validation and post-operation calls.
+func (op *UserOperation) GasLimit() uint64 {
+	verification := op.VerificationGasLimit.Uint64()
+	if op.Paymaster() != nil {
+		verification *= 3
+	}
+	return op.PreVerificationGas.Uint64() + verification + op.CallGasLimit.Uint64()
+}
+
+// Gas overheads of including an operation in a handleOps bundle, which are not
+// accounted for by the entry point and have to be covered by the pre-verification
+// gas.
+const (
+	bundleOverheadGas = params.TxGas // Intrinsic gas of the bundle transaction, assuming a single operation
+	perOpOverheadGas  = 18300        // Fixed cost of an operation in handleOps
+	perOpWordGas      = 4            // Cost of copying a word of the packed operation
+)
+
+// PreVerificationGas returns the minimum pre-verification gas of an operation
+// included alone in a bundle: the calldata cost of the packed operation and the
+// fixed overheads of the bundle transaction and of handleOps.
+func PreVerificationGas(op *UserOperation) uint64 {
+	// Estimate with a placeholder of the maximum size for the unknown gas value
+	// and a non-zero signature, as the final ones only become known later
+	cpy := *op
+	cpy.PreVerificationGas = new(big.Int).SetUint64(1 << 32)
+	if len(cpy.Signature) == 0 {
+		cpy.Signature = bytes.Repeat([]byte{0xff}, 65)
+	}
+	packed, err := packUserOperation(&cpy)
+	if err != nil {
+		return 0
+	}
+	gas := bundleOverheadGas + perOpOverheadGas + uint64(len(packed)+31)/32*perOpWordGas
+	for _, b := range packed {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	return gas
+}
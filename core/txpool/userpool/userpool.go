@@ -0,0 +1,523 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package userpool implements a pool of ERC-4337 user operations.
+package userpool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// inclusionsLimit is the number of recently included operations whose receipts
+	// are retained.
+	inclusionsLimit = 8192
+
+	// maxResetDepth is the maximum number of blocks scanned for included
+	// operations on a head change.
+	maxResetDepth = 64
+)
+
+var (
+	// ErrDisabled is returned if no entry points are configured.
+	ErrDisabled = errors.New("user operations are not supported")
+
+	// ErrUnsupportedEntryPoint is returned if an operation targets an entry point
+	// the pool is not configured for.
+	ErrUnsupportedEntryPoint = errors.New("unsupported entry point")
+
+	// ErrInvalidFields is returned if an operation has missing or malformed fields.
+	ErrInvalidFields = errors.New("invalid user operation fields")
+
+	// ErrPreVerificationGas is returned if the pre-verification gas of an operation
+	// doesn't cover the cost of including it in a bundle.
+	ErrPreVerificationGas = errors.New("pre-verification gas too low")
+
+	// ErrVerificationGas is returned if the verification gas limit of an operation
+	// exceeds the pool limit.
+	ErrVerificationGas = errors.New("verification gas limit too high")
+
+	// ErrInvalidSignature is returned if the account or the paymaster reports an
+	// invalid signature.
+	ErrInvalidSignature = errors.New("invalid user operation signature")
+
+	// ErrExpired is returned if an operation is not valid in the next blocks.
+	ErrExpired = errors.New("user operation expired or not yet valid")
+
+	// ErrSenderLimit is returned if the sender has the maximum number of
+	// operations in the pool.
+	ErrSenderLimit = errors.New("too many operations of sender")
+
+	// ErrPoolFull is returned if the pool holds the maximum number of operations.
+	ErrPoolFull = errors.New("user operation pool is full")
+)
+
+var opsGauge = metrics.NewRegisteredGauge("userpool/ops", nil)
+
+// BlockChain defines the minimal set of methods needed to back a user operation
+// pool with a chain. Exists to allow mocking the live chain out of tests.
+type BlockChain interface {
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// CurrentBlock returns the current head of the chain.
+	CurrentBlock() *types.Header
+
+	// GetBlock retrieves a specific block, used during pool resets.
+	GetBlock(hash common.Hash, number uint64) *types.Block
+
+	// GetReceiptsByHash retrieves the receipts of a block.
+	GetReceiptsByHash(hash common.Hash) types.Receipts
+
+	// SubscribeChainHeadEvent subscribes to new blocks being added to the chain.
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// ValidateFunc simulates the validation of an operation on an entry point at the
+// given head, checking that it only accesses what it's allowed to.
+type ValidateFunc func(op *UserOperation, entryPoint common.Address, head *types.Header) (*ValidationResult, error)
+
+// BundleFunc submits the operations of each entry point to be bundled in the
+// block on top of the given head.
+type BundleFunc func(ops map[common.Address][]*UserOperation, head *types.Header) error
+
+// Inclusion is an operation executed in the chain.
+type Inclusion struct {
+	*OperationEvent
+	Receipt *types.Receipt // Receipt of the bundle transaction
+}
+
+// poolOp is an operation waiting in the pool.
+type poolOp struct {
+	op         *UserOperation
+	hash       common.Hash
+	entryPoint common.Address
+	result     *ValidationResult
+	added      time.Time
+}
+
+// UserPool maintains the ERC-4337 user operations submitted to the node until
+// they are executed by a bundle, or expire. Operations are validated by simulating
+// them against the entry point, and can be submitted as bundles to the block
+// builder. They are not propagated to the network.
+type UserPool struct {
+	config   Config
+	chain    BlockChain
+	chainID  *big.Int
+	validate ValidateFunc
+	bundle   BundleFunc
+
+	entryPoints map[common.Address]bool
+	head        *types.Header
+	ops         map[common.Hash]*poolOp
+	senders     map[common.Address][]*poolOp // Operations of each sender, sorted by nonce
+	included    lru.BasicLRU[common.Hash, *Inclusion]
+	lock        sync.RWMutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a new user operation pool, tracking the chain head for included
+// operations.
+func New(config Config, chain BlockChain, validate ValidateFunc) *UserPool {
+	config = config.sanitize()
+
+	pool := &UserPool{
+		config:      config,
+		chain:       chain,
+		chainID:     chain.Config().ChainID,
+		validate:    validate,
+		entryPoints: make(map[common.Address]bool),
+		head:        chain.CurrentBlock(),
+		ops:         make(map[common.Hash]*poolOp),
+		senders:     make(map[common.Address][]*poolOp),
+		included:    lru.NewBasicLRU[common.Hash, *Inclusion](inclusionsLimit),
+		quit:        make(chan struct{}),
+	}
+	for _, addr := range config.EntryPoints {
+		pool.entryPoints[addr] = true
+	}
+	if config.Enabled() {
+		pool.wg.Add(1)
+		go pool.loop()
+	}
+	return pool
+}
+
+// SetBundleFunc sets the callback the pending operations are submitted through
+// as bundles after every head change.
+func (p *UserPool) SetBundleFunc(fn BundleFunc) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bundle = fn
+}
+
+// Close terminates the pool.
+func (p *UserPool) Close() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// EntryPoints returns the entry points the pool accepts operations for.
+func (p *UserPool) EntryPoints() []common.Address {
+	return p.config.EntryPoints
+}
+
+// Supports returns an error if the pool doesn't accept operations for the given
+// entry point.
+func (p *UserPool) Supports(entryPoint common.Address) error {
+	if !p.config.Enabled() {
+		return ErrDisabled
+	}
+	if !p.entryPoints[entryPoint] {
+		return fmt.Errorf("%w: %s", ErrUnsupportedEntryPoint, entryPoint)
+	}
+	return nil
+}
+
+// loop resets the pool on every head change until the pool is closed.
+func (p *UserPool) loop() {
+	defer p.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, 16)
+	sub := p.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-heads:
+			p.Reset(ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Add validates an operation for the given entry point and adds it to the pool,
+// replacing the pending one of the same sender and nonce if it pays enough more.
+// It returns the hash identifying the operation.
+func (p *UserPool) Add(op *UserOperation, entryPoint common.Address) (common.Hash, error) {
+	if err := p.Supports(entryPoint); err != nil {
+		return common.Hash{}, err
+	}
+	if err := p.validateFields(op); err != nil {
+		return common.Hash{}, err
+	}
+	hash := op.Hash(entryPoint, p.chainID)
+
+	p.lock.RLock()
+	head := p.head
+	err := p.checkAdmission(op, hash, entryPoint)
+	p.lock.RUnlock()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if head.BaseFee != nil && op.MaxFeePerGas.Cmp(head.BaseFee) < 0 {
+		return common.Hash{}, fmt.Errorf("%w: max fee per gas %v, base fee %v", txpool.ErrUnderpriced, op.MaxFeePerGas, head.BaseFee)
+	}
+	// Simulate the validation without holding the lock, it may take a while
+	result, err := p.validate(op, entryPoint, head)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if result.SigFailed {
+		return common.Hash{}, ErrInvalidSignature
+	}
+	if result.Expired(head.Time+1) || result.ValidAfter > head.Time+1 {
+		return common.Hash{}, fmt.Errorf("%w: valid between %d and %d", ErrExpired, result.ValidAfter, result.ValidUntil)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// The pool may have changed while validating, check again
+	if err := p.checkAdmission(op, hash, entryPoint); err != nil {
+		return common.Hash{}, err
+	}
+	p.insert(&poolOp{op: op, hash: hash, entryPoint: entryPoint, result: result, added: time.Now()})
+	log.Debug("Added user operation", "hash", hash, "sender", op.Sender, "nonce", op.Nonce, "entrypoint", entryPoint)
+	return hash, nil
+}
+
+// validateFields checks the operation fields are set and consistent, without
+// accessing the state.
+func (p *UserPool) validateFields(op *UserOperation) error {
+	if op.Nonce == nil || op.CallGasLimit == nil || op.VerificationGasLimit == nil ||
+		op.PreVerificationGas == nil || op.MaxFeePerGas == nil || op.MaxPriorityFeePerGas == nil {
+		return fmt.Errorf("%w: missing gas or nonce field", ErrInvalidFields)
+	}
+	for _, value := range []*big.Int{op.Nonce, op.CallGasLimit, op.VerificationGasLimit, op.PreVerificationGas, op.MaxFeePerGas, op.MaxPriorityFeePerGas} {
+		if value.Sign() < 0 || value.BitLen() > 256 {
+			return fmt.Errorf("%w: value out of range", ErrInvalidFields)
+		}
+	}
+	for _, gas := range []*big.Int{op.CallGasLimit, op.VerificationGasLimit, op.PreVerificationGas} {
+		if !gas.IsUint64() {
+			return fmt.Errorf("%w: gas value out of range", ErrInvalidFields)
+		}
+	}
+	if op.MaxPriorityFeePerGas.Cmp(op.MaxFeePerGas) > 0 {
+		return core.ErrTipAboveFeeCap
+	}
+	if l := len(op.InitCode); l > 0 && l < common.AddressLength {
+		return fmt.Errorf("%w: init code shorter than a factory address", ErrInvalidFields)
+	}
+	if l := len(op.PaymasterAndData); l > 0 && l < common.AddressLength {
+		return fmt.Errorf("%w: paymaster data shorter than a paymaster address", ErrInvalidFields)
+	}
+	if gas := op.VerificationGasLimit.Uint64(); gas > p.config.MaxVerificationGas {
+		return fmt.Errorf("%w: %d, maximum %d", ErrVerificationGas, gas, p.config.MaxVerificationGas)
+	}
+	if have, want := op.PreVerificationGas.Uint64(), PreVerificationGas(op); have < want {
+		return fmt.Errorf("%w: have %d, want %d", ErrPreVerificationGas, have, want)
+	}
+	return nil
+}
+
+// checkAdmission checks whether the operation fits into the pool, either as a
+// new one or as a replacement. The lock must be held.
+func (p *UserPool) checkAdmission(op *UserOperation, hash common.Hash, entryPoint common.Address) error {
+	if p.ops[hash] != nil {
+		return txpool.ErrAlreadyKnown
+	}
+	if old := p.replaced(op, entryPoint); old != nil {
+		// Both fees have to be bumped for the replacement to be accepted
+		for _, fees := range [][2]*big.Int{{old.op.MaxFeePerGas, op.MaxFeePerGas}, {old.op.MaxPriorityFeePerGas, op.MaxPriorityFeePerGas}} {
+			threshold := new(big.Int).Mul(fees[0], big.NewInt(100+int64(p.config.PriceBump)))
+			threshold.Div(threshold, big.NewInt(100))
+			if fees[1].Cmp(threshold) < 0 {
+				return txpool.ErrReplaceUnderpriced
+			}
+		}
+		return nil
+	}
+	if len(p.senders[op.Sender]) >= p.config.MaxOpsPerSender {
+		return fmt.Errorf("%w: %s", ErrSenderLimit, op.Sender)
+	}
+	if len(p.ops) >= p.config.MaxOps {
+		return ErrPoolFull
+	}
+	return nil
+}
+
+// replaced returns the pooled operation the given one replaces, if any. The
+// lock must be held.
+func (p *UserPool) replaced(op *UserOperation, entryPoint common.Address) *poolOp {
+	for _, old := range p.senders[op.Sender] {
+		if old.entryPoint == entryPoint && old.op.Nonce.Cmp(op.Nonce) == 0 {
+			return old
+		}
+	}
+	return nil
+}
+
+// insert adds an operation to the pool, dropping the one it replaces. The lock
+// must be held.
+func (p *UserPool) insert(pop *poolOp) {
+	if old := p.replaced(pop.op, pop.entryPoint); old != nil {
+		p.remove(old.hash)
+	}
+	p.ops[pop.hash] = pop
+
+	ops := append(p.senders[pop.op.Sender], pop)
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].op.Nonce.Cmp(ops[j].op.Nonce) < 0
+	})
+	p.senders[pop.op.Sender] = ops
+	opsGauge.Update(int64(len(p.ops)))
+}
+
+// remove drops an operation from the pool. The lock must be held.
+func (p *UserPool) remove(hash common.Hash) {
+	pop := p.ops[hash]
+	if pop == nil {
+		return
+	}
+	delete(p.ops, hash)
+
+	sender := pop.op.Sender
+	ops := p.senders[sender]
+	for i, other := range ops {
+		if other == pop {
+			ops = append(ops[:i], ops[i+1:]...)
+			break
+		}
+	}
+	if len(ops) == 0 {
+		delete(p.senders, sender)
+	} else {
+		p.senders[sender] = ops
+	}
+	opsGauge.Update(int64(len(p.ops)))
+}
+
+// Get returns a pooled operation and the entry point it targets, or nil if the
+// operation is not in the pool.
+func (p *UserPool) Get(hash common.Hash) (*UserOperation, common.Address) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	pop := p.ops[hash]
+	if pop == nil {
+		return nil, common.Address{}
+	}
+	return pop.op, pop.entryPoint
+}
+
+// Inclusion returns the execution of a recently included operation, or nil if it
+// is unknown.
+func (p *UserPool) Inclusion(hash common.Hash) *Inclusion {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	inclusion, _ := p.included.Get(hash)
+	return inclusion
+}
+
+// Stats returns the number of operations in the pool.
+func (p *UserPool) Stats() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return len(p.ops)
+}
+
+// Pending returns the operations of an entry point which may be bundled in a block
+// with the given base fee, at most one per sender, ordered by priority fee.
+func (p *UserPool) Pending(entryPoint common.Address, baseFee *big.Int) []*UserOperation {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.pending(entryPoint, baseFee)
+}
+
+// pending implements Pending. The lock must be held.
+func (p *UserPool) pending(entryPoint common.Address, baseFee *big.Int) []*UserOperation {
+	var ops []*UserOperation
+	for _, senderOps := range p.senders {
+		for _, pop := range senderOps {
+			if pop.entryPoint != entryPoint {
+				continue
+			}
+			// Operations of a sender may depend on each other, only bundle the first
+			if baseFee == nil || pop.op.MaxFeePerGas.Cmp(baseFee) >= 0 {
+				ops = append(ops, pop.op)
+			}
+			break
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if cmp := ops[i].MaxPriorityFeePerGas.Cmp(ops[j].MaxPriorityFeePerGas); cmp != 0 {
+			return cmp > 0
+		}
+		if cmp := ops[i].Sender.Cmp(ops[j].Sender); cmp != 0 {
+			return cmp < 0
+		}
+		return ops[i].Nonce.Cmp(ops[j].Nonce) < 0
+	})
+	return ops
+}
+
+// Reset moves the pool to a new head: operations executed in the blocks since the
+// previous head are recorded and removed, expired ones are dropped, and the
+// remaining ones are submitted for bundling if enabled.
+func (p *UserPool) Reset(head *types.Header) {
+	// Collect the blocks added since the previous head, bounded for deep reorgs
+	p.lock.RLock()
+	oldHash := p.head.Hash()
+	p.lock.RUnlock()
+
+	var blocks []*types.Block
+	for hash, number := head.Hash(), head.Number.Uint64(); hash != oldHash && len(blocks) < maxResetDepth; number-- {
+		block := p.chain.GetBlock(hash, number)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+		hash = block.ParentHash()
+	}
+	var inclusions []*Inclusion
+	for i := len(blocks) - 1; i >= 0; i-- {
+		for _, receipt := range p.chain.GetReceiptsByHash(blocks[i].Hash()) {
+			for _, event := range UnpackOperationEvents(receipt, p.entryPoints) {
+				inclusions = append(inclusions, &Inclusion{OperationEvent: event, Receipt: receipt})
+			}
+		}
+	}
+	p.lock.Lock()
+	p.head = head
+	for _, inclusion := range inclusions {
+		p.included.Add(inclusion.Hash, inclusion)
+		p.remove(inclusion.Hash)
+
+		// Operations of the same nonce can't be executed anymore
+		for _, pop := range p.senders[inclusion.Sender] {
+			if pop.entryPoint == inclusion.EntryPoint && pop.op.Nonce.Cmp(inclusion.Nonce) == 0 {
+				p.remove(pop.hash)
+				break
+			}
+		}
+	}
+	var dropped int
+	for hash, pop := range p.ops {
+		if time.Since(pop.added) > p.config.Lifetime || pop.result.Expired(head.Time+1) {
+			p.remove(hash)
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		log.Debug("Dropped expired user operations", "count", dropped, "head", head.Number)
+	}
+	var (
+		bundle  = p.bundle
+		bundles = make(map[common.Address][]*UserOperation)
+	)
+	if bundle != nil {
+		for _, entryPoint := range p.config.EntryPoints {
+			if ops := p.pending(entryPoint, head.BaseFee); len(ops) > 0 {
+				if len(ops) > p.config.MaxBundleOps {
+					ops = ops[:p.config.MaxBundleOps]
+				}
+				bundles[entryPoint] = ops
+			}
+		}
+	}
+	p.lock.Unlock()
+
+	if len(bundles) > 0 {
+		if err := bundle(bundles, head); err != nil {
+			log.Warn("Failed to submit user operation bundle", "entrypoints", len(bundles), "err", err)
+		}
+	}
+}
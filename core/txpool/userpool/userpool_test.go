@@ -0,0 +1,357 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package userpool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var testEntryPoint = common.HexToAddress("0x5ff137d4b0fdcd49dca30c7cf57e578a026d2789")
+
+// testBlockChain is a mock of the live chain for testing the pool.
+type testBlockChain struct {
+	head     *types.Header
+	blocks   map[common.Hash]*types.Block
+	receipts map[common.Hash]types.Receipts
+	feed     event.Feed
+}
+
+func newTestBlockChain() *testBlockChain {
+	return &testBlockChain{
+		head:     &types.Header{Number: big.NewInt(0), BaseFee: big.NewInt(params.InitialBaseFee)},
+		blocks:   make(map[common.Hash]*types.Block),
+		receipts: make(map[common.Hash]types.Receipts),
+	}
+}
+
+func (bc *testBlockChain) Config() *params.ChainConfig { return params.TestChainConfig }
+
+func (bc *testBlockChain) CurrentBlock() *types.Header { return bc.head }
+
+func (bc *testBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.blocks[hash]
+}
+
+func (bc *testBlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	return bc.receipts[hash]
+}
+
+func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return bc.feed.Subscribe(ch)
+}
+
+// addBlock adds a block on top of the head with the given receipts.
+func (bc *testBlockChain) addBlock(receipts types.Receipts) *types.Header {
+	header := &types.Header{
+		ParentHash: bc.head.Hash(),
+		Number:     new(big.Int).Add(bc.head.Number, common.Big1),
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Time:       bc.head.Time + 1,
+	}
+	block := types.NewBlockWithHeader(header)
+	bc.blocks[block.Hash()] = block
+	bc.receipts[block.Hash()] = receipts
+	bc.head = header
+	return header
+}
+
+// validResult is a validation simulation accepting all operations.
+func validResult(op *UserOperation, entryPoint common.Address, head *types.Header) (*ValidationResult, error) {
+	return &ValidationResult{PreOpGas: new(big.Int), Prefund: new(big.Int)}, nil
+}
+
+// makeOp creates an operation of the given sender and nonce paying the given tip.
+func makeOp(sender byte, nonce int64, tip int64) *UserOperation {
+	op := &UserOperation{
+		Sender:               common.Address{sender},
+		Nonce:                big.NewInt(nonce),
+		CallData:             []byte{0x01, 0x02},
+		CallGasLimit:         big.NewInt(100_000),
+		VerificationGasLimit: big.NewInt(100_000),
+		MaxFeePerGas:         big.NewInt(params.InitialBaseFee + tip),
+		MaxPriorityFeePerGas: big.NewInt(tip),
+		Signature:            make([]byte, 65),
+	}
+	op.PreVerificationGas = new(big.Int).SetUint64(PreVerificationGas(op))
+	return op
+}
+
+func newTestPool(chain *testBlockChain, validate ValidateFunc) *UserPool {
+	config := DefaultConfig
+	config.EntryPoints = []common.Address{testEntryPoint}
+	config.MaxOpsPerSender = 2
+	return New(config, chain, validate)
+}
+
+// Tests that operations are checked before and after their simulated validation,
+// and that pooled ones are only replaced by better paying ones.
+func TestAdd(t *testing.T) {
+	pool := newTestPool(newTestBlockChain(), validResult)
+	defer pool.Close()
+
+	// Malformed operations are rejected before simulating them
+	tipAboveFee := makeOp(1, 0, 1)
+	tipAboveFee.MaxPriorityFeePerGas = new(big.Int).Add(tipAboveFee.MaxFeePerGas, common.Big1)
+
+	lowPreVerification := makeOp(1, 0, 1)
+	lowPreVerification.PreVerificationGas.Sub(lowPreVerification.PreVerificationGas, common.Big1)
+
+	highVerification := makeOp(1, 0, 1)
+	highVerification.VerificationGasLimit = new(big.Int).SetUint64(DefaultConfig.MaxVerificationGas + 1)
+
+	underpriced := makeOp(1, 0, 1)
+	underpriced.MaxFeePerGas = big.NewInt(params.InitialBaseFee - 1)
+	underpriced.MaxPriorityFeePerGas = new(big.Int)
+
+	for i, tt := range []struct {
+		op         *UserOperation
+		entryPoint common.Address
+		err        error
+	}{
+		{makeOp(1, 0, 1), common.Address{0xff}, ErrUnsupportedEntryPoint},
+		{tipAboveFee, testEntryPoint, core.ErrTipAboveFeeCap},
+		{lowPreVerification, testEntryPoint, ErrPreVerificationGas},
+		{highVerification, testEntryPoint, ErrVerificationGas},
+		{underpriced, testEntryPoint, txpool.ErrUnderpriced},
+	} {
+		if _, err := pool.Add(tt.op, tt.entryPoint); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Valid operations are added, up to the sender limit
+	op := makeOp(1, 0, 1)
+	hash, err := pool.Add(op, testEntryPoint)
+	if err != nil {
+		t.Fatalf("failed to add operation: %v", err)
+	}
+	if hash != op.Hash(testEntryPoint, params.TestChainConfig.ChainID) {
+		t.Errorf("hash mismatch: have %x, want %x", hash, op.Hash(testEntryPoint, params.TestChainConfig.ChainID))
+	}
+	if have, _ := pool.Get(hash); have != op {
+		t.Errorf("added operation not retrievable")
+	}
+	if _, err := pool.Add(op, testEntryPoint); !errors.Is(err, txpool.ErrAlreadyKnown) {
+		t.Errorf("duplicate error mismatch: have %v, want %v", err, txpool.ErrAlreadyKnown)
+	}
+	if _, err := pool.Add(makeOp(1, 1, 1), testEntryPoint); err != nil {
+		t.Fatalf("failed to add second operation: %v", err)
+	}
+	if _, err := pool.Add(makeOp(1, 2, 1), testEntryPoint); !errors.Is(err, ErrSenderLimit) {
+		t.Errorf("sender limit error mismatch: have %v, want %v", err, ErrSenderLimit)
+	}
+	// Replacements have to bump both fees
+	if _, err := pool.Add(makeOp(1, 0, 1_000_000), testEntryPoint); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Errorf("replacement error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	replacement := makeOp(1, 0, 2)
+	replacement.MaxFeePerGas = new(big.Int).Mul(op.MaxFeePerGas, common.Big2)
+	if _, err := pool.Add(replacement, testEntryPoint); err != nil {
+		t.Fatalf("failed to replace operation: %v", err)
+	}
+	if have, _ := pool.Get(hash); have != nil {
+		t.Errorf("replaced operation still pooled")
+	}
+	if have := pool.Stats(); have != 2 {
+		t.Errorf("pooled operations mismatch: have %d, want %d", have, 2)
+	}
+}
+
+// Tests that the outcome of the validation simulation is enforced.
+func TestAddValidation(t *testing.T) {
+	errRejected := errors.New("rejected")
+
+	for i, tt := range []struct {
+		result *ValidationResult
+		err    error
+	}{
+		{nil, errRejected},
+		{&ValidationResult{SigFailed: true}, ErrInvalidSignature},
+		{&ValidationResult{ValidUntil: 1}, ErrExpired},
+		{&ValidationResult{ValidAfter: 100}, ErrExpired},
+		{&ValidationResult{ValidUntil: 100}, nil},
+	} {
+		validate := func(op *UserOperation, entryPoint common.Address, head *types.Header) (*ValidationResult, error) {
+			if tt.result == nil {
+				return nil, errRejected
+			}
+			return tt.result, nil
+		}
+		pool := newTestPool(newTestBlockChain(), validate)
+		if _, err := pool.Add(makeOp(1, 0, 1), testEntryPoint); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		pool.Close()
+	}
+	// Pools without entry points reject everything
+	pool := New(DefaultConfig, newTestBlockChain(), validResult)
+	defer pool.Close()
+
+	if _, err := pool.Add(makeOp(1, 0, 1), testEntryPoint); !errors.Is(err, ErrDisabled) {
+		t.Errorf("disabled pool error mismatch: have %v, want %v", err, ErrDisabled)
+	}
+}
+
+// Tests that pending operations are ordered by tip, with one per sender.
+func TestPending(t *testing.T) {
+	pool := newTestPool(newTestBlockChain(), validResult)
+	defer pool.Close()
+
+	for _, op := range []*UserOperation{makeOp(1, 0, 1), makeOp(1, 1, 5), makeOp(2, 0, 3), makeOp(3, 7, 2)} {
+		if _, err := pool.Add(op, testEntryPoint); err != nil {
+			t.Fatalf("failed to add operation: %v", err)
+		}
+	}
+	pending := pool.Pending(testEntryPoint, big.NewInt(params.InitialBaseFee))
+	if len(pending) != 3 {
+		t.Fatalf("pending operations mismatch: have %d, want %d", len(pending), 3)
+	}
+	for i, want := range []common.Address{{2}, {3}, {1}} {
+		if pending[i].Sender != want {
+			t.Errorf("pending operation %d: sender mismatch: have %x, want %x", i, pending[i].Sender, want)
+		}
+	}
+	if pending[2].Nonce.Sign() != 0 {
+		t.Errorf("pending operation of sender with multiple ones is not the first")
+	}
+	if pending := pool.Pending(testEntryPoint, big.NewInt(params.InitialBaseFee+3)); len(pending) != 1 {
+		t.Errorf("pending operations above base fee mismatch: have %d, want %d", len(pending), 1)
+	}
+}
+
+// makeReceipt creates the receipt of a bundle executing the given operations.
+func makeReceipt(t *testing.T, ops ...*UserOperation) *types.Receipt {
+	receipt := &types.Receipt{
+		Logs: []*types.Log{{Address: testEntryPoint, Topics: []common.Hash{BeforeExecutionTopic}}},
+	}
+	for _, op := range ops {
+		data, err := entryPoint.Events["UserOperationEvent"].Inputs.NonIndexed().Pack(op.Nonce, true, big.NewInt(1000), big.NewInt(100))
+		if err != nil {
+			t.Fatalf("failed to pack event: %v", err)
+		}
+		receipt.Logs = append(receipt.Logs,
+			&types.Log{Address: op.Sender, Data: []byte{0x01}},
+			&types.Log{
+				Address: testEntryPoint,
+				Topics: []common.Hash{
+					UserOperationEventTopic,
+					op.Hash(testEntryPoint, params.TestChainConfig.ChainID),
+					common.BytesToHash(op.Sender[:]),
+					{},
+				},
+				Data: data,
+			},
+		)
+	}
+	return receipt
+}
+
+// Tests that operations included in new blocks are removed and their execution
+// recorded, and that the remaining ones are submitted for bundling.
+func TestReset(t *testing.T) {
+	chain := newTestBlockChain()
+	pool := newTestPool(chain, validResult)
+	defer pool.Close()
+
+	var bundled map[common.Address][]*UserOperation
+	pool.SetBundleFunc(func(ops map[common.Address][]*UserOperation, head *types.Header) error {
+		bundled = ops
+		return nil
+	})
+	var (
+		included = makeOp(1, 0, 1)
+		obsolete = makeOp(2, 0, 1)
+		pending  = makeOp(3, 0, 1)
+	)
+	for _, op := range []*UserOperation{included, obsolete, pending} {
+		if _, err := pool.Add(op, testEntryPoint); err != nil {
+			t.Fatalf("failed to add operation: %v", err)
+		}
+	}
+	// Include the first operation and another one with the nonce of the second
+	replacement := makeOp(2, 0, 5)
+	chain.addBlock(nil)
+	pool.Reset(chain.addBlock(types.Receipts{makeReceipt(t, included, replacement)}))
+
+	if have := pool.Stats(); have != 1 {
+		t.Fatalf("pooled operations mismatch: have %d, want %d", have, 1)
+	}
+	if have, _ := pool.Get(pending.Hash(testEntryPoint, params.TestChainConfig.ChainID)); have != pending {
+		t.Errorf("pending operation dropped")
+	}
+	inclusion := pool.Inclusion(included.Hash(testEntryPoint, params.TestChainConfig.ChainID))
+	if inclusion == nil {
+		t.Fatalf("included operation not recorded")
+	}
+	if inclusion.Sender != included.Sender || !inclusion.Success || inclusion.ActualGasCost.Int64() != 1000 {
+		t.Errorf("inclusion mismatch: %+v", inclusion.OperationEvent)
+	}
+	if len(inclusion.Logs) != 1 || inclusion.Logs[0].Address != included.Sender {
+		t.Errorf("inclusion logs mismatch: have %v", inclusion.Logs)
+	}
+	if inclusion := pool.Inclusion(replacement.Hash(testEntryPoint, params.TestChainConfig.ChainID)); inclusion == nil || len(inclusion.Logs) != 1 {
+		t.Errorf("replacement inclusion mismatch: have %v", inclusion)
+	}
+	if len(bundled[testEntryPoint]) != 1 || bundled[testEntryPoint][0] != pending {
+		t.Errorf("bundled operations mismatch: have %v", bundled)
+	}
+}
+
+// Tests that the revert data of simulateValidation is decoded.
+func TestUnpackValidationResult(t *testing.T) {
+	pack := func(name string, args ...interface{}) []byte {
+		data, err := entryPoint.Errors[name].Inputs.Pack(args...)
+		if err != nil {
+			t.Fatalf("failed to pack %s: %v", name, err)
+		}
+		id := entryPoint.Errors[name].ID
+		return append(id[:4:4], data...)
+	}
+	stake := struct {
+		Stake           *big.Int
+		UnstakeDelaySec *big.Int
+	}{new(big.Int), new(big.Int)}
+
+	data := pack("ValidationResult", returnInfo{
+		PreOpGas:   big.NewInt(50_000),
+		Prefund:    big.NewInt(1),
+		SigFailed:  true,
+		ValidAfter: big.NewInt(10),
+		ValidUntil: big.NewInt(20),
+	}, stake, stake, stake)
+
+	result, err := UnpackValidationResult(data)
+	if err != nil {
+		t.Fatalf("failed to unpack result: %v", err)
+	}
+	if result.PreOpGas.Int64() != 50_000 || !result.SigFailed || result.ValidAfter != 10 || result.ValidUntil != 20 {
+		t.Errorf("result mismatch: %+v", result)
+	}
+	_, err = UnpackValidationResult(pack("FailedOp", big.NewInt(0), "AA21 didn't pay prefund"))
+	var failed *FailedOpError
+	if !errors.As(err, &failed) || failed.Reason != "AA21 didn't pay prefund" {
+		t.Errorf("failure mismatch: have %v", err)
+	}
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"bytes"
+	"compress/zlib"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
 func (tx *Transaction) MarkAsAnchor() error {
 	return tx.inner.markAsAnchor()
 }
@@ -40,3 +48,48 @@ func (tx *AccessListTx) markAsAnchor() error {
 func (tx *BlobTx) markAsAnchor() error {
 	return ErrInvalidTxType
 }
+
+// zlibWriterPool reuses the zlib writers of CompressTxList, which are expensive
+// to allocate.
+var zlibWriterPool = sync.Pool{
+	New: func() interface{} { return zlib.NewWriter(nil) },
+}
+
+// CompressTxList RLP encodes and zlib compresses a list of transactions, the way
+// L2 transaction lists are proposed.
+func CompressTxList(txs Transactions) ([]byte, error) {
+	b, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := zlibWriterPool.Get().(*zlib.Writer)
+	defer zlibWriterPool.Put(w)
+	w.Reset(&buf)
+
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CompressedSize returns the estimated number of bytes the transaction occupies
+// in a proposed L2 transaction list, which is the size of a compressed list with
+// the transaction alone. The estimate is cached, pools pricing transactions by it
+// should compute it on admission.
+func (tx *Transaction) CompressedSize() uint64 {
+	if size := tx.compressedSize.Load(); size != nil {
+		return size.(uint64)
+	}
+	var size uint64
+	if b, err := CompressTxList(Transactions{tx}); err == nil {
+		size = uint64(len(b))
+	} else {
+		size = tx.Size()
+	}
+	tx.compressedSize.Store(size)
+	return size
+}
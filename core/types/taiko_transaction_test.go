@@ -0,0 +1,43 @@
+package types
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that transaction lists compressed with reused writers decompress to their
+// encoding and don't depend on the previous use of the writer.
+func TestCompressTxList(t *testing.T) {
+	lists := []Transactions{{emptyTx, rightvrsTx}, {emptyTx}, {emptyTx, rightvrsTx}}
+
+	var results [][]byte
+	for i, txs := range lists {
+		b, err := CompressTxList(txs)
+		if err != nil {
+			t.Fatalf("list %d: failed to compress: %v", i, err)
+		}
+		r, err := zlib.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("list %d: failed to open compressed list: %v", i, err)
+		}
+		have, err := io.ReadAll(r)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatalf("list %d: failed to decompress: %v", i, err)
+		}
+		want, _ := rlp.EncodeToBytes(txs)
+		if !bytes.Equal(have, want) {
+			t.Fatalf("list %d: decompressed list mismatch: have %x, want %x", i, have, want)
+		}
+		results = append(results, b)
+	}
+	if !bytes.Equal(results[0], results[2]) {
+		t.Fatalf("compression depends on previous use: %x != %x", results[0], results[2])
+	}
+	if size := emptyTx.CompressedSize(); size != uint64(len(results[1])) {
+		t.Fatalf("compressed size mismatch: have %d, want %d", size, len(results[1]))
+	}
+}
@@ -60,6 +60,9 @@ type Transaction struct {
 	hash atomic.Value
 	size atomic.Value
 	from atomic.Value
+
+	// CHANGE(taiko): cache of the compressed size
+	compressedSize atomic.Value
 }
 
 // NewTx creates a new transaction.
@@ -291,11 +291,34 @@ func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.eth.txPool.Add([]*types.Transaction{signedTx}, true, false)[0]
 }
 
+// SendPrivateTx adds the transaction to the pool without propagating it to the
+// network. It is only handed out for block building and dropped if not included
+// within the configured number of blocks.
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	return b.eth.txPool.AddPrivate([]*types.Transaction{signedTx}, b.eth.config.PrivateTxLifetime)[0]
+}
+
+// PrivateTx returns the tracking information of a privately submitted transaction.
+func (b *EthAPIBackend) PrivateTx(hash common.Hash) *txpool.PrivateTx {
+	return b.eth.txPool.PrivateTx(hash)
+}
+
+func (b *EthAPIBackend) TxPoolStatus(hash common.Hash) (txpool.TxStatus, *txpool.TxEvent) {
+	// Private transactions are only reported by their dedicated status endpoint
+	if b.eth.txPool.PrivateTx(hash) != nil {
+		return txpool.TxStatusUnknown, nil
+	}
+	return b.eth.txPool.Status(hash), b.eth.txPool.TxEvent(hash)
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending := b.eth.txPool.Pending(txpool.PendingFilter{})
 	var txs types.Transactions
 	for _, batch := range pending {
 		for _, lazy := range batch {
+			if b.eth.txPool.IsPrivate(lazy.Hash) {
+				continue
+			}
 			if tx := lazy.Resolve(); tx != nil {
 				txs = append(txs, tx)
 			}
@@ -305,6 +328,9 @@ func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 }
 
 func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	if b.eth.txPool.IsPrivate(hash) {
+		return nil
+	}
 	return b.eth.txPool.Get(hash)
 }
 
@@ -329,8 +355,19 @@ func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	return true, tx, lookup.BlockHash, lookup.BlockIndex, lookup.Index, nil
 }
 
+// GetPoolNonce returns the next nonce of an account, counting its pending
+// transactions up to the first privately submitted one. Private transactions are
+// not revealed through the pending nonce, their senders have to track the nonces
+// of their follow-up transactions themselves.
 func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
-	return b.eth.txPool.Nonce(addr), nil
+	nonce := b.eth.txPool.Nonce(addr)
+	pending, _ := b.eth.txPool.ContentFrom(addr)
+	for _, tx := range pending {
+		if tx.Nonce() < nonce && b.eth.txPool.IsPrivate(tx.Hash()) {
+			nonce = tx.Nonce()
+		}
+	}
+	return nonce, nil
 }
 
 func (b *EthAPIBackend) Stats() (runnable int, blocked int) {
@@ -338,11 +375,35 @@ func (b *EthAPIBackend) Stats() (runnable int, blocked int) {
 }
 
 func (b *EthAPIBackend) TxPoolContent() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
-	return b.eth.txPool.Content()
+	pending, queued := b.eth.txPool.Content()
+	for addr, txs := range pending {
+		if pending[addr] = b.publicTxs(txs); len(pending[addr]) == 0 {
+			delete(pending, addr)
+		}
+	}
+	for addr, txs := range queued {
+		if queued[addr] = b.publicTxs(txs); len(queued[addr]) == 0 {
+			delete(queued, addr)
+		}
+	}
+	return pending, queued
 }
 
 func (b *EthAPIBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
-	return b.eth.txPool.ContentFrom(addr)
+	pending, queued := b.eth.txPool.ContentFrom(addr)
+	return b.publicTxs(pending), b.publicTxs(queued)
+}
+
+// publicTxs filters the privately submitted transactions out of a list, which
+// are only served to the block builder.
+func (b *EthAPIBackend) publicTxs(txs []*types.Transaction) []*types.Transaction {
+	public := make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if !b.eth.txPool.IsPrivate(tx.Hash()) {
+			public = append(public, tx)
+		}
+	}
+	return public
 }
 
 func (b *EthAPIBackend) TxPool() *txpool.TxPool {
@@ -350,7 +411,7 @@ func (b *EthAPIBackend) TxPool() *txpool.TxPool {
 }
 
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
-	return b.eth.txPool.SubscribeTransactions(ch, true)
+	return b.eth.txPool.SubscribePublicTransactions(ch, true)
 }
 
 func (b *EthAPIBackend) SyncProgress() ethereum.SyncProgress {
@@ -407,6 +468,14 @@ func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, sections
 }
 
+func (b *EthAPIBackend) LogIndexStatus() uint64 {
+	if b.eth.logIndexer == nil {
+		return 0
+	}
+	sections, _, _ := b.eth.logIndexer.Sections()
+	return sections * params.LogIndexBlocks
+}
+
 func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.eth.bloomRequests)
@@ -0,0 +1,86 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the pending nonce served to the public doesn't reveal privately
+// submitted transactions.
+func TestPoolNonceHidesPrivateTxs(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+
+	config := legacypool.DefaultConfig
+	config.Journal = ""
+	pool, err := txpool.New(config.PriceLimit, chain, []txpool.SubPool{legacypool.New(config, chain)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	backend := &EthAPIBackend{eth: &Ethereum{txPool: pool}}
+	makeTx := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(key, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+			Gas:       params.TxGas,
+			To:        &addr,
+		})
+	}
+	if errs := pool.Add([]*types.Transaction{makeTx(0)}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add public transaction: %v", errs[0])
+	}
+	if errs := pool.AddPrivate([]*types.Transaction{makeTx(1)}, 10); errs[0] != nil {
+		t.Fatalf("failed to add private transaction: %v", errs[0])
+	}
+	if err := pool.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if nonce := pool.Nonce(addr); nonce != 2 {
+		t.Fatalf("wrong pool nonce: have %d, want 2", nonce)
+	}
+	if nonce, _ := backend.GetPoolNonce(context.Background(), addr); nonce != 1 {
+		t.Fatalf("private transaction revealed by the pending nonce: have %d, want 1", nonce)
+	}
+}
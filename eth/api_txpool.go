@@ -0,0 +1,190 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TxPoolAdminAPI is the collection of transaction pool APIs accessing the local
+// file system or disclosing the full content of the pool. They are only served
+// over authenticated and local transports.
+type TxPoolAdminAPI struct {
+	eth *Ethereum
+}
+
+// NewTxPoolAdminAPI creates a new instance of TxPoolAdminAPI.
+func NewTxPoolAdminAPI(eth *Ethereum) *TxPoolAdminAPI {
+	return &TxPoolAdminAPI{eth: eth}
+}
+
+// ImportResult is the outcome of a transaction pool import.
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Dropped  int `json:"dropped"`
+}
+
+// Export writes the content of the pool into a local file in the snapshot format,
+// as JSON lines if its name ends with ".jsonl", or as an RLP stream otherwise,
+// returning the number of exported transactions. Privately submitted
+// transactions are not exported.
+func (api *TxPoolAdminAPI) Export(file string) (int, error) {
+	snap := api.Snapshot()
+	if err := txpool.ExportSnapshot(file, snap); err != nil {
+		return 0, err
+	}
+	return len(snap.Txs), nil
+}
+
+// Import adds the transactions of a file written by Export to the pool as remote
+// ones, validating them against the current head.
+func (api *TxPoolAdminAPI) Import(file string) (*ImportResult, error) {
+	snap, err := txpool.ImportSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*types.Transaction, len(snap.Txs))
+	for i, tx := range snap.Txs {
+		txs[i] = tx.Tx
+	}
+	result := new(ImportResult)
+	for _, err := range api.eth.TxPool().Add(txs, false, true) {
+		if err != nil {
+			result.Dropped++
+		} else {
+			result.Imported++
+		}
+	}
+	return result, nil
+}
+
+// Snapshot returns the content of the pool along with the metadata of every
+// transaction, in the format written by Export. Privately submitted transactions
+// are not included.
+func (api *TxPoolAdminAPI) Snapshot() *txpool.Snapshot {
+	return api.eth.TxPool().Snapshot(api.eth.BlockChain().CurrentBlock())
+}
+
+// ReloadPolicies reloads the transaction admission policies from their
+// configuration file without waiting for the modification to be detected.
+func (api *TxPoolAdminAPI) ReloadPolicies() error {
+	if api.eth.txPolicies == nil {
+		return errors.New("no txpool policy file configured")
+	}
+	return api.eth.txPolicies.Reload()
+}
+
+// TxPoolQueueAPI provides diagnostics of the transactions waiting in the pool for
+// promotion, and notifications about transactions entering and leaving it.
+type TxPoolQueueAPI struct {
+	eth *Ethereum
+}
+
+// NewTxPoolQueueAPI creates a new instance of TxPoolQueueAPI.
+func NewTxPoolQueueAPI(eth *Ethereum) *TxPoolQueueAPI {
+	return &TxPoolQueueAPI{eth: eth}
+}
+
+// QueueStatus returns the queued transactions of an account, or of all accounts
+// if none is given, along with the nonces missing to promote them, the time they
+// were first seen and the time they are evicted if not promoted until then.
+func (api *TxPoolQueueAPI) QueueStatus(addr *common.Address) []*txpool.QueuedAccount {
+	var (
+		pool  = api.eth.TxPool()
+		addrs []common.Address
+	)
+	if addr != nil {
+		addrs = []common.Address{*addr}
+	}
+	accounts := pool.Queued(addrs)
+	for _, account := range accounts {
+		// Privately submitted transactions are not to be disclosed
+		queued := account.Queued[:0]
+		for _, tx := range account.Queued {
+			if !pool.IsPrivate(tx.Hash) {
+				queued = append(queued, tx)
+			}
+		}
+		account.Queued = queued
+	}
+	return accounts
+}
+
+// TxEvent is a transaction lifecycle notification.
+type TxEvent struct {
+	Hash       common.Hash    `json:"hash"`
+	From       common.Address `json:"from"`
+	Nonce      hexutil.Uint64 `json:"nonce"`
+	Kind       string         `json:"kind"`
+	Reason     string         `json:"reason,omitempty"`
+	ReplacedBy *common.Hash   `json:"replacedBy,omitempty"`
+	Time       time.Time      `json:"time"`
+}
+
+// TxEvents creates a subscription notifying about transactions promoted from the
+// queue, dropped or replaced in the pool, or skipped by the block builder.
+func (api *TxPoolQueueAPI) TxEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			pool   = api.eth.TxPool()
+			events = make(chan txpool.TxEvent, 128)
+			sub    = pool.SubscribeTxEvents(events)
+		)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case event := <-events:
+				// Privately submitted transactions are not to be disclosed
+				if pool.PrivateTx(event.Hash) != nil {
+					continue
+				}
+				notification := &TxEvent{
+					Hash:   event.Hash,
+					From:   event.From,
+					Nonce:  hexutil.Uint64(event.Nonce),
+					Kind:   event.Kind.String(),
+					Reason: event.Reason,
+					Time:   event.Time,
+				}
+				if event.Kind == txpool.TxEventReplaced {
+					notification.ReplacedBy = &event.ReplacedBy
+				}
+				notifier.Notify(rpcSub.ID, notification)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
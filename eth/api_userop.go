@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool/userpool"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// userOpGasHeadroom is the percentage added to the estimated verification and
+// call gas of user operations, absorbing state changes until their inclusion.
+const userOpGasHeadroom = 10
+
+// UserOperationAPI provides the ERC-4337 bundler APIs over the user operation
+// pool.
+type UserOperationAPI struct {
+	eth *Ethereum
+}
+
+// NewUserOperationAPI creates a new instance of UserOperationAPI.
+func NewUserOperationAPI(eth *Ethereum) *UserOperationAPI {
+	return &UserOperationAPI{eth: eth}
+}
+
+// SupportedEntryPoints returns the entry points user operations are accepted for.
+func (api *UserOperationAPI) SupportedEntryPoints() []common.Address {
+	entryPoints := api.eth.UserPool().EntryPoints()
+	if entryPoints == nil {
+		return []common.Address{}
+	}
+	return entryPoints
+}
+
+// SendUserOperation validates a user operation by simulating it against the entry
+// point and adds it to the pool, returning its hash.
+func (api *UserOperationAPI) SendUserOperation(op userpool.UserOperation, entryPoint common.Address) (common.Hash, error) {
+	return api.eth.UserPool().Add(&op, entryPoint)
+}
+
+// UserOperationGasEstimate is the result of eth_estimateUserOperationGas.
+type UserOperationGasEstimate struct {
+	PreVerificationGas   hexutil.Uint64 `json:"preVerificationGas"`
+	VerificationGasLimit hexutil.Uint64 `json:"verificationGasLimit"`
+	CallGasLimit         hexutil.Uint64 `json:"callGasLimit"`
+}
+
+// EstimateUserOperationGas estimates the gas values of a user operation. The gas
+// and fee values of the operation are ignored, and its signature has to be a
+// placeholder the account's validation succeeds with.
+func (api *UserOperationAPI) EstimateUserOperationGas(op userpool.UserOperation, entryPoint common.Address) (*UserOperationGasEstimate, error) {
+	if err := api.eth.UserPool().Supports(entryPoint); err != nil {
+		return nil, err
+	}
+	// Simulate without fees, so that no prefund is required, and with the maximum
+	// verification gas the pool accepts
+	op.CallGasLimit = new(big.Int)
+	op.VerificationGasLimit = new(big.Int).SetUint64(api.eth.config.UserPool.MaxVerificationGas)
+	op.MaxFeePerGas, op.MaxPriorityFeePerGas = new(big.Int), new(big.Int)
+	op.PreVerificationGas = new(big.Int).SetUint64(userpool.PreVerificationGas(&op))
+
+	head := api.eth.blockchain.CurrentBlock()
+	statedb, err := api.eth.blockchain.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	// The opcode rules are not enforced, as placeholder signatures may take
+	// different code paths
+	simulation, err := api.eth.simulateUserOp(&op, entryPoint, head, statedb, nil)
+	if err != nil {
+		return nil, err
+	}
+	result, err := userpool.UnpackValidationResult(simulation.Revert())
+	if err != nil {
+		return nil, err
+	}
+	verification := result.PreOpGas.Uint64() - op.PreVerificationGas.Uint64()
+	call, err := api.eth.estimateUserOpCall(&op, entryPoint, head, api.eth.config.RPCGasCap)
+	if err != nil {
+		return nil, err
+	}
+	return &UserOperationGasEstimate{
+		PreVerificationGas:   hexutil.Uint64(op.PreVerificationGas.Uint64()),
+		VerificationGasLimit: hexutil.Uint64(verification * (100 + userOpGasHeadroom) / 100),
+		CallGasLimit:         hexutil.Uint64(call * (100 + userOpGasHeadroom) / 100),
+	}, nil
+}
+
+// UserOperationReceipt is the result of eth_getUserOperationReceipt.
+type UserOperationReceipt struct {
+	UserOpHash    common.Hash    `json:"userOpHash"`
+	EntryPoint    common.Address `json:"entryPoint"`
+	Sender        common.Address `json:"sender"`
+	Nonce         *hexutil.Big   `json:"nonce"`
+	Paymaster     common.Address `json:"paymaster"`
+	ActualGasCost *hexutil.Big   `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big   `json:"actualGasUsed"`
+	Success       bool           `json:"success"`
+	Logs          []*types.Log   `json:"logs"`
+	Receipt       *types.Receipt `json:"receipt"`
+}
+
+// GetUserOperationReceipt returns the execution of a recently included user
+// operation, or nil if it's unknown or not included in the canonical chain.
+func (api *UserOperationAPI) GetUserOperationReceipt(hash common.Hash) (*UserOperationReceipt, error) {
+	inclusion := api.eth.UserPool().Inclusion(hash)
+	if inclusion == nil {
+		return nil, nil
+	}
+	receipt := inclusion.Receipt
+	if api.eth.blockchain.GetCanonicalHash(receipt.BlockNumber.Uint64()) != receipt.BlockHash {
+		return nil, nil
+	}
+	logs := inclusion.Logs
+	if logs == nil {
+		logs = []*types.Log{}
+	}
+	return &UserOperationReceipt{
+		UserOpHash:    inclusion.Hash,
+		EntryPoint:    inclusion.EntryPoint,
+		Sender:        inclusion.Sender,
+		Nonce:         (*hexutil.Big)(inclusion.Nonce),
+		Paymaster:     inclusion.Paymaster,
+		ActualGasCost: (*hexutil.Big)(inclusion.ActualGasCost),
+		ActualGasUsed: (*hexutil.Big)(inclusion.ActualGasUsed),
+		Success:       inclusion.Success,
+		Logs:          logs,
+		Receipt:       receipt,
+	}, nil
+}
@@ -36,7 +36,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/txpool/userpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
@@ -68,7 +70,9 @@ type Ethereum struct {
 	config *ethconfig.Config
 
 	// Handlers
-	txPool *txpool.TxPool
+	txPool     *txpool.TxPool
+	txPolicies *txpool.PolicySet
+	userPool   *userpool.UserPool
 
 	blockchain         *core.BlockChain
 	handler            *handler
@@ -86,8 +90,10 @@ type Ethereum struct {
 	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
+	logIndexer        *core.ChainIndexer // Exact log indexer operating during block imports (nil if disabled)
 
-	APIBackend *EthAPIBackend
+	APIBackend    *EthAPIBackend
+	responseCache *ethapi.ResponseCache // Cache of finalized RPC results (nil if disabled)
 
 	miner     *miner.Miner
 	gasPrice  *big.Int
@@ -218,21 +224,48 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		return nil, err
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
-
-	if config.BlobPool.Datadir != "" {
-		config.BlobPool.Datadir = stack.ResolvePath(config.BlobPool.Datadir)
+	if config.LogIndex {
+		eth.logIndexer = core.NewLogIndexer(chainDb, params.LogIndexBlocks, params.LogIndexConfirms)
+		eth.logIndexer.Start(eth.blockchain)
 	}
-	blobPool := blobpool.New(config.BlobPool, eth.blockchain)
 
+	if config.TxPolicy != "" {
+		if eth.txPolicies, err = txpool.NewPolicySet(stack.ResolvePath(config.TxPolicy)); err != nil {
+			return nil, err
+		}
+		config.TxPool.Policies = eth.txPolicies
+		config.BlobPool.Policies = eth.txPolicies
+		config.BundlePool.Policies = eth.txPolicies
+	}
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Checkpoint != "" {
+		config.TxPool.Checkpoint = stack.ResolvePath(config.TxPool.Checkpoint)
+	}
 	legacyPool := legacypool.New(config.TxPool, eth.blockchain)
+	subpools := []txpool.SubPool{legacyPool}
 
-	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, []txpool.SubPool{legacyPool, blobPool})
+	// CHANGE(taiko): networks not including blob transactions don't pool them
+	if !eth.blockchain.Config().BlobTxsDisabled() {
+		if config.BlobPool.Datadir != "" {
+			config.BlobPool.Datadir = stack.ResolvePath(config.BlobPool.Datadir)
+		}
+		subpools = append(subpools, blobpool.New(config.BlobPool, eth.blockchain))
+	}
+	subpools = append(subpools, bundlepool.New(config.BundlePool, eth.blockchain))
+
+	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, subpools)
 	if err != nil {
 		return nil, err
 	}
+	// Private transactions must not resurface as public ones after a restart
+	legacyPool.SetCheckpointFilter(eth.txPool.IsPrivate)
+
+	eth.userPool = userpool.New(config.UserPool, eth.blockchain, eth.validateUserOp)
+	if config.UserPool.Enabled() && config.UserPool.Bundler != (common.Address{}) {
+		eth.userPool.SetBundleFunc(eth.bundleUserOps)
+	}
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
 	if eth.handler, err = newHandler(&handlerConfig{
@@ -261,6 +294,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		gpoParams.Default = config.Miner.GasPrice
 	}
 	eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, gpoParams)
+	if config.RPCResponseCache > 0 {
+		eth.responseCache = ethapi.NewResponseCache(eth.APIBackend, uint64(config.RPCResponseCache)*1024*1024)
+		stack.SetResponseCache(eth.responseCache)
+	}
 
 	// Setup DNS discovery iterators.
 	dnsclient := dnsdisc.NewClient(dnsdisc.Config{})
@@ -317,6 +354,9 @@ func (s *Ethereum) APIs() []rpc.API {
 		{
 			Namespace: "eth",
 			Service:   NewEthereumAPI(s),
+		}, {
+			Namespace: "eth",
+			Service:   NewUserOperationAPI(s),
 		}, {
 			Namespace: "miner",
 			Service:   NewMinerAPI(s),
@@ -326,6 +366,9 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "admin",
 			Service:   NewAdminAPI(s),
+		}, {
+			Namespace: "txpool",
+			Service:   NewTxPoolQueueAPI(s),
 		}, {
 			Namespace: "debug",
 			Service:   NewDebugAPI(s),
@@ -477,6 +520,7 @@ func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 func (s *Ethereum) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Ethereum) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Ethereum) TxPool() *txpool.TxPool             { return s.txPool }
+func (s *Ethereum) UserPool() *userpool.UserPool       { return s.userPool }
 func (s *Ethereum) EventMux() *event.TypeMux           { return s.eventMux }
 func (s *Ethereum) Engine() consensus.Engine           { return s.engine }
 func (s *Ethereum) ChainDb() ethdb.Database            { return s.chainDb }
@@ -486,6 +530,7 @@ func (s *Ethereum) Synced() bool                       { return s.handler.synced
 func (s *Ethereum) SetSynced()                         { s.handler.enableSyncedFeatures() }
 func (s *Ethereum) ArchiveMode() bool                  { return s.config.NoPruning }
 func (s *Ethereum) BloomIndexer() *core.ChainIndexer   { return s.bloomIndexer }
+func (s *Ethereum) LogIndexer() *core.ChainIndexer     { return s.logIndexer }
 func (s *Ethereum) Merger() *consensus.Merger          { return s.merger }
 func (s *Ethereum) SyncMode() downloader.SyncMode {
 	mode, _ := s.handler.chainSync.modeAndLocalHead()
@@ -536,9 +581,17 @@ func (s *Ethereum) Stop() error {
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
+	if s.logIndexer != nil {
+		s.logIndexer.Close()
+	}
 	close(s.closeBloomHandler)
+	s.userPool.Close()
 	s.txPool.Close()
+	s.txPolicies.Close()
 	s.miner.Close()
+	if s.responseCache != nil {
+		s.responseCache.Stop()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 
@@ -29,7 +29,9 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/taiko"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/txpool/userpool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -64,6 +66,9 @@ var Defaults = Config{
 	Miner:              miner.DefaultConfig,
 	TxPool:             legacypool.DefaultConfig,
 	BlobPool:           blobpool.DefaultConfig,
+	BundlePool:         bundlepool.DefaultConfig,
+	UserPool:           userpool.DefaultConfig,
+	PrivateTxLifetime:  64,
 	RPCGasCap:          50000000,
 	RPCEVMTimeout:      5 * time.Second,
 	GPO:                FullNodeGPO,
@@ -129,12 +134,28 @@ type Config struct {
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
 
+	// Enables the exact address/topic log index used for log filtering.
+	LogIndex bool `toml:",omitempty"`
+
 	// Mining options
 	Miner miner.Config
 
 	// Transaction pool options
-	TxPool   legacypool.Config
-	BlobPool blobpool.Config
+	TxPool     legacypool.Config
+	BlobPool   blobpool.Config
+	BundlePool bundlepool.Config
+
+	// UserPool configures the pool of ERC-4337 user operations, which is
+	// disabled unless entry points are configured.
+	UserPool userpool.Config
+
+	// PrivateTxLifetime is the number of blocks after which privately submitted
+	// transactions are dropped if not included.
+	PrivateTxLifetime uint64
+
+	// TxPolicy is the path of a JSON file configuring transaction admission
+	// policies. It is reloaded whenever modified. Policies are disabled if empty.
+	TxPolicy string
 
 	// Gas Price Oracle options
 	GPO gasprice.Config
@@ -155,6 +176,10 @@ type Config struct {
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// RPCResponseCache is the size in megabytes of the cache holding RPC results
+	// which only depend on finalized blocks, 0 if disabled.
+	RPCResponseCache int `toml:",omitempty"`
+
 	// OverrideCancun (TODO: remove after the fork)
 	OverrideCancun *uint64 `toml:",omitempty"`
 
@@ -55,6 +55,7 @@ type filter struct {
 	fullTx   bool
 	txs      []*types.Transaction
 	crit     FilterCriteria
+	ext      *ExtendedFilterCriteria // extended criteria of the log filter, if any
 	logs     []*types.Log
 	s        *Subscription // associated subscription in event system
 }
@@ -73,10 +74,14 @@ type FilterAPI struct {
 func NewFilterAPI(system *FilterSystem, lightMode bool) *FilterAPI {
 	api := &FilterAPI{
 		sys:     system,
-		events:  NewEventSystem(system, lightMode),
 		filters: make(map[rpc.ID]*filter),
 		timeout: system.cfg.Timeout,
 	}
+	if lightMode {
+		api.events = NewEventSystem(system, true)
+	} else {
+		api.events = system.EventSystem()
+	}
 	go api.timeoutLoop(system.cfg.Timeout)
 
 	return api
@@ -290,6 +295,122 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	return rpcSub, nil
 }
 
+// LogsFromCursor creates a subscription that fires for all new logs that match
+// the given filter criteria, each carrying a cursor of its position. If a cursor
+// is given, the logs the subscriber missed since are delivered first: logs of
+// blocks reorged out since are sent again with the removed flag set, followed by
+// the logs of the canonical chain after the cursor.
+func (api *FilterAPI) LogsFromCursor(ctx context.Context, crit FilterCriteria, cursor *LogCursor) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	matchedLogs := make(chan []*types.Log)
+
+	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+	// Queue up the live logs while the missed ones are being collected, so
+	// the event loop is not blocked and no log falls between the two.
+	var (
+		replay = &logReplay{replayed: make(map[common.Hash]struct{})}
+		done   = make(chan struct{})
+		queued = make(chan []*types.Log)
+	)
+	go func() {
+		var pending []*types.Log
+		for {
+			select {
+			case logs := <-matchedLogs:
+				pending = append(pending, logs...)
+			case <-done:
+				queued <- pending
+				return
+			}
+		}
+	}()
+	if cursor != nil {
+		replay, err = api.sys.replayLogs(ctx, ethereum.FilterQuery(crit), *cursor)
+	}
+	close(done)
+	pending := <-queued
+
+	if err != nil {
+		logsSub.Unsubscribe()
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer logsSub.Unsubscribe()
+
+		notify := func(logs []*types.Log) {
+			for _, log := range logs {
+				// Skip live logs of blocks already covered by the replay
+				if _, ok := replay.replayed[log.BlockHash]; ok && !log.Removed {
+					continue
+				}
+				notifier.Notify(rpcSub.ID, newCursorLog(log))
+			}
+		}
+		for _, log := range replay.logs {
+			notifier.Notify(rpcSub.ID, newCursorLog(log))
+		}
+		notify(pending)
+
+		for {
+			select {
+			case logs := <-matchedLogs:
+				notify(logs)
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				return
+			case <-notifier.Closed(): // connection dropped
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// ExtendedLogs creates a subscription that fires for all new logs that match
+// the given extended filter criteria, decoded with the criteria's ABI.
+func (api *FilterAPI) ExtendedLogs(ctx context.Context, crit ExtendedFilterCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	var (
+		rpcSub      = notifier.CreateSubscription()
+		matchedLogs = make(chan []*types.Log)
+	)
+
+	logsSub, err := api.events.SubscribeLogs(crit.query(), matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer logsSub.Unsubscribe()
+		for {
+			select {
+			case logs := <-matchedLogs:
+				for _, log := range crit.filter(logs) {
+					notifier.Notify(rpcSub.ID, log)
+				}
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				return
+			case <-notifier.Closed(): // connection dropped
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
@@ -306,14 +427,31 @@ type FilterCriteria ethereum.FilterQuery
 //
 // In case "fromBlock" > "toBlock" an error is returned.
 func (api *FilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
+	return api.newLogFilter(crit, nil)
+}
+
+// NewExtendedFilter creates a new log filter like NewFilter, additionally
+// evaluating the predicate of the extended criteria against the logs. The
+// changes of the filter are returned decoded with the criteria's ABI.
+func (api *FilterAPI) NewExtendedFilter(crit ExtendedFilterCriteria) (rpc.ID, error) {
+	return api.newLogFilter(crit.FilterCriteria, &crit)
+}
+
+// newLogFilter installs a log filter, optionally narrowed down by extended
+// criteria.
+func (api *FilterAPI) newLogFilter(crit FilterCriteria, ext *ExtendedFilterCriteria) (rpc.ID, error) {
+	query := ethereum.FilterQuery(crit)
+	if ext != nil {
+		query = ext.query()
+	}
 	logs := make(chan []*types.Log)
-	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), logs)
+	logsSub, err := api.events.SubscribeLogs(query, logs)
 	if err != nil {
 		return "", err
 	}
 
 	api.filtersMu.Lock()
-	api.filters[logsSub.ID] = &filter{typ: LogsSubscription, crit: crit, deadline: time.NewTimer(api.timeout), logs: make([]*types.Log, 0), s: logsSub}
+	api.filters[logsSub.ID] = &filter{typ: LogsSubscription, crit: FilterCriteria(query), ext: ext, deadline: time.NewTimer(api.timeout), logs: make([]*types.Log, 0), s: logsSub}
 	api.filtersMu.Unlock()
 
 	go func() {
@@ -322,7 +460,11 @@ func (api *FilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 			case l := <-logs:
 				api.filtersMu.Lock()
 				if f, found := api.filters[logsSub.ID]; found {
-					f.logs = append(f.logs, l...)
+					for _, log := range l {
+						if ext == nil || ext.match(log) {
+							f.logs = append(f.logs, log)
+						}
+					}
 				}
 				api.filtersMu.Unlock()
 			case <-logsSub.Err():
@@ -339,35 +481,118 @@ func (api *FilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 
 // GetLogs returns logs matching the given argument that are stored within the state.
 func (api *FilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+	filter, err := api.newLogsFilter(crit)
+	if err != nil {
+		return nil, err
+	}
+	// Run the filter and return all the logs
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return returnLogs(logs), err
+}
+
+// newLogsFilter validates the given criteria and creates the filter matching them.
+func (api *FilterAPI) newLogsFilter(crit FilterCriteria) (*Filter, error) {
 	if len(crit.Topics) > maxTopics {
 		return nil, errExceedMaxTopics
 	}
-	var filter *Filter
 	if crit.BlockHash != nil {
 		// Block filter requested, construct a single-shot filter
-		filter = api.sys.NewBlockFilter(*crit.BlockHash, crit.Addresses, crit.Topics)
-	} else {
-		// Convert the RPC block numbers into internal representations
-		begin := rpc.LatestBlockNumber.Int64()
-		if crit.FromBlock != nil {
-			begin = crit.FromBlock.Int64()
-		}
-		end := rpc.LatestBlockNumber.Int64()
-		if crit.ToBlock != nil {
-			end = crit.ToBlock.Int64()
-		}
-		if begin > 0 && end > 0 && begin > end {
-			return nil, errInvalidBlockRange
+		return api.sys.NewBlockFilter(*crit.BlockHash, crit.Addresses, crit.Topics), nil
+	}
+	// Convert the RPC block numbers into internal representations
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	if begin > 0 && end > 0 && begin > end {
+		return nil, errInvalidBlockRange
+	}
+	// Construct the range filter
+	return api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics), nil
+}
+
+// streamLogs returns a stream encoding the logs found by the filter as an array.
+// If extended criteria are set, logs not matching them are skipped and the rest
+// are decoded with the criteria's ABI.
+func streamLogs(filter *Filter, ext *ExtendedFilterCriteria) rpc.StreamFunc {
+	return func(s *rpc.JSONStream) error {
+		s.BeginArray()
+		err := filter.forEach(s.Context(), func(log *types.Log) error {
+			if ext == nil {
+				return s.Value(log)
+			}
+			if !ext.match(log) {
+				return nil
+			}
+			return s.Value(ext.decode(log))
+		})
+		if err != nil {
+			return err
 		}
-		// Construct the range filter
-		filter = api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
+		return s.EndArray()
+	}
+}
+
+// StreamingFilterAPI serves a FilterAPI over RPC, streaming the results of the log
+// queries to the client while they're retrieved, instead of collecting them in
+// memory first. All other methods are those of the FilterAPI.
+type StreamingFilterAPI struct {
+	*FilterAPI
+}
+
+// NewStreamingFilterAPI wraps the filter API to stream the results of log queries.
+func NewStreamingFilterAPI(api *FilterAPI) *StreamingFilterAPI {
+	return &StreamingFilterAPI{api}
+}
+
+// GetLogs returns logs matching the given argument that are stored within the state.
+// The logs are streamed to the client while they're retrieved.
+func (api *StreamingFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) (rpc.StreamFunc, error) {
+	filter, err := api.newLogsFilter(crit)
+	if err != nil {
+		return nil, err
+	}
+	// Resolve the block range upfront to fail without a partial result
+	if err := filter.resolve(ctx); err != nil {
+		return nil, err
+	}
+	return streamLogs(filter, nil), nil
+}
+
+// GetFilterLogs returns the logs for the filter with the given id.
+// If the filter could not be found an empty array of logs is returned.
+// Logs of extended filters are decoded, the same as in GetFilterChanges.
+// The logs are streamed to the client while they're retrieved.
+func (api *StreamingFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) (rpc.StreamFunc, error) {
+	filter, ext, err := api.newFilterLogsFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := filter.resolve(ctx); err != nil {
+		return nil, err
+	}
+	return streamLogs(filter, ext), nil
+}
+
+// GetExtendedLogs returns the stored logs matching the given extended criteria,
+// decoded with the criteria's ABI.
+func (api *FilterAPI) GetExtendedLogs(ctx context.Context, crit ExtendedFilterCriteria) ([]*DecodedLog, error) {
+	filter, err := api.newLogsFilter(FilterCriteria(crit.query()))
+	if err != nil {
+		return nil, err
 	}
-	// Run the filter and return all the logs
 	logs, err := filter.Logs(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return returnLogs(logs), err
+	return crit.filter(returnLogs(logs)), nil
 }
 
 // UninstallFilter removes the filter with the given filter id.
@@ -387,13 +612,34 @@ func (api *FilterAPI) UninstallFilter(id rpc.ID) bool {
 
 // GetFilterLogs returns the logs for the filter with the given id.
 // If the filter could not be found an empty array of logs is returned.
-func (api *FilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Log, error) {
+//
+// For log filters the result is []Log, extended log filters return their logs
+// decoded as []DecodedLog, the same as GetFilterChanges.
+func (api *FilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) (interface{}, error) {
+	filter, ext, err := api.newFilterLogsFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	// Run the filter and return all the logs
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ext != nil {
+		return ext.filter(returnLogs(logs)), nil
+	}
+	return returnLogs(logs), nil
+}
+
+// newFilterLogsFilter creates the filter retrieving the stored logs of the filter
+// with the given id, returning its extended criteria too, if any.
+func (api *FilterAPI) newFilterLogsFilter(id rpc.ID) (*Filter, *ExtendedFilterCriteria, error) {
 	api.filtersMu.Lock()
 	f, found := api.filters[id]
 	api.filtersMu.Unlock()
 
 	if !found || f.typ != LogsSubscription {
-		return nil, errFilterNotFound
+		return nil, nil, errFilterNotFound
 	}
 
 	var filter *Filter
@@ -413,12 +659,7 @@ func (api *FilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Lo
 		// Construct the range filter
 		filter = api.sys.NewRangeFilter(begin, end, f.crit.Addresses, f.crit.Topics)
 	}
-	// Run the filter and return all the logs
-	logs, err := filter.Logs(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return returnLogs(logs), nil
+	return filter, f.ext, nil
 }
 
 // GetFilterChanges returns the logs for the filter with the given id since
@@ -465,6 +706,13 @@ func (api *FilterAPI) GetFilterChanges(id rpc.ID) (interface{}, error) {
 		case LogsSubscription, MinedAndPendingLogsSubscription:
 			logs := f.logs
 			f.logs = nil
+			if f.ext != nil {
+				decoded := make([]*DecodedLog, 0, len(logs))
+				for _, log := range logs {
+					decoded = append(decoded, f.ext.decode(log))
+				}
+				return decoded, nil
+			}
 			return returnLogs(logs), nil
 		}
 	}
@@ -0,0 +1,168 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errUnknownCursor = errors.New("unknown log cursor")
+	errCursorTooOld  = errors.New("log cursor too old")
+)
+
+// The maximum number of blocks replayed or rolled back when resuming a log
+// subscription from a cursor.
+const maxCursorReplayBlocks = 1024
+
+// LogCursor identifies the position of a delivered log within the chain. A log
+// subscription can be resumed from the cursor of the last log received.
+type LogCursor struct {
+	BlockHash common.Hash  `json:"blockHash"`
+	LogIndex  hexutil.Uint `json:"logIndex"`
+}
+
+// CursorLog is a log delivered by a cursor based subscription.
+type CursorLog struct {
+	*types.Log
+	Cursor LogCursor
+}
+
+// newCursorLog wraps a log together with its cursor.
+func newCursorLog(log *types.Log) *CursorLog {
+	return &CursorLog{
+		Log:    log,
+		Cursor: LogCursor{BlockHash: log.BlockHash, LogIndex: hexutil.Uint(log.Index)},
+	}
+}
+
+// MarshalJSON marshals the log as usual, extended with its cursor.
+func (l *CursorLog) MarshalJSON() ([]byte, error) {
+	enc, err := json.Marshal(l.Log)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := json.Marshal(struct {
+		Cursor LogCursor `json:"cursor"`
+	}{l.Cursor})
+	if err != nil {
+		return nil, err
+	}
+	return spliceJSON(enc, extra), nil
+}
+
+// logReplay is the outcome of resuming a log subscription from a cursor.
+type logReplay struct {
+	logs     []*types.Log             // removed and replayed logs, in delivery order
+	replayed map[common.Hash]struct{} // canonical blocks whose logs were replayed
+}
+
+// replayLogs collects the logs matching the query that a subscriber positioned
+// at the given cursor missed. If the cursor's block was reorged out since, the
+// logs of the abandoned chain segment up to the cursor are returned as removed
+// first, after which the logs of the canonical chain are replayed up to the
+// current head.
+//
+// Removals are delivered at least once: resuming from the cursor of a removed
+// log may report logs as removed again.
+func (sys *FilterSystem) replayLogs(ctx context.Context, crit ethereum.FilterQuery, cursor LogCursor) (*logReplay, error) {
+	var (
+		backend = sys.backend
+		filter  = sys.NewBlockFilter(cursor.BlockHash, crit.Addresses, crit.Topics)
+		head    = backend.CurrentHeader()
+		res     = &logReplay{replayed: make(map[common.Hash]struct{})}
+	)
+	header, err := backend.HeaderByHash(ctx, cursor.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("%w: block %x not found", errUnknownCursor, cursor.BlockHash)
+	}
+	// Roll back the side chain the cursor is on until reaching the canonical chain
+	ancestor := header
+	for {
+		canon, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(ancestor.Number.Int64()))
+		if err != nil {
+			return nil, err
+		}
+		if canon != nil && canon.Hash() == ancestor.Hash() {
+			break
+		}
+		if header.Number.Uint64()-ancestor.Number.Uint64() >= maxCursorReplayBlocks {
+			return nil, errCursorTooOld
+		}
+		logs, err := filter.blockLogs(ctx, ancestor)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(logs) - 1; i >= 0; i-- {
+			if ancestor == header && logs[i].Index > uint(cursor.LogIndex) {
+				continue // never delivered
+			}
+			removed := *logs[i]
+			removed.Removed = true
+			res.logs = append(res.logs, &removed)
+		}
+		if ancestor, err = backend.HeaderByHash(ctx, ancestor.ParentHash); err != nil {
+			return nil, err
+		}
+		if ancestor == nil {
+			return nil, fmt.Errorf("%w: ancestor of block %x not found", errUnknownCursor, cursor.BlockHash)
+		}
+	}
+	if head.Number.Uint64() < ancestor.Number.Uint64() || head.Number.Uint64()-ancestor.Number.Uint64() > maxCursorReplayBlocks {
+		return nil, errCursorTooOld
+	}
+	// Replay the canonical chain from the cursor or the fork point onwards. The
+	// logs of the fork point itself were all delivered before the cursor's.
+	number := ancestor.Number.Uint64()
+	if ancestor != header {
+		number++
+	}
+	for ; number <= head.Number.Uint64(); number++ {
+		canon, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if canon == nil {
+			break
+		}
+		logs, err := filter.blockLogs(ctx, canon)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			if canon.Hash() == cursor.BlockHash && log.Index <= uint(cursor.LogIndex) {
+				continue // already delivered
+			}
+			res.logs = append(res.logs, log)
+		}
+		res.replayed[canon.Hash()] = struct{}{}
+	}
+	res.logs = filterLogs(res.logs, crit.FromBlock, crit.ToBlock, nil, nil)
+	return res, nil
+}
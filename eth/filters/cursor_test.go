@@ -0,0 +1,162 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// Tests that logs missed since a cursor are replayed, and that logs of blocks
+// reorged out since are reported as removed.
+func TestReplayLogsFromCursor(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		_, sys = newTestFilterSystem(t, db, Config{})
+		addr   = common.Address{0xaa}
+		gspec  = &core.Genesis{
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+	)
+	// Every block carries two logs tagging the chain and the block number
+	generate := func(side bool) ([]*types.Block, []types.Receipts) {
+		_, blocks, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, func(i int, gen *core.BlockGen) {
+			tag := byte(0)
+			if side && i >= 5 {
+				tag = 0x80
+			}
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{
+				{Address: addr, Data: []byte{tag, byte(i + 1), 0}},
+				{Address: addr, Data: []byte{tag, byte(i + 1), 1}},
+			}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		})
+		return blocks, receipts
+	}
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+
+	chain, receipts := generate(false)
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	side, sideReceipts := generate(true)
+	if side[4].Hash() != chain[4].Hash() || side[5].Hash() == chain[5].Hash() {
+		t.Fatal("side chain does not fork off at block 6")
+	}
+	for i, block := range side[5:8] {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), sideReceipts[5+i])
+	}
+	// tag returns the chain tag, block number, log number and removal flag of a log
+	tag := func(log *types.Log) [4]int {
+		removed := 0
+		if log.Removed {
+			removed = 1
+		}
+		return [4]int{int(log.Data[0]), int(log.Data[1]), int(log.Data[2]), removed}
+	}
+	canonical := func(from, to int) (tags [][4]int) {
+		for n := from; n <= to; n++ {
+			tags = append(tags, [4]int{0, n, 0, 0}, [4]int{0, n, 1, 0})
+		}
+		return tags
+	}
+	for i, tc := range []struct {
+		crit   ethereum.FilterQuery
+		cursor LogCursor
+		want   [][4]int
+	}{
+		// Cursor on the canonical chain replays the rest of its block onwards
+		{
+			cursor: LogCursor{BlockHash: chain[2].Hash(), LogIndex: 0},
+			want:   append([][4]int{{0, 3, 1, 0}}, canonical(4, 10)...),
+		},
+		// Cursor at the head has nothing to replay
+		{
+			cursor: LogCursor{BlockHash: chain[9].Hash(), LogIndex: 1},
+		},
+		// Cursor on a reorged out block removes the delivered logs in reverse
+		{
+			cursor: LogCursor{BlockHash: side[6].Hash(), LogIndex: 0},
+			want:   append([][4]int{{0x80, 7, 0, 1}, {0x80, 6, 1, 1}, {0x80, 6, 0, 1}}, canonical(6, 10)...),
+		},
+		// Block range of the criteria is respected
+		{
+			crit:   ethereum.FilterQuery{ToBlock: big.NewInt(8)},
+			cursor: LogCursor{BlockHash: side[5].Hash(), LogIndex: 1},
+			want:   append([][4]int{{0x80, 6, 1, 1}, {0x80, 6, 0, 1}}, canonical(6, 8)...),
+		},
+		// Address of the criteria is respected
+		{
+			crit:   ethereum.FilterQuery{Addresses: []common.Address{{0xbb}}},
+			cursor: LogCursor{BlockHash: side[7].Hash(), LogIndex: 1},
+		},
+	} {
+		replay, err := sys.replayLogs(context.Background(), tc.crit, tc.cursor)
+		if err != nil {
+			t.Fatalf("test %d: failed to replay logs: %v", i, err)
+		}
+		var have [][4]int
+		for _, log := range replay.logs {
+			have = append(have, tag(log))
+		}
+		if !reflect.DeepEqual(have, tc.want) {
+			t.Errorf("test %d: replayed logs mismatch: have %v, want %v", i, have, tc.want)
+		}
+	}
+	if _, err := sys.replayLogs(context.Background(), ethereum.FilterQuery{}, LogCursor{BlockHash: common.Hash{0x01}}); !errors.Is(err, errUnknownCursor) {
+		t.Fatalf("expected unknown cursor error, got %v", err)
+	}
+}
+
+func TestCursorLogJSON(t *testing.T) {
+	log := &types.Log{Address: common.Address{0xaa}, BlockHash: common.Hash{0x01}, Index: 3, Topics: []common.Hash{}}
+	enc, err := json.Marshal(newCursorLog(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec struct {
+		Address common.Address `json:"address"`
+		Cursor  LogCursor      `json:"cursor"`
+	}
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", enc, err)
+	}
+	if dec.Address != log.Address || dec.Cursor != (LogCursor{BlockHash: common.Hash{0x01}, LogIndex: 3}) {
+		t.Fatalf("unexpected cursor log %s", enc)
+	}
+}
@@ -20,13 +20,20 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// logIndexBatchSize is the number of blocks looked up in the log index at once,
+// bounding the memory used by filters matching popular addresses or topics.
+const logIndexBatchSize = 4096
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	sys *FilterSystem
@@ -37,6 +44,11 @@ type Filter struct {
 	block      *common.Hash // Block hash if filtering a single block
 	begin, end int64        // Range interval if filtering multiple blocks
 
+	resolved    bool          // Whether the block or range was resolved
+	header      *types.Header // Header of the block if filtering a single block
+	pending     bool          // Whether pending logs are included
+	pendingOnly bool          // Whether only pending logs are included
+
 	matcher *bloombits.Matcher
 }
 
@@ -95,16 +107,82 @@ func newFilter(sys *FilterSystem, addresses []common.Address, topics [][]common.
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	var logs []*types.Log
+	err := f.forEach(ctx, func(log *types.Log) error {
+		logs = append(logs, log)
+		return nil
+	})
+	// if an error occurs during extraction, we do return the extracted data
+	return logs, err
+}
+
+// forEach searches the blockchain for matching log entries like Logs, passing
+// them to fn as they're found instead of collecting them. The search stops at
+// the first error returned by fn.
+func (f *Filter) forEach(ctx context.Context, fn func(*types.Log) error) error {
+	if err := f.resolve(ctx); err != nil {
+		return err
+	}
 	// If we're doing singleton block filtering, execute and return
+	if f.header != nil {
+		logs, err := f.blockLogs(ctx, f.header)
+		if err != nil {
+			return err
+		}
+		return eachLog(logs, fn)
+	}
+	// Short-cut if all we care about is pending logs
+	if f.pendingOnly {
+		return eachLog(f.pendingLogs(), fn)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logChan, errChan := f.rangeLogsAsync(ctx)
+	for {
+		select {
+		case log := <-logChan:
+			if err := fn(log); err != nil {
+				// Stop the search and drain the channels until it exits
+				cancel()
+				go func() {
+					for range errChan {
+					}
+				}()
+				for range logChan {
+				}
+				return err
+			}
+		case err := <-errChan:
+			if err != nil {
+				return err
+			}
+			// Append the pending ones
+			if f.pending {
+				return eachLog(f.pendingLogs(), fn)
+			}
+			return nil
+		}
+	}
+}
+
+// resolve looks up the block of a single block filter, or resolves the special
+// block numbers of a range filter. It's a no-op once the filter was resolved.
+func (f *Filter) resolve(ctx context.Context) error {
+	if f.resolved {
+		return nil
+	}
+	// If we're doing singleton block filtering, look up the block
 	if f.block != nil {
 		header, err := f.sys.backend.HeaderByHash(ctx, *f.block)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if header == nil {
-			return nil, errors.New("unknown block")
+			return errors.New("unknown block")
 		}
-		return f.blockLogs(ctx, header)
+		f.header, f.resolved = header, true
+		return nil
 	}
 
 	var (
@@ -114,12 +192,15 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 
 	// special case for pending logs
 	if beginPending && !endPending {
-		return nil, errInvalidBlockRange
+		return errInvalidBlockRange
 	}
 
+	f.pending = endPending
+
 	// Short-cut if all we care about is pending logs
 	if beginPending && endPending {
-		return f.pendingLogs(), nil
+		f.pendingOnly, f.resolved = true, true
+		return nil
 	}
 
 	resolveSpecial := func(number int64) (int64, error) {
@@ -151,31 +232,23 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	var err error
 	// range query need to resolve the special begin/end block number
 	if f.begin, err = resolveSpecial(f.begin); err != nil {
-		return nil, err
+		return err
 	}
 	if f.end, err = resolveSpecial(f.end); err != nil {
-		return nil, err
+		return err
 	}
+	f.resolved = true
+	return nil
+}
 
-	logChan, errChan := f.rangeLogsAsync(ctx)
-	var logs []*types.Log
-	for {
-		select {
-		case log := <-logChan:
-			logs = append(logs, log)
-		case err := <-errChan:
-			if err != nil {
-				// if an error occurs during extraction, we do return the extracted data
-				return logs, err
-			}
-			// Append the pending ones
-			if endPending {
-				pendingLogs := f.pendingLogs()
-				logs = append(logs, pendingLogs...)
-			}
-			return logs, nil
+// eachLog passes the given logs to fn, stopping at the first error.
+func eachLog(logs []*types.Log, fn func(*types.Log) error) error {
+	for _, log := range logs {
+		if err := fn(log); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
 // rangeLogsAsync retrieves block-range logs that match the filter criteria asynchronously,
@@ -196,9 +269,20 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 		var (
 			end            = uint64(f.end)
 			size, sections = f.sys.backend.BloomStatus()
+			logIndexed     = f.sys.backend.LogIndexStatus()
 			err            error
 		)
-		if indexed := sections * size; indexed > uint64(f.begin) {
+		// Prefer the exact log index over the bloombits if it's available. Filters
+		// without any criteria match every block, for those the index is useless.
+		if logIndexed > uint64(f.begin) && f.hasCriteria() {
+			if logIndexed > end {
+				logIndexed = end + 1
+			}
+			if err = f.logIndexedLogs(ctx, logIndexed-1, logChan); err != nil {
+				errChan <- err
+				return
+			}
+		} else if indexed := sections * size; indexed > uint64(f.begin) {
 			if indexed > end {
 				indexed = end + 1
 			}
@@ -265,6 +349,123 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *type
 	}
 }
 
+// logIndexedLogs returns the logs matching the filter criteria based on the exact
+// address and topic log index.
+func (f *Filter) logIndexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
+	db := f.sys.backend.ChainDb()
+	for f.begin <= int64(end) {
+		from, to := uint64(f.begin), uint64(f.begin)+logIndexBatchSize-1
+		if to > end {
+			to = end
+		}
+		numbers, err := f.logIndexMatches(db, from, to)
+		if err != nil {
+			return err
+		}
+		for _, number := range numbers {
+			// Retrieve the matching block and pull the logs out of it
+			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return err
+			}
+			for _, log := range found {
+				select {
+				case logChan <- log:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		f.begin = int64(to) + 1
+	}
+	return nil
+}
+
+// logPosition identifies a single log within the chain.
+type logPosition struct {
+	number uint64 // Number of the block containing the log
+	index  uint   // Block-wide index of the log
+}
+
+// logIndexMatches looks up the log index for the block range [from, to] and
+// returns the numbers of the blocks containing at least one log matching all
+// the filter criteria, in ascending order.
+func (f *Filter) logIndexMatches(db ethdb.Database, from, to uint64) ([]uint64, error) {
+	var (
+		matches   map[logPosition]struct{} // nil until the first clause is applied
+		canonical = make(map[uint64]common.Hash)
+	)
+	// intersect narrows the matches down to the union of the given entries,
+	// skipping any stale entries left behind by reorged blocks.
+	intersect := func(entries []*rawdb.LogIndexEntry) {
+		union := make(map[logPosition]struct{})
+		for _, entry := range entries {
+			hash, ok := canonical[entry.Number]
+			if !ok {
+				hash = rawdb.ReadCanonicalHash(db, entry.Number)
+				canonical[entry.Number] = hash
+			}
+			if entry.Hash != hash {
+				continue
+			}
+			for _, index := range entry.Logs {
+				pos := logPosition{number: entry.Number, index: index}
+				if matches != nil {
+					if _, ok := matches[pos]; !ok {
+						continue
+					}
+				}
+				union[pos] = struct{}{}
+			}
+		}
+		matches = union
+	}
+	if len(f.addresses) > 0 {
+		var entries []*rawdb.LogIndexEntry
+		for _, address := range f.addresses {
+			found, err := rawdb.ReadLogIndexByAddress(db, address, from, to)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, found...)
+		}
+		intersect(entries)
+	}
+	for i, sub := range f.topics {
+		if len(sub) == 0 {
+			continue // empty rule set == wildcard
+		}
+		if matches != nil && len(matches) == 0 {
+			break // no need to look up further topics
+		}
+		var entries []*rawdb.LogIndexEntry
+		for _, topic := range sub {
+			found, err := rawdb.ReadLogIndexByTopic(db, uint8(i), topic, from, to)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, found...)
+		}
+		intersect(entries)
+	}
+	var (
+		seen    = make(map[uint64]struct{})
+		numbers []uint64
+	)
+	for pos := range matches {
+		if _, ok := seen[pos.number]; !ok {
+			seen[pos.number] = struct{}{}
+			numbers = append(numbers, pos.number)
+		}
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers, nil
+}
+
 // unindexedLogs returns the logs matching the filter criteria based on raw block
 // iteration and bloom matching.
 func (f *Filter) unindexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
@@ -331,6 +532,20 @@ func (f *Filter) checkMatches(ctx context.Context, header *types.Header) ([]*typ
 	return logs, nil
 }
 
+// hasCriteria returns whether the filter restricts the addresses or topics of
+// the logs it matches, or if it matches every log.
+func (f *Filter) hasCriteria() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, sub := range f.topics {
+		if len(sub) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // pendingLogs returns the logs matching the filter criteria within the pending block.
 func (f *Filter) pendingLogs() []*types.Log {
 	block, receipts := f.sys.backend.PendingBlockAndReceipts()
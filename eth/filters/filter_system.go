@@ -73,6 +73,7 @@ type Backend interface {
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
 
 	BloomStatus() (uint64, uint64)
+	LogIndexStatus() uint64
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
@@ -81,6 +82,9 @@ type FilterSystem struct {
 	backend   Backend
 	logsCache *lru.Cache[common.Hash, *logCacheElem]
 	cfg       *Config
+
+	events     *EventSystem // Event system shared by the filter APIs, created on first use
+	eventsOnce sync.Once
 }
 
 // NewFilterSystem creates a filter system.
@@ -93,6 +97,15 @@ func NewFilterSystem(backend Backend, config Config) *FilterSystem {
 	}
 }
 
+// EventSystem returns the event system shared by all the consumers of the
+// filter system, creating it on first use.
+func (sys *FilterSystem) EventSystem() *EventSystem {
+	sys.eventsOnce.Do(func() {
+		sys.events = NewEventSystem(sys, false)
+	})
+	return sys.events
+}
+
 type logCacheElem struct {
 	logs []*types.Log
 	body atomic.Value
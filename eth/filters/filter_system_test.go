@@ -45,6 +45,7 @@ import (
 type testBackend struct {
 	db              ethdb.Database
 	sections        uint64
+	logIndexed      uint64
 	txFeed          event.Feed
 	logsFeed        event.Feed
 	rmLogsFeed      event.Feed
@@ -153,6 +154,10 @@ func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
 
+func (b *testBackend) LogIndexStatus() uint64 {
+	return b.logIndexed
+}
+
 func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	requests := make(chan chan *bloombits.Retrieval)
 
@@ -191,6 +196,18 @@ func newTestFilterSystem(t testing.TB, db ethdb.Database, cfg Config) (*testBack
 // - one at the start and should receive all posted chain events and a second (blockHashes)
 // - one that is created after a cutoff moment and uninstalled after a second cutoff moment (blockHashes[cutoff1:cutoff2])
 // - one that is created after the second cutoff moment (blockHashes[cutoff2:])
+// TestSharedEventSystem tests that the consumers of a filter system share a
+// single event system instead of subscribing to the backend each.
+func TestSharedEventSystem(t *testing.T) {
+	t.Parallel()
+
+	_, sys := newTestFilterSystem(t, rawdb.NewMemoryDatabase(), Config{})
+	first, second := NewFilterAPI(sys, false), NewFilterAPI(sys, false)
+	if first.events != sys.EventSystem() || second.events != sys.EventSystem() {
+		t.Fatal("filter APIs not sharing the event system of the filter system")
+	}
+}
+
 func TestBlockSubscription(t *testing.T) {
 	t.Parallel()
 
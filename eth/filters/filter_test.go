@@ -19,7 +19,9 @@ package filters
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -107,6 +109,85 @@ func BenchmarkFilters(b *testing.B) {
 	}
 }
 
+func TestLogIndexFilters(t *testing.T) {
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		key1, _      = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1        = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2        = common.BytesToAddress([]byte("jeff"))
+		topic1       = common.BytesToHash([]byte("topic1"))
+		topic2       = common.BytesToHash([]byte("topic2"))
+
+		gspec = &core.Genesis{
+			Alloc:   types.GenesisAlloc{addr1: {Balance: big.NewInt(1000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Config:  params.TestChainConfig,
+		}
+	)
+	addLogs := func(gen *core.BlockGen, logs ...*types.Log) {
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = logs
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+	}
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 300, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 9:
+			addLogs(gen, &types.Log{Address: addr1, Topics: []common.Hash{topic1}})
+		case 19:
+			addLogs(gen, &types.Log{Address: addr2, Topics: []common.Hash{topic2, topic1}})
+		case 29:
+			// Bloom false positive for (addr1, topic1), both present in different logs
+			addLogs(gen, &types.Log{Address: addr1, Topics: []common.Hash{topic2}}, &types.Log{Address: addr2, Topics: []common.Hash{topic1}})
+		case 299:
+			addLogs(gen, &types.Log{Address: addr1, Topics: []common.Hash{topic1, topic2}})
+		}
+	})
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	if err := core.RebuildLogIndex(db, 300); err != nil {
+		t.Fatalf("failed to build log index: %v", err)
+	}
+	backend.logIndexed = 301
+
+	// Inject a stale entry of a reorged block, which must be ignored
+	rawdb.WriteLogIndex(db, common.Hash{0x01}, 50, [][]*types.Log{{{Address: addr1, Topics: []common.Hash{topic1}}}})
+
+	for i, tc := range []struct {
+		addresses []common.Address
+		topics    [][]common.Hash
+		want      []uint64
+	}{
+		{addresses: []common.Address{addr1}, want: []uint64{10, 30, 300}},
+		{addresses: []common.Address{addr1}, topics: [][]common.Hash{{topic1}}, want: []uint64{10, 300}},
+		{addresses: []common.Address{addr1, addr2}, topics: [][]common.Hash{{topic2}}, want: []uint64{20, 30}},
+		{topics: [][]common.Hash{{topic1}}, want: []uint64{10, 30, 300}},
+		{topics: [][]common.Hash{nil, {topic1}}, want: []uint64{20}},
+		{topics: [][]common.Hash{{topic2}, {topic1}}, want: []uint64{20}},
+		{addresses: []common.Address{addr2}, topics: [][]common.Hash{nil, {topic2}}},
+	} {
+		logs, err := sys.NewRangeFilter(0, int64(rpc.LatestBlockNumber), tc.addresses, tc.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: failed to filter logs: %v", i, err)
+		}
+		var have []uint64
+		for _, log := range logs {
+			have = append(have, log.BlockNumber)
+		}
+		if !reflect.DeepEqual(have, tc.want) {
+			t.Errorf("test %d: block mismatch: have %v, want %v", i, have, tc.want)
+		}
+	}
+}
+
 func TestFilters(t *testing.T) {
 	var (
 		db     = rawdb.NewMemoryDatabase()
@@ -387,4 +468,65 @@ func TestFilters(t *testing.T) {
 			t.Fatalf("expected context.DeadlineExceeded, got %v", err)
 		}
 	})
+
+	t.Run("stream", func(t *testing.T) {
+		logs, err := sys.NewRangeFilter(0, int64(rpc.PendingBlockNumber), nil, nil).Logs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := json.Marshal(logs)
+		have, err := json.Marshal(streamLogs(sys.NewRangeFilter(0, int64(rpc.PendingBlockNumber), nil, nil), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(have) != string(want) {
+			t.Fatalf("streamed logs differ, have:\n%s\nwant:\n%s", have, want)
+		}
+		// Logs of extended filters are streamed decoded.
+		var ext ExtendedFilterCriteria
+		if err := json.Unmarshal([]byte(`{"abi":`+transferABI+`}`), &ext); err != nil {
+			t.Fatal(err)
+		}
+		want, _ = json.Marshal(ext.filter(logs))
+		have, err = json.Marshal(streamLogs(sys.NewRangeFilter(0, int64(rpc.PendingBlockNumber), nil, nil), &ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(have) != string(want) {
+			t.Fatalf("streamed extended logs differ, have:\n%s\nwant:\n%s", have, want)
+		}
+		// Over RPC, the streaming API serves the same logs as the in-process one.
+		api := NewFilterAPI(sys, false)
+		server := rpc.NewServer()
+		defer server.Stop()
+		if err := server.RegisterName("eth", NewStreamingFilterAPI(api)); err != nil {
+			t.Fatal(err)
+		}
+		client := rpc.DialInProc(server)
+		defer client.Close()
+
+		crit := FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}
+		logs, err = api.GetLogs(context.Background(), crit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ = json.Marshal(logs)
+		var streamed json.RawMessage
+		if err := client.Call(&streamed, "eth_getLogs", map[string]interface{}{"fromBlock": "0x0", "toBlock": "latest"}); err != nil {
+			t.Fatal(err)
+		}
+		if string(streamed) != string(want) {
+			t.Fatalf("eth_getLogs differs from GetLogs, have:\n%s\nwant:\n%s", streamed, want)
+		}
+		// Stopping the search early must not leak or block the retrieval.
+		errStop := errors.New("stop")
+		var seen int
+		err = sys.NewRangeFilter(0, int64(rpc.LatestBlockNumber), nil, nil).forEach(context.Background(), func(*types.Log) error {
+			seen++
+			return errStop
+		})
+		if err != errStop || seen != 1 {
+			t.Fatalf("search not stopped: err %v, seen %d", err, seen)
+		}
+	})
 }
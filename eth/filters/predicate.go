@@ -0,0 +1,411 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var errInvalidPredicate = errors.New("invalid log predicate")
+
+// The maximum number of nodes allowed in a single predicate tree
+const maxPredicateNodes = 256
+
+// The maximum nesting depth of a predicate tree
+const maxPredicateDepth = 16
+
+// LogPredicate is a node of a boolean expression tree evaluated against logs.
+// Exactly one of the fields must be set: either a combinator over child
+// predicates or a leaf condition on the topics or the data of the log.
+type LogPredicate struct {
+	And   []*LogPredicate `json:"and,omitempty"`
+	Or    []*LogPredicate `json:"or,omitempty"`
+	Not   *LogPredicate   `json:"not,omitempty"`
+	Topic *TopicPredicate `json:"topic,omitempty"`
+	Data  *DataPredicate  `json:"data,omitempty"`
+}
+
+// TopicPredicate matches logs whose topic at the given position equals any
+// of the listed values.
+type TopicPredicate struct {
+	Index uint          `json:"index"`
+	OneOf []common.Hash `json:"oneOf"`
+}
+
+// DataPredicate matches logs whose data contains the given bytes starting at
+// the given offset.
+type DataPredicate struct {
+	Offset uint64        `json:"offset"`
+	Equals hexutil.Bytes `json:"equals"`
+}
+
+// validate checks that the predicate tree is well formed and within the size
+// limits, returning the number of nodes it contains.
+func (p *LogPredicate) validate(depth int) (int, error) {
+	if p == nil {
+		return 0, fmt.Errorf("%w: empty node", errInvalidPredicate)
+	}
+	if depth > maxPredicateDepth {
+		return 0, fmt.Errorf("%w: nesting deeper than %d", errInvalidPredicate, maxPredicateDepth)
+	}
+	var set int
+	if p.And != nil {
+		set++
+	}
+	if p.Or != nil {
+		set++
+	}
+	if p.Not != nil {
+		set++
+	}
+	if p.Topic != nil {
+		set++
+	}
+	if p.Data != nil {
+		set++
+	}
+	if set != 1 {
+		return 0, fmt.Errorf("%w: node must have exactly one of and, or, not, topic, data", errInvalidPredicate)
+	}
+	nodes := 1
+	switch {
+	case p.And != nil || p.Or != nil:
+		children := p.And
+		if p.Or != nil {
+			children = p.Or
+		}
+		if len(children) == 0 {
+			return 0, fmt.Errorf("%w: empty combinator", errInvalidPredicate)
+		}
+		for _, child := range children {
+			n, err := child.validate(depth + 1)
+			if err != nil {
+				return 0, err
+			}
+			nodes += n
+		}
+	case p.Not != nil:
+		n, err := p.Not.validate(depth + 1)
+		if err != nil {
+			return 0, err
+		}
+		nodes += n
+	case p.Topic != nil:
+		if p.Topic.Index >= maxTopics {
+			return 0, fmt.Errorf("%w: topic index %d out of range", errInvalidPredicate, p.Topic.Index)
+		}
+		if len(p.Topic.OneOf) == 0 || len(p.Topic.OneOf) > maxSubTopics {
+			return 0, fmt.Errorf("%w: topic value count must be within [1, %d]", errInvalidPredicate, maxSubTopics)
+		}
+	case p.Data != nil:
+		if len(p.Data.Equals) == 0 {
+			return 0, fmt.Errorf("%w: empty data match", errInvalidPredicate)
+		}
+	}
+	if nodes > maxPredicateNodes {
+		return 0, fmt.Errorf("%w: more than %d nodes", errInvalidPredicate, maxPredicateNodes)
+	}
+	return nodes, nil
+}
+
+// Match evaluates the predicate against the given log.
+func (p *LogPredicate) Match(log *types.Log) bool {
+	switch {
+	case p.And != nil:
+		for _, child := range p.And {
+			if !child.Match(log) {
+				return false
+			}
+		}
+		return true
+	case p.Or != nil:
+		for _, child := range p.Or {
+			if child.Match(log) {
+				return true
+			}
+		}
+		return false
+	case p.Not != nil:
+		return !p.Not.Match(log)
+	case p.Topic != nil:
+		if p.Topic.Index >= uint(len(log.Topics)) {
+			return false
+		}
+		for _, topic := range p.Topic.OneOf {
+			if log.Topics[p.Topic.Index] == topic {
+				return true
+			}
+		}
+		return false
+	case p.Data != nil:
+		end := p.Data.Offset + uint64(len(p.Data.Equals))
+		if end < p.Data.Offset || end > uint64(len(log.Data)) {
+			return false
+		}
+		return bytes.Equal(log.Data[p.Data.Offset:end], p.Data.Equals)
+	}
+	return false
+}
+
+// topicHints derives positional topic constraints that every log matched by
+// the predicate must satisfy. The result can be used as the plain topic filter
+// of the underlying query, letting the bloom or log index narrow the search.
+func (p *LogPredicate) topicHints() [][]common.Hash {
+	var leaves []*TopicPredicate
+	switch {
+	case p.Topic != nil:
+		leaves = append(leaves, p.Topic)
+	case p.And != nil:
+		for _, child := range p.And {
+			if child.Topic != nil {
+				leaves = append(leaves, child.Topic)
+			}
+		}
+	}
+	var hints [][]common.Hash
+	for _, leaf := range leaves {
+		for uint(len(hints)) <= leaf.Index {
+			hints = append(hints, nil)
+		}
+		// Multiple conditions on the same position are intersected by the
+		// predicate itself, any one of them is a valid superset.
+		if hints[leaf.Index] == nil {
+			hints[leaf.Index] = leaf.OneOf
+		}
+	}
+	return hints
+}
+
+// ExtendedFilterCriteria is a log filter request which, on top of the plain
+// address and topic sets, supports an arbitrary predicate over the topics and
+// data of the logs and the decoding of matched logs via an ABI.
+type ExtendedFilterCriteria struct {
+	FilterCriteria
+	Where *LogPredicate // Optional predicate the logs must additionally satisfy
+	ABI   *abi.ABI      // Optional event definitions to decode matched logs with
+}
+
+// UnmarshalJSON sets *args fields with given data.
+func (args *ExtendedFilterCriteria) UnmarshalJSON(data []byte) error {
+	if err := args.FilterCriteria.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	var raw struct {
+		Where *LogPredicate   `json:"where"`
+		ABI   json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Where != nil {
+		if _, err := raw.Where.validate(0); err != nil {
+			return err
+		}
+		args.Where = raw.Where
+	}
+	if len(raw.ABI) > 0 && string(raw.ABI) != "null" {
+		parsed, err := parseEventABI(raw.ABI)
+		if err != nil {
+			return err
+		}
+		args.ABI = parsed
+	}
+	return nil
+}
+
+// parseEventABI parses either a single ABI fragment or a full ABI definition
+// and ensures it contains at least one event.
+func parseEventABI(data json.RawMessage) (*abi.ABI, error) {
+	// A JSON string is also accepted for clients passing the ABI verbatim
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		data = json.RawMessage(str)
+	}
+	def := strings.TrimSpace(string(data))
+	if strings.HasPrefix(def, "{") {
+		def = "[" + def + "]"
+	}
+	parsed, err := abi.JSON(strings.NewReader(def))
+	if err != nil {
+		return nil, fmt.Errorf("invalid event abi: %v", err)
+	}
+	if len(parsed.Events) == 0 {
+		return nil, errors.New("invalid event abi: no events defined")
+	}
+	return &parsed, nil
+}
+
+// query returns the plain filter query used to pre-select logs from the event
+// system or the database before the predicate is evaluated.
+func (args *ExtendedFilterCriteria) query() ethereum.FilterQuery {
+	query := ethereum.FilterQuery(args.FilterCriteria)
+	if len(query.Topics) == 0 && args.Where != nil {
+		query.Topics = args.Where.topicHints()
+	}
+	return query
+}
+
+// match reports whether the log satisfies the extended predicate, if any.
+func (args *ExtendedFilterCriteria) match(log *types.Log) bool {
+	return args.Where == nil || args.Where.Match(log)
+}
+
+// filter returns the logs satisfying the extended predicate, decoded with the
+// provided ABI.
+func (args *ExtendedFilterCriteria) filter(logs []*types.Log) []*DecodedLog {
+	res := []*DecodedLog{}
+	for _, log := range logs {
+		if args.match(log) {
+			res = append(res, args.decode(log))
+		}
+	}
+	return res
+}
+
+// DecodedLog is a log matched by an extended filter, carrying the event name
+// and fields decoded with the filter's ABI if it defines the event.
+type DecodedLog struct {
+	*types.Log
+	Event       string
+	Fields      map[string]interface{}
+	DecodeError string
+}
+
+// MarshalJSON marshals the log as usual, extended with the decoded fields.
+func (l *DecodedLog) MarshalJSON() ([]byte, error) {
+	enc, err := json.Marshal(l.Log)
+	if err != nil {
+		return nil, err
+	}
+	if l.Event == "" && l.DecodeError == "" {
+		return enc, nil
+	}
+	extra, err := json.Marshal(struct {
+		Event       string                 `json:"event,omitempty"`
+		Fields      map[string]interface{} `json:"fields,omitempty"`
+		DecodeError string                 `json:"decodeError,omitempty"`
+	}{l.Event, l.Fields, l.DecodeError})
+	if err != nil {
+		return nil, err
+	}
+	return spliceJSON(enc, extra), nil
+}
+
+// spliceJSON merges the fields of the extra JSON object into the base one.
+func spliceJSON(base, extra []byte) []byte {
+	if len(extra) <= 2 {
+		return base
+	}
+	base = append(base[:len(base)-1], ',')
+	return append(base, extra[1:]...)
+}
+
+// decode attempts to decode the log with the event of the ABI matching its
+// first topic. Anonymous events are not supported.
+func (args *ExtendedFilterCriteria) decode(log *types.Log) *DecodedLog {
+	res := &DecodedLog{Log: log}
+	if args.ABI == nil || len(log.Topics) == 0 {
+		return res
+	}
+	event, err := args.ABI.EventByID(log.Topics[0])
+	if err != nil {
+		return res
+	}
+	res.Event = event.Name
+
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	fields := make(map[string]interface{})
+	if err := event.Inputs.UnpackIntoMap(fields, log.Data); err != nil {
+		res.DecodeError = err.Error()
+		return res
+	}
+	if err := abi.ParseTopicsIntoMap(fields, indexed, log.Topics[1:]); err != nil {
+		res.DecodeError = err.Error()
+		return res
+	}
+	res.Fields = make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		res.Fields[name] = toJSONValue(reflect.ValueOf(value))
+	}
+	return res
+}
+
+var (
+	bigIntType    = reflect.TypeOf((*big.Int)(nil))
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*interface{ MarshalText() ([]byte, error) })(nil)).Elem()
+)
+
+// toJSONValue converts a value produced by the ABI decoder into its RPC
+// representation, using hex encoding for integers and byte sequences as the
+// rest of the API does.
+func toJSONValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type() == bigIntType {
+		return (*hexutil.Big)(v.Interface().(*big.Int))
+	}
+	if v.Type().Implements(marshalerType) || v.Type().Implements(textType) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return hexutil.Uint64(v.Uint())
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return (*hexutil.Big)(big.NewInt(v.Int()))
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(buf), v)
+			return hexutil.Bytes(buf)
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = toJSONValue(v.Index(i))
+		}
+		return items
+	case reflect.Struct:
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Tag.Get("json")
+			if name == "" {
+				name = v.Type().Field(i).Name
+			}
+			fields[name] = toJSONValue(v.Field(i))
+		}
+		return fields
+	}
+	return v.Interface()
+}
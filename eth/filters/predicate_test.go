@@ -0,0 +1,252 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+const transferABI = `{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}`
+
+var (
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	approvalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+)
+
+func transferLog(from, to common.Address, value int64) *types.Log {
+	return &types.Log{
+		Address: common.Address{0xaa},
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.BigToHash(big.NewInt(value)).Bytes(),
+	}
+}
+
+func TestUnmarshalExtendedFilterCriteria(t *testing.T) {
+	var crit ExtendedFilterCriteria
+	vector := `{"fromBlock":"0x1","address":"0xaa00000000000000000000000000000000000000","where":{"and":[{"topic":{"index":0,"oneOf":["` + transferTopic.Hex() + `"]}},{"not":{"data":{"offset":31,"equals":"0x00"}}}]},"abi":` + transferABI + `}`
+	if err := json.Unmarshal([]byte(vector), &crit); err != nil {
+		t.Fatal(err)
+	}
+	if crit.FromBlock == nil || crit.FromBlock.Int64() != 1 {
+		t.Fatalf("expected from block 1, got %v", crit.FromBlock)
+	}
+	if len(crit.Addresses) != 1 || crit.Addresses[0] != (common.Address{0xaa}) {
+		t.Fatalf("unexpected addresses %v", crit.Addresses)
+	}
+	if crit.Where == nil || len(crit.Where.And) != 2 {
+		t.Fatalf("unexpected predicate %+v", crit.Where)
+	}
+	if crit.ABI == nil || len(crit.ABI.Events) != 1 {
+		t.Fatalf("unexpected abi %+v", crit.ABI)
+	}
+	// The top level topic condition should be pushed down into the plain query
+	if query := crit.query(); !reflect.DeepEqual(query.Topics, [][]common.Hash{{transferTopic}}) {
+		t.Fatalf("unexpected query topics %v", query.Topics)
+	}
+	// ABI given as an encoded string
+	quoted, _ := json.Marshal("[" + transferABI + "]")
+	if err := json.Unmarshal([]byte(`{"abi":`+string(quoted)+`}`), &crit); err != nil {
+		t.Fatalf("failed to parse quoted abi: %v", err)
+	}
+	// Invalid predicates
+	invalid := []string{
+		`{"where":{}}`,
+		`{"where":{"and":[]}}`,
+		`{"where":{"not":{"topic":{"index":4,"oneOf":["` + transferTopic.Hex() + `"]}}}}`,
+		`{"where":{"topic":{"index":0,"oneOf":[]}}}`,
+		`{"where":{"data":{"offset":0,"equals":"0x"}}}`,
+		`{"where":{"topic":{"index":0,"oneOf":["` + transferTopic.Hex() + `"]},"data":{"offset":0,"equals":"0x01"}}}`,
+	}
+	for i, vector := range invalid {
+		var crit ExtendedFilterCriteria
+		if err := json.Unmarshal([]byte(vector), &crit); !errors.Is(err, errInvalidPredicate) {
+			t.Errorf("case %d: expected invalid predicate error, got %v", i, err)
+		}
+	}
+	// Too deeply nested predicates
+	deep := `{"topic":{"index":0,"oneOf":["` + transferTopic.Hex() + `"]}}`
+	for i := 0; i <= maxPredicateDepth; i++ {
+		deep = `{"not":` + deep + `}`
+	}
+	if err := json.Unmarshal([]byte(`{"where":`+deep+`}`), &crit); !errors.Is(err, errInvalidPredicate) {
+		t.Errorf("expected invalid predicate error for deep nesting, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"abi":[{"type":"function","name":"f","inputs":[]}]}`), &crit); err == nil {
+		t.Errorf("expected error for abi without events")
+	}
+}
+
+func TestLogPredicateMatch(t *testing.T) {
+	var (
+		alice = common.Address{0x01}
+		bob   = common.Address{0x02}
+		carol = common.Address{0x03}
+
+		logs = []*types.Log{
+			transferLog(alice, bob, 1),
+			transferLog(bob, carol, 2),
+			transferLog(carol, alice, 256),
+			{Topics: []common.Hash{approvalTopic}},
+		}
+		topic = func(index uint, values ...common.Hash) *LogPredicate {
+			return &LogPredicate{Topic: &TopicPredicate{Index: index, OneOf: values}}
+		}
+		addr = func(a common.Address) common.Hash { return common.BytesToHash(a.Bytes()) }
+	)
+	tests := []struct {
+		pred *LogPredicate
+		want []int
+	}{
+		{topic(0, transferTopic), []int{0, 1, 2}},
+		{topic(1, addr(alice), addr(bob)), []int{0, 1}},
+		{&LogPredicate{And: []*LogPredicate{topic(1, addr(bob)), topic(2, addr(carol))}}, []int{1}},
+		{&LogPredicate{Or: []*LogPredicate{topic(1, addr(alice)), topic(2, addr(alice))}}, []int{0, 2}},
+		{&LogPredicate{Not: topic(0, transferTopic)}, []int{3}},
+		{&LogPredicate{Data: &DataPredicate{Offset: 31, Equals: hexutil.Bytes{0x02}}}, []int{1}},
+		{&LogPredicate{Data: &DataPredicate{Offset: 30, Equals: hexutil.Bytes{0x01, 0x00}}}, []int{2}},
+		{&LogPredicate{Data: &DataPredicate{Offset: 31, Equals: hexutil.Bytes{0x00, 0x00}}}, nil},
+		{&LogPredicate{And: []*LogPredicate{
+			topic(0, transferTopic),
+			{Not: &LogPredicate{Data: &DataPredicate{Offset: 31, Equals: hexutil.Bytes{0x01}}}},
+		}}, []int{1, 2}},
+	}
+	for i, tt := range tests {
+		if _, err := tt.pred.validate(0); err != nil {
+			t.Fatalf("case %d: invalid predicate: %v", i, err)
+		}
+		var have []int
+		for j, log := range logs {
+			if tt.pred.Match(log) {
+				have = append(have, j)
+			}
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("case %d: matched logs mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	var crit ExtendedFilterCriteria
+	if err := json.Unmarshal([]byte(`{"abi":`+transferABI+`}`), &crit); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		alice = common.Address{0x01}
+		bob   = common.Address{0x02}
+	)
+	decoded := crit.decode(transferLog(alice, bob, 1000))
+	if decoded.Event != "Transfer" {
+		t.Fatalf("unexpected event name %q", decoded.Event)
+	}
+	enc, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields struct {
+		Address common.Address `json:"address"`
+		Event   string         `json:"event"`
+		Fields  struct {
+			From  common.Address `json:"from"`
+			To    common.Address `json:"to"`
+			Value *hexutil.Big   `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(enc, &fields); err != nil {
+		t.Fatalf("failed to unmarshal decoded log %s: %v", enc, err)
+	}
+	if fields.Address != (common.Address{0xaa}) || fields.Fields.From != alice || fields.Fields.To != bob || fields.Fields.Value.ToInt().Int64() != 1000 {
+		t.Fatalf("unexpected decoded log %s", enc)
+	}
+	// Logs of unknown events are passed through undecoded
+	if decoded := crit.decode(&types.Log{Topics: []common.Hash{approvalTopic}}); decoded.Event != "" || decoded.Fields != nil {
+		t.Fatalf("unexpected decoding of unknown event: %+v", decoded)
+	}
+	// Malformed logs carry the decoding error
+	malformed := transferLog(alice, bob, 1)
+	malformed.Data = malformed.Data[:16]
+	if decoded := crit.decode(malformed); decoded.DecodeError == "" {
+		t.Fatalf("expected decoding error for malformed log")
+	}
+}
+
+func TestExtendedLogFilter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+
+		alice = common.Address{0x01}
+		bob   = common.Address{0x02}
+		logs  = []*types.Log{transferLog(alice, bob, 1), transferLog(bob, alice, 2), {Topics: []common.Hash{approvalTopic}}}
+	)
+	var crit ExtendedFilterCriteria
+	vector := `{"where":{"and":[{"topic":{"index":0,"oneOf":["` + transferTopic.Hex() + `"]}},{"data":{"offset":31,"equals":"0x02"}}]},"abi":` + transferABI + `}`
+	if err := json.Unmarshal([]byte(vector), &crit); err != nil {
+		t.Fatal(err)
+	}
+	gspec := &core.Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+
+	id, err := api.NewExtendedFilter(crit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1 * time.Second)
+	if nsend := backend.logsFeed.Send(logs); nsend == 0 {
+		t.Fatal("Logs event not delivered")
+	}
+	var fetched []*DecodedLog
+	for timeout := time.Now().Add(time.Second); len(fetched) == 0 && time.Now().Before(timeout); time.Sleep(100 * time.Millisecond) {
+		results, err := api.GetFilterChanges(id)
+		if err != nil {
+			t.Fatalf("Unable to fetch logs: %v", err)
+		}
+		fetched = append(fetched, results.([]*DecodedLog)...)
+	}
+	if len(fetched) != 1 {
+		t.Fatalf("invalid number of logs, want 1, got %d", len(fetched))
+	}
+	if fetched[0].Log != logs[1] || fetched[0].Event != "Transfer" || fetched[0].Fields["from"] != bob {
+		t.Fatalf("unexpected log %+v", fetched[0])
+	}
+	// Stored logs of the filter are decoded the same way
+	stored, err := api.GetFilterLogs(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Unable to fetch stored logs: %v", err)
+	}
+	if _, ok := stored.([]*DecodedLog); !ok {
+		t.Fatalf("stored logs not decoded: %T", stored)
+	}
+}
@@ -34,7 +34,21 @@ import (
 type ethHandler handler
 
 func (h *ethHandler) Chain() *core.BlockChain { return h.chain }
-func (h *ethHandler) TxPool() eth.TxPool      { return h.txpool }
+func (h *ethHandler) TxPool() eth.TxPool      { return publicTxPool{h.txpool} }
+
+// publicTxPool is the view of the transaction pool served to peers, hiding the
+// privately submitted transactions.
+type publicTxPool struct {
+	txpool txPool
+}
+
+// Get retrieves the transaction with the given hash, unless it is private.
+func (p publicTxPool) Get(hash common.Hash) *types.Transaction {
+	if p.txpool.IsPrivate(hash) {
+		return nil
+	}
+	return p.txpool.Get(hash)
+}
 
 // RunPeer is invoked when a peer joins on the `eth` protocol.
 func (h *ethHandler) RunPeer(peer *eth.Peer, hand eth.Handler) error {
@@ -68,6 +82,12 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		return h.handleBlockBroadcast(peer, packet.Block, packet.TD)
 
 	case *eth.NewPooledTransactionHashesPacket:
+		// CHANGE(taiko): don't retrieve blob transactions on networks not including them
+		if h.chain.Config().BlobTxsDisabled() {
+			if packet = withoutBlobTxs(packet); len(packet.Hashes) == 0 {
+				return nil
+			}
+		}
 		return h.txFetcher.Notify(peer.ID(), packet.Types, packet.Sizes, packet.Hashes)
 
 	case *eth.TransactionsPacket:
@@ -86,6 +106,20 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 	}
 }
 
+// withoutBlobTxs returns the announcement without the blob transactions.
+func withoutBlobTxs(ann *eth.NewPooledTransactionHashesPacket) *eth.NewPooledTransactionHashesPacket {
+	filtered := new(eth.NewPooledTransactionHashesPacket)
+	for i, kind := range ann.Types {
+		if kind == types.BlobTxType {
+			continue
+		}
+		filtered.Types = append(filtered.Types, kind)
+		filtered.Sizes = append(filtered.Sizes, ann.Sizes[i])
+		filtered.Hashes = append(filtered.Hashes, ann.Hashes[i])
+	}
+	return filtered
+}
+
 // handleBlockAnnounces is invoked from a peer's message handler when it transmits a
 // batch of block announcements for the local node to process.
 func (h *ethHandler) handleBlockAnnounces(peer *eth.Peer, hashes []common.Hash, numbers []uint64) error {
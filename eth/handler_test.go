@@ -79,6 +79,12 @@ func (p *testTxPool) Get(hash common.Hash) *types.Transaction {
 	return p.pool[hash]
 }
 
+// IsPrivate reports whether the transaction was submitted privately, which the
+// test pool doesn't support.
+func (p *testTxPool) IsPrivate(hash common.Hash) bool {
+	return false
+}
+
 // Add appends a batch of transactions to the pool, and notifies any
 // listeners if the addition channel is non nil
 func (p *testTxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
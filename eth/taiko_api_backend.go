@@ -1,12 +1,16 @@
 package eth
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/miner"
 )
@@ -133,3 +137,30 @@ func (a *TaikoAuthAPIBackend) TxPoolContentWithMinTip(
 		minTip,
 	)
 }
+
+// PrivateTxResult is the outcome of submitting one of a batch of private
+// transactions.
+type PrivateTxResult struct {
+	Hash  common.Hash `json:"hash"`
+	Error string      `json:"error,omitempty"`
+}
+
+// SendPrivateRawTransactions adds a batch of signed transactions to the
+// transaction pool without propagating them to the network, like
+// eth_sendPrivateRawTransaction. The transactions are submitted in order, a
+// failing one doesn't prevent the submission of the rest.
+func (a *TaikoAuthAPIBackend) SendPrivateRawTransactions(ctx context.Context, txs []hexutil.Bytes) []PrivateTxResult {
+	results := make([]PrivateTxResult, len(txs))
+	for i, input := range txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(input); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Hash = tx.Hash()
+		if _, err := ethapi.SubmitPrivateTransaction(ctx, a.eth.APIBackend, tx); err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+	return results
+}
@@ -0,0 +1,57 @@
+package eth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that the OpenRPC document describes the transactions lists returned by
+// the taikoAuth namespace.
+func TestTaikoAuthOpenRPCSchema(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName(rpc.TaikoAuth, NewTaikoAuthAPIBackend(nil)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	var doc rpc.OpenRPCDocument
+	if err := client.Call(&doc, "rpc.discover"); err != nil {
+		t.Fatal("discover failed:", err)
+	}
+	const ref = "#/components/schemas/miner.PreBuiltTxList"
+	for _, name := range []string{"taikoAuth_txPoolContent", "taikoAuth_txPoolContentWithMinTip"} {
+		var method *rpc.OpenRPCMethod
+		for _, m := range doc.Methods {
+			if m.Name == name {
+				method = m
+			}
+		}
+		if method == nil {
+			t.Fatalf("method %s missing", name)
+		}
+		if res := method.Result.Schema; res.Type != "array" || res.Items == nil || res.Items.Ref != ref {
+			t.Fatalf("wrong result of %s: %+v", name, res)
+		}
+	}
+	want := &rpc.JSONSchema{
+		Type: "object",
+		Properties: map[string]*rpc.JSONSchema{
+			"TxList":           {Type: "array", Items: &rpc.JSONSchema{Title: "types.Transaction"}},
+			"EstimatedGasUsed": {Type: "integer"},
+			"BytesLength":      {Type: "integer"},
+			"bundles":          {Type: "array", Items: &rpc.JSONSchema{Ref: "#/components/schemas/miner.BundleResult"}},
+		},
+		Required: []string{"BytesLength", "EstimatedGasUsed", "TxList"},
+	}
+	if have := doc.Components.Schemas["miner.PreBuiltTxList"]; !reflect.DeepEqual(have, want) {
+		t.Fatalf("wrong PreBuiltTxList schema: %+v", have)
+	}
+	bundle := doc.Components.Schemas["miner.BundleResult"]
+	if bundle == nil || !reflect.DeepEqual(bundle.Required, []string{"gasUsed", "hash", "included"}) {
+		t.Fatalf("wrong BundleResult schema: %+v", bundle)
+	}
+}
@@ -388,8 +388,12 @@ func TestTraceCall(t *testing.T) {
 				t.Errorf("test %d: expect no error, got %v", i, err)
 				continue
 			}
+			enc, err := json.Marshal(result)
+			if err != nil {
+				t.Errorf("test %d: failed to marshal result %v", i, err)
+			}
 			var have *logger.ExecutionResult
-			if err := json.Unmarshal(result.(json.RawMessage), &have); err != nil {
+			if err := json.Unmarshal(enc, &have); err != nil {
 				t.Errorf("test %d: failed to unmarshal result %v", i, err)
 			}
 			var want *logger.ExecutionResult
@@ -397,7 +401,7 @@ func TestTraceCall(t *testing.T) {
 				t.Errorf("test %d: failed to unmarshal result %v", i, err)
 			}
 			if !reflect.DeepEqual(have, want) {
-				t.Errorf("test %d: result mismatch, want %v, got %v", i, testspec.expect, string(result.(json.RawMessage)))
+				t.Errorf("test %d: result mismatch, want %v, got %v", i, testspec.expect, string(enc))
 			}
 		}
 	}
@@ -438,8 +442,12 @@ func TestTraceTransaction(t *testing.T) {
 	if err != nil {
 		t.Errorf("Failed to trace transaction %v", err)
 	}
+	enc, err := json.Marshal(result)
+	if err != nil {
+		t.Errorf("failed to marshal result %v", err)
+	}
 	var have *logger.ExecutionResult
-	if err := json.Unmarshal(result.(json.RawMessage), &have); err != nil {
+	if err := json.Unmarshal(enc, &have); err != nil {
 		t.Errorf("failed to unmarshal result %v", err)
 	}
 	if !reflect.DeepEqual(have, &logger.ExecutionResult{
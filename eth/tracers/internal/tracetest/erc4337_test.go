@@ -0,0 +1,123 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// Tests that the erc4337Tracer reports restricted opcodes used by the contracts
+// called from the entry point, but not by the entry point itself.
+func TestErc4337Tracer(t *testing.T) {
+	var (
+		entryPoint = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		account    = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		origin     = common.HexToAddress("0x00000000000000000000000000000000feed")
+		context    = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(8000000),
+			Time:        5,
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+		}
+		// callCode calls the given address with all gas, as the entry point calls
+		// the account
+		callCode = func(addr byte) []byte {
+			return []byte{
+				byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), // in and outs zero
+				byte(vm.DUP1), byte(vm.PUSH1), addr, byte(vm.GAS), // value=0,address=addr, gas=GAS
+				byte(vm.CALL),
+			}
+		}
+	)
+	for _, tc := range []struct {
+		name string
+		code []byte
+		want string
+	}{
+		{
+			name: "Allowed",
+			code: []byte{byte(vm.PUSH1), 0x1, byte(vm.POP)},
+			want: `{"violations":[]}`,
+		},
+		{
+			name: "Banned opcode",
+			code: []byte{byte(vm.TIMESTAMP), byte(vm.POP)},
+			want: `{"violations":[{"address":"0x00000000000000000000000000000000000000aa","opcode":"TIMESTAMP","depth":2}]}`,
+		},
+		{
+			name: "GAS not followed by a call",
+			code: []byte{byte(vm.GAS), byte(vm.POP)},
+			want: `{"violations":[{"address":"0x00000000000000000000000000000000000000aa","opcode":"GAS","depth":2,"reason":"GAS not followed by a call"}]}`,
+		},
+		{
+			name: "Call to address without code",
+			code: callCode(0xbb),
+			want: `{"violations":[{"address":"0x00000000000000000000000000000000000000aa","opcode":"CALL","depth":2,"reason":"call to address without code 0x00000000000000000000000000000000000000bb"}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := tests.MakePreState(rawdb.NewMemoryDatabase(),
+				types.GenesisAlloc{
+					// The entry point itself may use restricted opcodes
+					entryPoint: types.Account{Code: append([]byte{byte(vm.TIMESTAMP), byte(vm.POP)}, callCode(0xaa)...)},
+					account:    types.Account{Code: tc.code},
+					origin:     types.Account{Balance: big.NewInt(500000000000000)},
+				}, false, rawdb.HashScheme)
+			defer state.Close()
+
+			tracer, err := tracers.DefaultDirectory.New("erc4337Tracer", nil, json.RawMessage(`{"entryPoint":"0x00000000000000000000000000000000deadbeef"}`))
+			if err != nil {
+				t.Fatalf("failed to create tracer: %v", err)
+			}
+			txContext := vm.TxContext{Origin: origin, GasPrice: big.NewInt(1)}
+			evm := vm.NewEVM(context, txContext, state.StateDB, params.MainnetChainConfig, vm.Config{Tracer: tracer})
+			msg := &core.Message{
+				To:        &entryPoint,
+				From:      origin,
+				Value:     big.NewInt(0),
+				GasLimit:  80000,
+				GasPrice:  big.NewInt(0),
+				GasFeeCap: big.NewInt(0),
+				GasTipCap: big.NewInt(0),
+			}
+			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+			if _, err := st.TransitionDb(); err != nil {
+				t.Fatalf("failed to execute transaction: %v", err)
+			}
+			res, err := tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
+			}
+			if string(res) != tc.want {
+				t.Errorf("trace mismatch\n have: %v\n want: %v\n", string(res), tc.want)
+			}
+		})
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/holiman/uint256"
 )
 
@@ -241,21 +242,51 @@ func (l *StructLogger) GetResult() (json.RawMessage, error) {
 	if l.reason != nil {
 		return nil, l.reason
 	}
-	failed := l.err != nil
-	returnData := common.CopyBytes(l.output)
-	// Return data when successful and revert reason when reverted, otherwise empty.
-	returnVal := fmt.Sprintf("%x", returnData)
-	if failed && l.err != vm.ErrExecutionReverted {
-		returnVal = ""
-	}
 	return json.Marshal(&ExecutionResult{
 		Gas:         l.usedGas,
-		Failed:      failed,
-		ReturnValue: returnVal,
+		Failed:      l.err != nil,
+		ReturnValue: l.returnValue(),
 		StructLogs:  formatLogs(l.StructLogs()),
 	})
 }
 
+// StreamResult returns the same result as GetResult, but encodes it when it's
+// written by the RPC server. The logs are formatted one by one while streaming,
+// so large traces don't have to be held in memory in their final form.
+func (l *StructLogger) StreamResult() (rpc.StreamFunc, error) {
+	// Tracing aborted
+	if l.reason != nil {
+		return nil, l.reason
+	}
+	return func(s *rpc.JSONStream) error {
+		s.BeginObject()
+		s.Field("gas")
+		s.Value(l.usedGas)
+		s.Field("failed")
+		s.Value(l.err != nil)
+		s.Field("returnValue")
+		s.Value(l.returnValue())
+		s.Field("structLogs")
+		s.BeginArray()
+		for _, trace := range l.logs {
+			if err := s.Value(formatLog(trace)); err != nil {
+				return err
+			}
+		}
+		s.EndArray()
+		return s.EndObject()
+	}, nil
+}
+
+// returnValue returns the return data when successful and the revert reason when
+// reverted, otherwise it's empty.
+func (l *StructLogger) returnValue() string {
+	if l.err != nil && l.err != vm.ErrExecutionReverted {
+		return ""
+	}
+	return fmt.Sprintf("%x", l.output)
+}
+
 // Stop terminates execution of the tracer at the first opportune moment.
 func (l *StructLogger) Stop(err error) {
 	l.reason = err
@@ -429,39 +460,45 @@ type StructLogRes struct {
 func formatLogs(logs []StructLog) []StructLogRes {
 	formatted := make([]StructLogRes, len(logs))
 	for index, trace := range logs {
-		formatted[index] = StructLogRes{
-			Pc:            trace.Pc,
-			Op:            trace.Op.String(),
-			Gas:           trace.Gas,
-			GasCost:       trace.GasCost,
-			Depth:         trace.Depth,
-			Error:         trace.ErrorString(),
-			RefundCounter: trace.RefundCounter,
-		}
-		if trace.Stack != nil {
-			stack := make([]string, len(trace.Stack))
-			for i, stackValue := range trace.Stack {
-				stack[i] = stackValue.Hex()
-			}
-			formatted[index].Stack = &stack
-		}
-		if trace.ReturnData != nil && len(trace.ReturnData) > 0 {
-			formatted[index].ReturnData = hexutil.Bytes(trace.ReturnData).String()
+		formatted[index] = formatLog(trace)
+	}
+	return formatted
+}
+
+// formatLog formats a single structured log for json output.
+func formatLog(trace StructLog) StructLogRes {
+	formatted := StructLogRes{
+		Pc:            trace.Pc,
+		Op:            trace.Op.String(),
+		Gas:           trace.Gas,
+		GasCost:       trace.GasCost,
+		Depth:         trace.Depth,
+		Error:         trace.ErrorString(),
+		RefundCounter: trace.RefundCounter,
+	}
+	if trace.Stack != nil {
+		stack := make([]string, len(trace.Stack))
+		for i, stackValue := range trace.Stack {
+			stack[i] = stackValue.Hex()
 		}
-		if trace.Memory != nil {
-			memory := make([]string, 0, (len(trace.Memory)+31)/32)
-			for i := 0; i+32 <= len(trace.Memory); i += 32 {
-				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
-			}
-			formatted[index].Memory = &memory
+		formatted.Stack = &stack
+	}
+	if trace.ReturnData != nil && len(trace.ReturnData) > 0 {
+		formatted.ReturnData = hexutil.Bytes(trace.ReturnData).String()
+	}
+	if trace.Memory != nil {
+		memory := make([]string, 0, (len(trace.Memory)+31)/32)
+		for i := 0; i+32 <= len(trace.Memory); i += 32 {
+			memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
 		}
-		if trace.Storage != nil {
-			storage := make(map[string]string)
-			for i, storageValue := range trace.Storage {
-				storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
-			}
-			formatted[index].Storage = &storage
+		formatted.Memory = &memory
+	}
+	if trace.Storage != nil {
+		storage := make(map[string]string)
+		for i, storageValue := range trace.Storage {
+			storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
 		}
+		formatted.Storage = &storage
 	}
 	return formatted
 }
@@ -0,0 +1,207 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("erc4337Tracer", newErc4337Tracer, false)
+}
+
+// erc4337BannedOpcodes are the opcodes the validation of a user operation may not
+// use outside of the entry point, as their result differs between the simulation
+// and the inclusion of the operation.
+var erc4337BannedOpcodes = map[vm.OpCode]bool{
+	vm.GASPRICE:     true,
+	vm.GASLIMIT:     true,
+	vm.DIFFICULTY:   true,
+	vm.TIMESTAMP:    true,
+	vm.BASEFEE:      true,
+	vm.BLOCKHASH:    true,
+	vm.NUMBER:       true,
+	vm.SELFBALANCE:  true,
+	vm.BALANCE:      true,
+	vm.ORIGIN:       true,
+	vm.CREATE:       true,
+	vm.COINBASE:     true,
+	vm.SELFDESTRUCT: true,
+	vm.BLOBHASH:     true,
+	vm.BLOBBASEFEE:  true,
+}
+
+// Erc4337Violation is a use of a restricted opcode during the validation of a
+// user operation.
+type Erc4337Violation struct {
+	Address common.Address `json:"address"` // Contract executing the opcode
+	Opcode  string         `json:"opcode"`
+	Depth   int            `json:"depth"`
+	Reason  string         `json:"reason,omitempty"`
+}
+
+// Erc4337Result is the result of the erc4337Tracer.
+type Erc4337Result struct {
+	Violations []Erc4337Violation `json:"violations"`
+}
+
+// erc4337Tracer checks the opcode rules of ERC-4337 for the validation of a user
+// operation, as simulated by EntryPoint.simulateValidation. It reports the use of
+// opcodes whose result may change between simulation and inclusion by the
+// account, factory and paymaster contracts called by the entry point. Storage
+// access rules and reputation are not tracked.
+//
+// Example:
+//
+//	> debug.traceCall({to: entryPoint, data: simulateValidationCalldata}, "latest", {tracer: "erc4337Tracer", tracerConfig: {entryPoint: entryPoint}})
+//	{
+//	  violations: [{address: "0x...", opcode: "TIMESTAMP", depth: 3}]
+//	}
+type erc4337Tracer struct {
+	noopTracer
+	env        *vm.EVM
+	config     erc4337TracerConfig
+	violations []Erc4337Violation
+	creates    int // Number of CREATE2 executed outside the entry point
+	depth      int // Depth of the current call frame
+
+	gasDepth int            // Depth of a pending GAS opcode, which must be followed by a call
+	gasAddr  common.Address // Contract executing the pending GAS opcode
+
+	activePrecompiles []common.Address
+	interrupt         atomic.Bool
+	reason            error
+}
+
+type erc4337TracerConfig struct {
+	EntryPoint common.Address `json:"entryPoint"` // Entry point whose own execution is trusted
+}
+
+// newErc4337Tracer returns a native go tracer which checks the ERC-4337 opcode
+// rules, and implements vm.EVMLogger.
+func newErc4337Tracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config erc4337TracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if config.EntryPoint == (common.Address{}) {
+		return nil, errors.New("erc4337Tracer: entry point not configured")
+	}
+	return &erc4337Tracer{config: config}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *erc4337Tracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env, t.depth = env, 1
+	rules := env.ChainConfig().Rules(env.Context.BlockNumber, env.Context.Random != nil, env.Context.Time)
+	t.activePrecompiles = vm.ActivePrecompiles(rules)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *erc4337Tracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if err != nil || t.interrupt.Load() {
+		return
+	}
+	addr := scope.Contract.Address()
+
+	// GAS is only allowed as the gas argument of a call
+	if t.gasDepth == depth {
+		t.gasDepth = 0
+		if op != vm.CALL && op != vm.CALLCODE && op != vm.DELEGATECALL && op != vm.STATICCALL {
+			t.violate(t.gasAddr, vm.GAS, depth, "GAS not followed by a call")
+		}
+	}
+	if addr == t.config.EntryPoint {
+		return
+	}
+	switch {
+	case op == vm.GAS:
+		t.gasDepth, t.gasAddr = depth, addr
+	case op == vm.CREATE2:
+		// Only the factory may deploy the sender, once
+		if t.creates++; t.creates > 1 {
+			t.violate(addr, op, depth, "CREATE2 may only be used once, to deploy the sender")
+		}
+	case erc4337BannedOpcodes[op]:
+		t.violate(addr, op, depth, "")
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *erc4337Tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.depth++
+	if t.interrupt.Load() || from == t.config.EntryPoint {
+		return
+	}
+	if typ != vm.CALL && typ != vm.CALLCODE && typ != vm.DELEGATECALL && typ != vm.STATICCALL {
+		return
+	}
+	// Calling an address without code succeeds now, but may deploy code later on
+	if !t.isPrecompiled(to) && t.env.StateDB.GetCodeSize(to) == 0 {
+		t.violate(from, typ, t.depth-1, "call to address without code "+to.Hex())
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *erc4337Tracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.depth--
+}
+
+// isPrecompiled returns whether the addr is a precompile.
+func (t *erc4337Tracer) isPrecompiled(addr common.Address) bool {
+	for _, p := range t.activePrecompiles {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// violate records a rule violation.
+func (t *erc4337Tracer) violate(addr common.Address, op vm.OpCode, depth int, reason string) {
+	t.violations = append(t.violations, Erc4337Violation{Address: addr, Opcode: op.String(), Depth: depth, Reason: reason})
+}
+
+// GetResult returns the json-encoded rule violations, and any error arising from
+// the encoding or forceful termination (via `Stop`).
+func (t *erc4337Tracer) GetResult() (json.RawMessage, error) {
+	result := Erc4337Result{Violations: t.violations}
+	if result.Violations == nil {
+		result.Violations = []Erc4337Violation{}
+	}
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *erc4337Tracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
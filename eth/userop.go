@@ -0,0 +1,242 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/userpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+// userOpEntryPointGas is the gas allowance of the entry point itself when
+// simulating an operation, on top of the gas limits of the operation.
+const userOpEntryPointGas = 1_000_000
+
+// restrictedOpcodeError is returned if the validation of a user operation uses
+// opcodes it's not allowed to.
+type restrictedOpcodeError struct {
+	violation native.Erc4337Violation
+}
+
+func (e *restrictedOpcodeError) Error() string {
+	msg := fmt.Sprintf("user operation validation uses restricted opcode %s in %s", e.violation.Opcode, e.violation.Address)
+	if e.violation.Reason != "" {
+		msg += ": " + e.violation.Reason
+	}
+	return msg
+}
+
+// ErrorCode returns the ERC-4337 error code of opcode rule violations.
+func (e *restrictedOpcodeError) ErrorCode() int { return -32502 }
+
+// simulateUserOp calls EntryPoint.simulateValidation with the operation on top
+// of the given head, returning the result of the call, which always reverts.
+func (s *Ethereum) simulateUserOp(op *userpool.UserOperation, entryPoint common.Address, head *types.Header, statedb *state.StateDB, tracer vm.EVMLogger) (*core.ExecutionResult, error) {
+	data, err := userpool.PackSimulateValidation(op)
+	if err != nil {
+		return nil, err
+	}
+	msg := &core.Message{
+		To:                &entryPoint,
+		Value:             new(big.Int),
+		GasLimit:          op.GasLimit() + userOpEntryPointGas,
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		Data:              data,
+		SkipAccountChecks: true,
+	}
+	var (
+		blockCtx = core.NewEVMBlockContext(head, s.blockchain, nil)
+		evm      = vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, s.blockchain.Config(), vm.Config{Tracer: tracer, NoBaseFee: true})
+	)
+	return core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+}
+
+// validateUserOp simulates the validation of an operation, checking that the
+// account, factory and paymaster don't use restricted opcodes. It implements
+// userpool.ValidateFunc.
+func (s *Ethereum) validateUserOp(op *userpool.UserOperation, entryPoint common.Address, head *types.Header) (*userpool.ValidationResult, error) {
+	statedb, err := s.blockchain.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	config, _ := json.Marshal(map[string]common.Address{"entryPoint": entryPoint})
+	tracer, err := tracers.DefaultDirectory.New("erc4337Tracer", new(tracers.Context), config)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.simulateUserOp(op, entryPoint, head, statedb, tracer)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		return nil, err
+	}
+	var trace native.Erc4337Result
+	if err := json.Unmarshal(res, &trace); err != nil {
+		return nil, err
+	}
+	if len(trace.Violations) > 0 {
+		return nil, &restrictedOpcodeError{violation: trace.Violations[0]}
+	}
+	return userpool.UnpackValidationResult(result.Revert())
+}
+
+// estimateUserOpCall estimates the gas needed by the entry point to execute the
+// call of an operation, deploying the sender first if needed.
+func (s *Ethereum) estimateUserOpCall(op *userpool.UserOperation, entryPoint common.Address, head *types.Header, gasCap uint64) (uint64, error) {
+	statedb, err := s.blockchain.StateAt(head.Root)
+	if err != nil {
+		return 0, err
+	}
+	blockCtx := core.NewEVMBlockContext(head, s.blockchain, nil)
+	apply := func(statedb *state.StateDB, from common.Address, to *common.Address, data []byte, gas uint64) (*core.ExecutionResult, error) {
+		msg := &core.Message{
+			From:              from,
+			To:                to,
+			Value:             new(big.Int),
+			GasLimit:          gas,
+			GasPrice:          new(big.Int),
+			GasFeeCap:         new(big.Int),
+			GasTipCap:         new(big.Int),
+			Data:              data,
+			SkipAccountChecks: true,
+		}
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, s.blockchain.Config(), vm.Config{NoBaseFee: true})
+		return core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	}
+	// Undeployed senders are deployed by their factory first, assuming it doesn't
+	// restrict its callers to the entry point's sender creator
+	if factory := op.Factory(); factory != nil && statedb.GetCodeSize(op.Sender) == 0 {
+		result, err := apply(statedb, entryPoint, factory, op.InitCode[common.AddressLength:], gasCap)
+		if err != nil {
+			return 0, err
+		}
+		if result.Failed() {
+			return 0, fmt.Errorf("sender deployment failed: %w", result.Err)
+		}
+	}
+	call := func(gas uint64) (*core.ExecutionResult, error) {
+		return apply(statedb.Copy(), entryPoint, &op.Sender, op.CallData, gas)
+	}
+	result, err := call(gasCap)
+	if err != nil {
+		return 0, err
+	}
+	if result.Failed() {
+		if len(result.Revert()) > 0 {
+			return 0, fmt.Errorf("call reverted: %#x", result.Revert())
+		}
+		return 0, fmt.Errorf("call failed: %w", result.Err)
+	}
+	// Binary search the lowest gas limit the call succeeds with, as it may need
+	// more than it uses, e.g. due to the 63/64 rule
+	lo, hi := result.UsedGas-1, gasCap
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		result, err := call(mid)
+		if err != nil {
+			return 0, err
+		}
+		if result.Failed() {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
+
+// bundleUserOps submits the operations as a bundle of handleOps transactions,
+// one per entry point, signed by the configured bundler account. It implements
+// userpool.BundleFunc.
+func (s *Ethereum) bundleUserOps(ops map[common.Address][]*userpool.UserOperation, head *types.Header) error {
+	var (
+		account = accounts.Account{Address: s.config.UserPool.Bundler}
+		chainID = s.blockchain.Config().ChainID
+		nonce   = s.txPool.Nonce(account.Address)
+		bundle  = &txpool.Bundle{
+			MinBlock: head.Number.Uint64() + 1,
+			MaxBlock: head.Number.Uint64() + 1,
+		}
+	)
+	wallet, err := s.accountManager.Find(account)
+	if err != nil {
+		return err
+	}
+	for _, entryPoint := range s.config.UserPool.EntryPoints {
+		if len(ops[entryPoint]) == 0 {
+			continue
+		}
+		data, err := userpool.PackHandleOps(ops[entryPoint], account.Address)
+		if err != nil {
+			return err
+		}
+		// The bundler is compensated by the operations, it can't pay more than
+		// the least paying one does
+		var (
+			gas    uint64
+			feeCap = ops[entryPoint][0].MaxFeePerGas
+			tip    = ops[entryPoint][0].MaxPriorityFeePerGas
+		)
+		for _, op := range ops[entryPoint] {
+			gas += op.GasLimit()
+			if op.MaxFeePerGas.Cmp(feeCap) < 0 {
+				feeCap = op.MaxFeePerGas
+			}
+			if op.MaxPriorityFeePerGas.Cmp(tip) < 0 {
+				tip = op.MaxPriorityFeePerGas
+			}
+		}
+		tx, err := wallet.SignTx(account, types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			To:        &entryPoint,
+			Data:      data,
+		}), chainID)
+		if err != nil {
+			return err
+		}
+		bundle.Txs = append(bundle.Txs, tx)
+		nonce++
+	}
+	if len(bundle.Txs) == 0 {
+		return nil
+	}
+	if err := s.txPool.AddBundle(bundle); err != nil && !errors.Is(err, txpool.ErrAlreadyKnown) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Error codes and messages the server uses to reject oversized batches.
+const (
+	errcodeInvalidRequest   = -32600
+	errcodeResponseTooLarge = -32003
+	errMsgBatchTooLarge     = "batch too large"
+	errMsgResponseTooLarge  = "response too large"
+)
+
+// errBatchPending is returned by the results of calls which haven't been executed.
+var errBatchPending = errors.New("batch not executed")
+
+// Batch queues typed calls to be sent to the server in as few JSON-RPC batches as
+// possible. Calls are queued by the methods mirroring those of Client, and their
+// results become available once Execute returns.
+//
+// Batches exceeding the server's item or response size limits are split and the
+// rejected calls retried, so a batch may be of any size. The item limit learned
+// this way is remembered by the client for later batches.
+type Batch struct {
+	ec    *Client
+	calls []*batchCall
+}
+
+// batchCall is a queued call of a batch.
+type batchCall struct {
+	method string
+	args   []interface{}
+	result json.RawMessage
+	err    error
+	done   bool                      // set when the server responded
+	finish func(ctx context.Context) // decodes the response into the typed result
+}
+
+// BatchResult is the typed result of a call queued in a batch.
+type BatchResult[T any] struct {
+	value T
+	err   error
+}
+
+// Result returns the result of the call, or the error it failed with.
+func (r *BatchResult[T]) Result() (T, error) {
+	return r.value, r.err
+}
+
+// NewBatch creates an empty batch of calls.
+func (ec *Client) NewBatch() *Batch {
+	return &Batch{ec: ec}
+}
+
+// Len returns the number of queued calls.
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Execute sends all queued calls and fills in their results, emptying the batch.
+//
+// In contrast to the methods of Client, Execute only returns errors that prevented
+// the calls from being sent. Errors of individual calls are reported through their
+// results. Calls which weren't answered when such an error occurred fail with it.
+func (b *Batch) Execute(ctx context.Context) error {
+	calls := b.calls
+	b.calls = nil
+
+	err := b.ec.sendBatch(ctx, calls)
+	for _, call := range calls {
+		if !call.done {
+			call.err = err
+		}
+		call.finish(ctx)
+	}
+	return err
+}
+
+// sendBatch sends the calls in batches of at most the server's item limit, halving
+// the batch size whenever the server rejects a batch or its response as too large.
+func (ec *Client) sendBatch(ctx context.Context, calls []*batchCall) error {
+	limit := int(ec.batchLimit.Load())
+	for len(calls) > 0 {
+		n := len(calls)
+		if limit > 0 && n > limit {
+			n = limit
+		}
+		elems := make([]rpc.BatchElem, n)
+		for i, call := range calls[:n] {
+			elems[i] = rpc.BatchElem{Method: call.method, Args: call.args, Result: &call.result}
+		}
+		if err := ec.c.BatchCallContext(ctx, elems); err != nil {
+			return err
+		}
+		if n > 1 && batchTooLarge(elems) {
+			limit = n / 2
+			ec.batchLimit.Store(int64(limit))
+			continue
+		}
+		// Calls cut off by the response size limit are retried, unless the call
+		// was sent on its own and is thus too large by itself.
+		var retry []*batchCall
+		for i, call := range calls[:n] {
+			if n > 1 && responseTooLarge(elems[i].Error) {
+				retry = append(retry, call)
+				continue
+			}
+			call.err, call.done = elems[i].Error, true
+		}
+		if len(retry) == n {
+			limit = n / 2
+		}
+		calls = append(retry, calls[n:]...)
+	}
+	return nil
+}
+
+// batchTooLarge reports whether the server rejected the batch for having too many
+// items.
+func batchTooLarge(elems []rpc.BatchElem) bool {
+	for _, elem := range elems {
+		if hasErrorCode(elem.Error, errcodeInvalidRequest) && elem.Error.Error() == errMsgBatchTooLarge {
+			return true
+		}
+	}
+	return false
+}
+
+// responseTooLarge reports whether the server cut off the response of a call for
+// exceeding the size limit. The error code alone isn't conclusive, it's shared
+// with other errors such as rejected transactions.
+func responseTooLarge(err error) bool {
+	return hasErrorCode(err, errcodeResponseTooLarge) && err.Error() == errMsgResponseTooLarge
+}
+
+// hasErrorCode reports whether err is a JSON-RPC error with the given code.
+func hasErrorCode(err error, code int) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == code
+}
+
+// queueCall adds a call to the batch, whose response is decoded by the given
+// function once the batch is executed.
+func queueCall[T any](b *Batch, decode func(ctx context.Context, raw json.RawMessage) (T, error), method string, args ...interface{}) *BatchResult[T] {
+	res := &BatchResult[T]{err: errBatchPending}
+	call := &batchCall{method: method, args: args}
+	call.finish = func(ctx context.Context) {
+		if call.err != nil {
+			res.err = call.err
+			return
+		}
+		res.value, res.err = decode(ctx, call.result)
+	}
+	b.calls = append(b.calls, call)
+	return res
+}
+
+// decodeResult decodes a response into T.
+func decodeResult[T any](ctx context.Context, raw json.RawMessage) (T, error) {
+	var v T
+	err := json.Unmarshal(raw, &v)
+	return v, err
+}
+
+// decodeFound decodes a response into *T, failing with ethereum.NotFound if it is
+// null.
+func decodeFound[T any](ctx context.Context, raw json.RawMessage) (*T, error) {
+	var v *T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ethereum.NotFound
+	}
+	return v, nil
+}
+
+// BatchCall queues a call of any method to the batch, whose result is decoded
+// into T.
+func BatchCall[T any](b *Batch, method string, args ...interface{}) *BatchResult[T] {
+	return queueCall(b, decodeResult[T], method, args...)
+}
+
+// BlockByHash queues a call of Client.BlockByHash.
+//
+// Blocks with uncles take an additional request each, made when the batch is
+// executed.
+func (b *Batch) BlockByHash(hash common.Hash) *BatchResult[*types.Block] {
+	return queueCall(b, b.ec.decodeBlock, "eth_getBlockByHash", hash, true)
+}
+
+// BlockByNumber queues a call of Client.BlockByNumber.
+//
+// Blocks with uncles take an additional request each, made when the batch is
+// executed.
+func (b *Batch) BlockByNumber(number *big.Int) *BatchResult[*types.Block] {
+	return queueCall(b, b.ec.decodeBlock, "eth_getBlockByNumber", toBlockNumArg(number), true)
+}
+
+// HeaderByHash queues a call of Client.HeaderByHash.
+func (b *Batch) HeaderByHash(hash common.Hash) *BatchResult[*types.Header] {
+	return queueCall(b, decodeFound[types.Header], "eth_getBlockByHash", hash, false)
+}
+
+// HeaderByNumber queues a call of Client.HeaderByNumber.
+func (b *Batch) HeaderByNumber(number *big.Int) *BatchResult[*types.Header] {
+	return queueCall(b, decodeFound[types.Header], "eth_getBlockByNumber", toBlockNumArg(number), false)
+}
+
+// TransactionReceipt queues a call of Client.TransactionReceipt.
+func (b *Batch) TransactionReceipt(txHash common.Hash) *BatchResult[*types.Receipt] {
+	return queueCall(b, decodeFound[types.Receipt], "eth_getTransactionReceipt", txHash)
+}
+
+// BlockReceipts queues a call of Client.BlockReceipts.
+func (b *Batch) BlockReceipts(blockNrOrHash rpc.BlockNumberOrHash) *BatchResult[[]*types.Receipt] {
+	decode := func(ctx context.Context, raw json.RawMessage) ([]*types.Receipt, error) {
+		r, err := decodeResult[[]*types.Receipt](ctx, raw)
+		if err == nil && r == nil {
+			return nil, ethereum.NotFound
+		}
+		return r, err
+	}
+	return queueCall(b, decode, "eth_getBlockReceipts", blockNrOrHash.String())
+}
+
+// BalanceAt queues a call of Client.BalanceAt.
+func (b *Batch) BalanceAt(account common.Address, blockNumber *big.Int) *BatchResult[*big.Int] {
+	decode := func(ctx context.Context, raw json.RawMessage) (*big.Int, error) {
+		result, err := decodeResult[hexutil.Big](ctx, raw)
+		return (*big.Int)(&result), err
+	}
+	return queueCall(b, decode, "eth_getBalance", account, toBlockNumArg(blockNumber))
+}
+
+// NonceAt queues a call of Client.NonceAt.
+func (b *Batch) NonceAt(account common.Address, blockNumber *big.Int) *BatchResult[uint64] {
+	decode := func(ctx context.Context, raw json.RawMessage) (uint64, error) {
+		result, err := decodeResult[hexutil.Uint64](ctx, raw)
+		return uint64(result), err
+	}
+	return queueCall(b, decode, "eth_getTransactionCount", account, toBlockNumArg(blockNumber))
+}
+
+// CodeAt queues a call of Client.CodeAt.
+func (b *Batch) CodeAt(account common.Address, blockNumber *big.Int) *BatchResult[[]byte] {
+	return queueCall(b, decodeBytes, "eth_getCode", account, toBlockNumArg(blockNumber))
+}
+
+// StorageAt queues a call of Client.StorageAt.
+func (b *Batch) StorageAt(account common.Address, key common.Hash, blockNumber *big.Int) *BatchResult[[]byte] {
+	return queueCall(b, decodeBytes, "eth_getStorageAt", account, key, toBlockNumArg(blockNumber))
+}
+
+// decodeBytes decodes a hex encoded byte slice.
+func decodeBytes(ctx context.Context, raw json.RawMessage) ([]byte, error) {
+	result, err := decodeResult[hexutil.Bytes](ctx, raw)
+	return result, err
+}
+
+// HeadL1Origin queues a call of Client.HeadL1Origin.
+func (b *Batch) HeadL1Origin() *BatchResult[*rawdb.L1Origin] {
+	return queueCall(b, decodeResult[*rawdb.L1Origin], "taiko_headL1Origin")
+}
+
+// L1OriginByID queues a call of Client.L1OriginByID.
+func (b *Batch) L1OriginByID(blockID *big.Int) *BatchResult[*rawdb.L1Origin] {
+	return queueCall(b, decodeResult[*rawdb.L1Origin], "taiko_l1OriginByID", hexutil.EncodeBig(blockID))
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestBatch(t *testing.T) {
+	backend, chain := newTestBackend(t)
+	defer backend.Close()
+	server, err := backend.RPCHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name      string
+		itemLimit int
+		sizeLimit int
+	}{
+		{"unlimited", 0, 0},
+		{"item limit", 2, 0},
+		{"response limit", 0, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			server.SetBatchLimits(test.itemLimit, test.sizeLimit)
+			ec := NewClient(backend.Attach())
+			defer ec.Close()
+
+			var (
+				batch   = ec.NewBatch()
+				headers []*BatchResult[*types.Header]
+			)
+			for i := range chain {
+				headers = append(headers, batch.HeaderByNumber(big.NewInt(int64(i))))
+			}
+			var (
+				missing = batch.HeaderByNumber(big.NewInt(100))
+				block   = batch.BlockByNumber(big.NewInt(2))
+				receipt = batch.TransactionReceipt(testTx1.Hash())
+				balance = batch.BalanceAt(testAddr, big.NewInt(0))
+				nonce   = batch.NonceAt(testAddr, nil)
+			)
+			if _, err := balance.Result(); err != errBatchPending {
+				t.Fatalf("result available before execution: %v", err)
+			}
+			if err := batch.Execute(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if batch.Len() != 0 {
+				t.Fatalf("batch not emptied: %d calls left", batch.Len())
+			}
+			for i, res := range headers {
+				header, err := res.Result()
+				if err != nil {
+					t.Fatalf("header %d: %v", i, err)
+				}
+				if header.Hash() != chain[i].Hash() {
+					t.Fatalf("header %d: wrong hash %x", i, header.Hash())
+				}
+			}
+			if _, err := missing.Result(); !errors.Is(err, ethereum.NotFound) {
+				t.Fatalf("missing header: wrong error %v", err)
+			}
+			if b, err := block.Result(); err != nil || b.Hash() != chain[2].Hash() || len(b.Transactions()) != 2 {
+				t.Fatalf("wrong block: %v", err)
+			}
+			if r, err := receipt.Result(); err != nil || r.TxHash != testTx1.Hash() {
+				t.Fatalf("wrong receipt: %v", err)
+			}
+			if b, err := balance.Result(); err != nil || b.Cmp(testBalance) != 0 {
+				t.Fatalf("wrong balance %v: %v", b, err)
+			}
+			if n, err := nonce.Result(); err != nil || n != 2 {
+				t.Fatalf("wrong nonce %d: %v", n, err)
+			}
+			if limit := ec.batchLimit.Load(); test.itemLimit > 0 && (limit == 0 || limit > int64(test.itemLimit)) {
+				t.Fatalf("item limit not learned: %d", limit)
+			}
+		})
+	}
+}
+
+// rejectedError mimics an error sharing the code of oversized responses, like the
+// transaction rejections of EIP-1474.
+type rejectedError struct{}
+
+func (rejectedError) Error() string  { return "transaction rejected" }
+func (rejectedError) ErrorCode() int { return errcodeResponseTooLarge }
+
+type rejectingService struct{ calls int }
+
+func (s *rejectingService) GetBalance(common.Address, string) (*hexutil.Big, error) {
+	s.calls++
+	return nil, rejectedError{}
+}
+
+// Tests that errors sharing the code of oversized responses aren't retried.
+func TestBatchRejectedNotRetried(t *testing.T) {
+	var (
+		service = new(rejectingService)
+		server  = rpc.NewServer()
+	)
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	batch := ec.NewBatch()
+	results := []*BatchResult[*big.Int]{batch.BalanceAt(testAddr, nil), batch.BalanceAt(testAddr, nil)}
+	if err := batch.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if _, err := res.Result(); err == nil || err.Error() != "transaction rejected" {
+			t.Fatalf("call %d: wrong error %v", i, err)
+		}
+	}
+	if service.calls != 2 {
+		t.Fatalf("rejected calls retried: %d calls served", service.calls)
+	}
+	if limit := ec.batchLimit.Load(); limit != 0 {
+		t.Fatalf("batch limit lowered to %d", limit)
+	}
+}
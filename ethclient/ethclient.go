@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -34,6 +35,20 @@ import (
 // Client defines typed wrappers for the Ethereum RPC API.
 type Client struct {
 	c *rpc.Client
+
+	durable bool                  // re-establish subscriptions after connection loss
+	onGap   func(SubscriptionGap) // notified of notifications missed while resubscribing
+
+	batchLimit atomic.Int64 // batch item limit of the server, learned from rejected batches
+}
+
+// SubscriptionGap describes the notifications a durable subscription may have
+// missed while it was being re-established. LastSeen is the block number of the
+// last notification delivered before the connection was lost, nil if there was
+// none, and FirstNew the block number of the first one delivered afterwards.
+type SubscriptionGap struct {
+	LastSeen *big.Int
+	FirstNew *big.Int
 }
 
 // Dial connects a client to the given URL.
@@ -50,9 +65,27 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 	return NewClient(c), nil
 }
 
+// DialEndpoints connects a client to multiple nodes, spreading requests across the
+// healthy ones and failing over between them. See rpc.DialEndpoints for details.
+func DialEndpoints(ctx context.Context, rawurls []string, options ...rpc.ClientOption) (*Client, error) {
+	c, err := rpc.DialEndpoints(ctx, rawurls, options...)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
 // NewClient creates a client that uses the given RPC client.
 func NewClient(c *rpc.Client) *Client {
-	return &Client{c}
+	return &Client{c: c}
+}
+
+// NewDurableClient creates a client that uses the given RPC client and re-issues
+// its subscriptions whenever the connection is re-established. The onGap callback,
+// if non-nil, is invoked on every resubscription with the range of blocks whose
+// notifications may have been missed, so that callers can backfill them.
+func NewDurableClient(c *rpc.Client, onGap func(SubscriptionGap)) *Client {
+	return &Client{c: c, durable: true, onGap: onGap}
 }
 
 // Close closes the underlying RPC connection.
@@ -131,7 +164,12 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 	if err != nil {
 		return nil, err
 	}
+	return ec.decodeBlock(ctx, raw)
+}
 
+// decodeBlock decodes a full block returned by the API, loading its uncles if it
+// has any.
+func (ec *Client) decodeBlock(ctx context.Context, raw json.RawMessage) (*types.Block, error) {
 	// Decode header and transactions.
 	var head *types.Header
 	if err := json.Unmarshal(raw, &head); err != nil {
@@ -335,16 +373,50 @@ func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, err
 // SubscribeNewHead subscribes to notifications about the current blockchain head
 // on the given channel.
 func (ec *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
-	sub, err := ec.c.EthSubscribe(ctx, ch, "newHeads")
+	return ec.subscribe(ctx, ch, headNumber, "newHeads")
+}
+
+// subscribe registers an eth subscription, which is durable if the client is.
+// The number function extracts the block number of a notification.
+func (ec *Client) subscribe(ctx context.Context, ch interface{}, number func(interface{}) *big.Int, args ...interface{}) (ethereum.Subscription, error) {
+	if !ec.durable {
+		sub, err := ec.c.EthSubscribe(ctx, ch, args...)
+		if err != nil {
+			// Defensively prefer returning nil interface explicitly on error-path, instead
+			// of letting default golang behavior wrap it with non-nil interface that stores
+			// nil concrete type value.
+			return nil, err
+		}
+		return sub, nil
+	}
+	var hook rpc.ResubscribeHook
+	if ec.onGap != nil {
+		hook = func(last, first interface{}) {
+			gap := SubscriptionGap{FirstNew: number(first)}
+			if last != nil {
+				gap.LastSeen = number(last)
+			}
+			ec.onGap(gap)
+		}
+	}
+	sub, err := ec.c.SubscribeDurable(ctx, "eth", ch, hook, args...)
 	if err != nil {
-		// Defensively prefer returning nil interface explicitly on error-path, instead
-		// of letting default golang behavior wrap it with non-nil interface that stores
-		// nil concrete type value.
 		return nil, err
 	}
 	return sub, nil
 }
 
+func headNumber(v interface{}) *big.Int {
+	if head := v.(*types.Header); head != nil && head.Number != nil {
+		return new(big.Int).Set(head.Number)
+	}
+	return nil
+}
+
+func logNumber(v interface{}) *big.Int {
+	return new(big.Int).SetUint64(v.(types.Log).BlockNumber)
+}
+
 // State Access
 
 // NetworkID returns the network ID for this client.
@@ -439,14 +511,7 @@ func (ec *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuer
 	if err != nil {
 		return nil, err
 	}
-	sub, err := ec.c.EthSubscribe(ctx, ch, "logs", arg)
-	if err != nil {
-		// Defensively prefer returning nil interface explicitly on error-path, instead
-		// of letting default golang behavior wrap it with non-nil interface that stores
-		// nil concrete type value.
-		return nil, err
-	}
-	return sub, nil
+	return ec.subscribe(ctx, ch, logNumber, "logs", arg)
 }
 
 func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
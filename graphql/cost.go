@@ -0,0 +1,71 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// Resolving some fields is considerably more expensive than reading chain data.
+// Every query is granted a cost budget which those fields draw from, failing
+// once it's exhausted.
+const (
+	maxQueryCost  = 1000 // Cost budget of a single query
+	maxQueryDepth = 32   // Maximum nesting depth of a query
+
+	costL1Origin        = 1   // Database lookup
+	costFeeDistribution = 10  // Receipts retrieval
+	costCallTrace       = 100 // Transaction re-execution
+)
+
+var (
+	errQueryCostExceeded = errors.New("query cost limit exceeded")
+	errQueryNotBudgeted  = errors.New("field is not available in subscriptions")
+)
+
+type queryBudgetKey struct{}
+
+// queryBudget is the remaining cost budget of a query. It's shared by all the
+// resolvers of the query, which may run concurrently.
+type queryBudget struct {
+	left atomic.Int64
+}
+
+// withQueryBudget returns a context carrying a fresh cost budget.
+func withQueryBudget(ctx context.Context, cost int64) context.Context {
+	budget := new(queryBudget)
+	budget.left.Store(cost)
+	return context.WithValue(ctx, queryBudgetKey{}, budget)
+}
+
+// chargeQuery draws the given cost from the budget of the query. Subscriptions
+// run without a budget, so only cheap fields can be charged outside of one.
+func chargeQuery(ctx context.Context, cost int64) error {
+	budget, ok := ctx.Value(queryBudgetKey{}).(*queryBudget)
+	if !ok {
+		if cost >= costCallTrace {
+			return errQueryNotBudgeted
+		}
+		return nil
+	}
+	if budget.left.Add(-cost) < 0 {
+		return errQueryCostExceeded
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"io"
 	"math/big"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -40,6 +41,8 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -483,3 +486,243 @@ func newGQLService(t *testing.T, stack *node.Node, shanghai bool, gspec *core.Ge
 	}
 	return handler, chain
 }
+
+func TestGraphQLSubscriptions(t *testing.T) {
+	// Other tests switch the shared config to post-merge, undo it
+	config := *params.AllEthashProtocolChanges
+	config.TerminalTotalDifficulty, config.ShanghaiTime = nil, nil
+
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		signer  = types.LatestSigner(&config)
+		genesis = &core.Genesis{
+			Config:     &config,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc:      types.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	stack := createNode(t)
+	defer stack.Close()
+
+	ethBackend, err := eth.New(stack, &ethconfig.Config{Genesis: genesis, NetworkId: 1337, TrieTimeout: 60 * time.Minute})
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	filterSystem := filters.NewFilterSystem(ethBackend.APIBackend, filters.Config{})
+	if _, err := newHandler(stack, ethBackend.APIBackend, filterSystem, []string{}, []string{}); err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	url := "ws" + strings.TrimPrefix(stack.HTTPEndpoint(), "http") + "/graphql"
+	conn, _, err := (&websocket.Dialer{Subprotocols: []string{wsSubprotocol}}).Dial(url, nil)
+	if err != nil {
+		t.Fatalf("could not dial graphql websocket: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	send := func(msg string) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("failed to send message: %v", err)
+		}
+	}
+	read := func() wsMessage {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+		return msg
+	}
+	send(`{"type":"connection_init"}`)
+	if msg := read(); msg.Type != wsConnectionAck {
+		t.Fatalf("expected connection ack, got %v", msg.Type)
+	}
+	// Queries are answered over the websocket too
+	send(`{"id":"q","type":"subscribe","payload":{"query":"{ block(number: 0) { number } }"}}`)
+	if msg := read(); msg.ID != "q" || msg.Type != wsNext || string(msg.Payload) != `{"data":{"block":{"number":"0x0"}}}` {
+		t.Fatalf("unexpected query response: %v %s", msg.Type, msg.Payload)
+	}
+	if msg := read(); msg.ID != "q" || msg.Type != wsComplete {
+		t.Fatalf("expected query completion, got %v", msg.Type)
+	}
+	send(`{"id":"blocks","type":"subscribe","payload":{"query":"subscription { newBlocks { number } }"}}`)
+	send(`{"id":"logs","type":"subscribe","payload":{"query":"subscription { logs(filter: {}) { data transaction { hash } } }"}}`)
+	time.Sleep(500 * time.Millisecond)
+
+	// Import two blocks, the first one creating a contract emitting a log
+	var creation common.Hash
+	chain, _ := core.GenerateChain(&config, ethBackend.BlockChain().Genesis(), ethash.NewFaker(), ethBackend.ChainDb(), 2, func(i int, gen *core.BlockGen) {
+		if i == 0 {
+			// PUSH1 0x2a PUSH1 0 MSTORE8 PUSH1 1 PUSH1 0 LOG0 STOP
+			tx, _ := types.SignTx(types.NewContractCreation(0, big.NewInt(0), 100000, gen.BaseFee(), common.FromHex("602a60005360016000a000")), signer, key)
+			gen.AddTx(tx)
+			creation = tx.Hash()
+		}
+	})
+	if _, err := ethBackend.BlockChain().InsertChain(chain); err != nil {
+		t.Fatalf("could not import blocks: %v", err)
+	}
+	var (
+		blocks []string
+		logs   []string
+	)
+	for len(blocks) < 2 || len(logs) < 1 {
+		msg := read()
+		if msg.Type != wsNext {
+			t.Fatalf("unexpected message type %v: %s", msg.Type, msg.Payload)
+		}
+		switch msg.ID {
+		case "blocks":
+			blocks = append(blocks, string(msg.Payload))
+		case "logs":
+			logs = append(logs, string(msg.Payload))
+		}
+	}
+	if blocks[0] != `{"data":{"newBlocks":{"number":"0x1"}}}` || blocks[1] != `{"data":{"newBlocks":{"number":"0x2"}}}` {
+		t.Errorf("unexpected blocks: %v", blocks)
+	}
+	if want := fmt.Sprintf(`{"data":{"logs":{"data":"0x2a","transaction":{"hash":"%s"}}}}`, creation.Hex()); logs[0] != want {
+		t.Errorf("unexpected log: have %s, want %s", logs[0], want)
+	}
+	// Completing a subscription by the client is not acknowledged, but a
+	// subsequent subscription with the same id is accepted
+	send(`{"id":"blocks","type":"complete"}`)
+	send(`{"id":"blocks","type":"subscribe","payload":{"query":"{ block { number } }"}}`)
+	if msg := read(); msg.ID != "blocks" || string(msg.Payload) != `{"data":{"block":{"number":"0x2"}}}` {
+		t.Fatalf("unexpected response: %v %s", msg.Type, msg.Payload)
+	}
+}
+
+// Tests that WebSocket upgrades are subject to the virtual hosts and queries sent
+// over WebSocket to the request timeout, like plain HTTP requests.
+func TestGraphQLWebsocketLimits(t *testing.T) {
+	stack := createNode(t)
+	defer stack.Close()
+
+	ethBackend, err := eth.New(stack, &ethconfig.Config{Genesis: &core.Genesis{Config: params.AllEthashProtocolChanges}, NetworkId: 1337, TrieTimeout: 60 * time.Minute})
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	filterSystem := filters.NewFilterSystem(ethBackend.APIBackend, filters.Config{})
+	h, err := newHandler(stack, ethBackend.APIBackend, filterSystem, []string{}, []string{"allowed.example"})
+	if err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	url := "ws" + strings.TrimPrefix(stack.HTTPEndpoint(), "http") + "/graphql"
+	dialer := &websocket.Dialer{Subprotocols: []string{wsSubprotocol}}
+	if _, resp, err := dialer.Dial(url, http.Header{"Host": []string{"denied.example"}}); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("upgrade from denied host not rejected: %v", err)
+	}
+	conn, _, err := dialer.Dial(url, http.Header{"Host": []string{"allowed.example"}})
+	if err != nil {
+		t.Fatalf("upgrade from allowed host rejected: %v", err)
+	}
+	conn.Close()
+
+	// Serve the operations of a connection whose request timed out already
+	sub, err := graphql.ParseSchema(subscriptionSchema, newSubscriptionResolver(&Resolver{ethBackend.APIBackend, filterSystem}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := newWSHandler(h.Schema, sub, nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithDeadline(r.Context(), time.Now())
+		defer cancel()
+		ws.ServeHTTP(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	conn, _, err = dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("could not dial graphql websocket: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	for _, msg := range []string{`{"type":"connection_init"}`, `{"id":"q","type":"subscribe","payload":{"query":"{ block { number } }"}}`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("failed to send message: %v", err)
+		}
+	}
+	var msg wsMessage
+	for msg.ID != "q" {
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+	}
+	if msg.Type != wsNext || string(msg.Payload) != `{"errors":[{"message":"request timed out"}]}` {
+		t.Fatalf("unexpected query response: %v %s", msg.Type, msg.Payload)
+	}
+}
+
+func TestGraphQLCallTrace(t *testing.T) {
+	// Other tests switch the shared config to post-merge, undo it
+	config := *params.AllEthashProtocolChanges
+	config.TerminalTotalDifficulty, config.ShanghaiTime = nil, nil
+
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		dadStr  = "0x0000000000000000000000000000000000000dad"
+		dad     = common.HexToAddress(dadStr)
+		genesis = &core.Genesis{
+			Config:     &config,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc: types.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				dad: {
+					// LOG0(0, 0), LOG0(0, 0), RETURN(0, 0)
+					Code:    common.Hex2Bytes("60006000a060006000a060006000f3"),
+					Nonce:   0,
+					Balance: big.NewInt(0),
+				},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		stack  = createNode(t)
+	)
+	defer stack.Close()
+
+	handler, _ := newGQLService(t, stack, false, genesis, 1, func(i int, gen *core.BlockGen) {
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{To: &dad, Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee)})
+		gen.AddTx(tx)
+	})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	// Trace the transaction, the Taiko specific fields are empty on other chains
+	res := handler.Schema.Exec(withQueryBudget(context.Background(), maxQueryCost), "{ block { l1Origin { blockID } feeDistribution { treasuryFee } transactions { isAnchor callTrace { type from to gasUsed input output error calls { type } } } } }", "", nil)
+	if res.Errors != nil {
+		t.Fatalf("failed to execute query: %v", res.Errors)
+	}
+	have, err := json.Marshal(res.Data)
+	if err != nil {
+		t.Fatalf("failed to encode graphql response: %v", err)
+	}
+	want := fmt.Sprintf(`{"block":{"l1Origin":null,"feeDistribution":null,"transactions":[{"isAnchor":false,"callTrace":{"type":"CALL","from":"%s","to":"%s","gasUsed":"0x5508","input":"0x","output":"0x","error":null,"calls":[]}}]}}`, strings.ToLower(addr.Hex()), dadStr)
+	if string(have) != want {
+		t.Errorf("response mismatch.\nExpected:\n%s\nGot:\n%s\n", want, have)
+	}
+	// Tracing is not available without a query budget
+	res = handler.Schema.Exec(context.Background(), "{ block { transactions { callTrace { type } } } }", "", nil)
+	if len(res.Errors) == 0 || res.Errors[0].Message != errQueryNotBudgeted.Error() {
+		t.Errorf("unexpected errors without budget: %v", res.Errors)
+	}
+	// Exhaust the budget by tracing the transaction repeatedly
+	var fields []string
+	for i := 0; i <= maxQueryCost/costCallTrace; i++ {
+		fields = append(fields, fmt.Sprintf("t%d: callTrace { type }", i))
+	}
+	res = handler.Schema.Exec(withQueryBudget(context.Background(), maxQueryCost), "{ block { transactions { "+strings.Join(fields, " ")+" } } }", "", nil)
+	if len(res.Errors) == 0 || res.Errors[0].Message != errQueryCostExceeded.Error() {
+		t.Errorf("unexpected errors when exceeding the budget: %v", res.Errors)
+	}
+}
@@ -17,6 +17,39 @@
 package graphql
 
 const schema string = `
+    schema {
+        query: Query
+        mutation: Mutation
+    }
+` + schemaTypes
+
+// subscriptionSchema is the schema served over WebSocket. Its root type is
+// resolved by a separate resolver, since the subscription fields share their
+// names with query fields.
+const subscriptionSchema string = `
+    schema {
+        query: SubscriptionQuery
+        subscription: Subscription
+    }
+
+    # SubscriptionQuery is the mandatory query root of the subscription schema.
+    # Regular queries are executed against the main schema.
+    type SubscriptionQuery {
+        # ChainID returns the current chain ID for transaction replay protection.
+        chainID: BigInt!
+    }
+
+    type Subscription {
+        # NewBlocks fires for every new head block of the canonical chain.
+        newBlocks: Block!
+        # PendingTransactions fires for every transaction entering the transaction pool.
+        pendingTransactions: Transaction!
+        # Logs fires for every log of newly imported blocks matching the filter.
+        logs(filter: FilterCriteria!): Log!
+    }
+` + schemaTypes
+
+const schemaTypes string = `
     # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
     scalar Bytes32
     # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
@@ -33,11 +66,6 @@ const schema string = `
     # 0x-prefixed hexadecimal.
     scalar Long
 
-    schema {
-        query: Query
-        mutation: Mutation
-    }
-
     # Account is an Ethereum account at a particular block.
     type Account {
         # Address is the address owning the account.
@@ -170,6 +198,65 @@ const schema string = `
         rawReceipt: Bytes!
         # BlobVersionedHashes is a set of hash outputs from the blobs in the transaction.
         blobVersionedHashes: [Bytes32!]
+        # IsAnchor is true if this is the anchor transaction of a Taiko block.
+        isAnchor: Boolean!
+        # CallTrace is the call tree of the transaction, produced by re-executing
+        # it with the call tracer. If the transaction is pending, this field will
+        # be null. Tracing is expensive and counts heavily against the query cost.
+        callTrace: CallFrame
+    }
+
+    # CallFrame is a single call within the call trace of a transaction.
+    type CallFrame {
+        # Type is the kind of the call, e.g. CALL, DELEGATECALL or CREATE.
+        type: String!
+        # From is the account making the call.
+        from: Address!
+        # To is the account called. It's null if a contract creation failed.
+        to: Address
+        # Value is the value, in wei, transferred by the call.
+        value: BigInt
+        # Gas is the amount of gas provided to the call.
+        gas: Long!
+        # GasUsed is the amount of gas used by the call.
+        gasUsed: Long!
+        # Input is the data sent to the callee.
+        input: Bytes!
+        # Output is the data returned by the callee.
+        output: Bytes!
+        # Error is the error the call failed with, if any.
+        error: String
+        # RevertReason is the decoded reason of a reverted call, if any.
+        revertReason: String
+        # Calls are the calls made by this call.
+        calls: [CallFrame!]!
+    }
+
+    # L1Origin is the L1 block a Taiko L2 block was proposed in.
+    type L1Origin {
+        # BlockID is the number of the L2 block.
+        blockID: BigInt!
+        # L2BlockHash is the hash of the L2 block.
+        l2BlockHash: Bytes32!
+        # L1BlockHeight is the number of the L1 block.
+        l1BlockHeight: BigInt!
+        # L1BlockHash is the hash of the L1 block.
+        l1BlockHash: Bytes32!
+    }
+
+    # FeeDistribution is the split of the base fees of a Taiko block between the
+    # treasury and the block's coinbase.
+    type FeeDistribution {
+        # Treasury is the address receiving the non-shared part of the base fees.
+        treasury: Address!
+        # BasefeeSharingPctg is the percentage of the base fees paid to the coinbase.
+        basefeeSharingPctg: Long!
+        # TotalBaseFee is the sum of the base fees paid by the transactions, in wei.
+        totalBaseFee: BigInt!
+        # TreasuryFee is the part of the base fees paid to the treasury, in wei.
+        treasuryFee: BigInt!
+        # CoinbaseFee is the part of the base fees paid to the coinbase, in wei.
+        coinbaseFee: BigInt!
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -279,6 +366,12 @@ const schema string = `
         blobGasUsed: Long
         # ExcessBlobGas is a running total of blob gas consumed in excess of the target, prior to the block.
         excessBlobGas: Long
+        # L1Origin is the L1 block this block was proposed in. If the chain is not
+        # a Taiko chain or the origin is unknown, this field will be null.
+        l1Origin: L1Origin
+        # FeeDistribution is the split of the base fees paid in this block. If the
+        # chain is not a Taiko chain, this field will be null.
+        feeDistribution: FeeDistribution
     }
 
     # CallData represents the data associated with a local contract call.
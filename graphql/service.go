@@ -87,6 +87,7 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	ctx = withQueryBudget(ctx, maxQueryCost)
 	response := h.Schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
 	if timer != nil {
 		timer.Stop()
@@ -116,13 +117,24 @@ func New(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterS
 func newHandler(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cors, vhosts []string) (*handler, error) {
 	q := Resolver{backend, filterSystem}
 
-	s, err := graphql.ParseSchema(schema, &q)
+	s, err := graphql.ParseSchema(schema, &q, graphql.MaxDepth(maxQueryDepth))
 	if err != nil {
 		return nil, err
 	}
 	h := handler{Schema: s}
 	handler := node.NewHTTPHandlerStack(h, cors, vhosts, nil)
 
+	// Subscriptions are served over WebSocket on the same endpoint. The
+	// upgrade requests bypass the HTTP stack, which can't hijack connections,
+	// but the virtual hosts are checked the same.
+	if filterSystem != nil {
+		sub, err := graphql.ParseSchema(subscriptionSchema, newSubscriptionResolver(&q), graphql.MaxDepth(maxQueryDepth))
+		if err != nil {
+			return nil, err
+		}
+		handler = withWebsocket(handler, node.NewVHostHandler(vhosts, newWSHandler(s, sub, cors)))
+	}
+
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui", GraphiQL{})
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui/", GraphiQL{})
 	stack.RegisterHandler("GraphQL", "/graphql", handler)
@@ -130,3 +142,15 @@ func newHandler(stack *node.Node, backend ethapi.Backend, filterSystem *filters.
 
 	return &h, nil
 }
+
+// withWebsocket routes websocket upgrade requests to the ws handler and all
+// other requests to the http one.
+func withWebsocket(plain, ws http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isWebsocket(r) {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		plain.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SubscriptionResolver is the root resolver of the subscription schema. It
+// streams the events of the filter system, wrapped into the regular resolvers.
+type SubscriptionResolver struct {
+	r      *Resolver
+	events *filters.EventSystem
+}
+
+// newSubscriptionResolver creates the subscription resolver on top of the
+// event system shared with the filter APIs of the query resolver's filter system.
+func newSubscriptionResolver(r *Resolver) *SubscriptionResolver {
+	return &SubscriptionResolver{
+		r:      r,
+		events: r.filterSystem.EventSystem(),
+	}
+}
+
+func (s *SubscriptionResolver) ChainID(ctx context.Context) (hexutil.Big, error) {
+	return s.r.ChainID(ctx)
+}
+
+func (s *SubscriptionResolver) NewBlocks(ctx context.Context) (<-chan *Block, error) {
+	var (
+		headers = make(chan *types.Header)
+		sub     = s.events.SubscribeNewHeads(headers)
+		out     = make(chan *Block)
+	)
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case header := <-headers:
+				numberOrHash := rpc.BlockNumberOrHashWithHash(header.Hash(), false)
+				block := &Block{
+					r:            s.r,
+					numberOrHash: &numberOrHash,
+					hash:         header.Hash(),
+					header:       header,
+				}
+				select {
+				case out <- block:
+				case <-ctx.Done():
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *SubscriptionResolver) PendingTransactions(ctx context.Context) (<-chan *Transaction, error) {
+	var (
+		txs = make(chan []*types.Transaction)
+		sub = s.events.SubscribePendingTxs(txs)
+		out = make(chan *Transaction)
+	)
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case batch := <-txs:
+				for _, tx := range batch {
+					select {
+					case out <- &Transaction{r: s.r, hash: tx.Hash(), tx: tx}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *SubscriptionResolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) (<-chan *Log, error) {
+	var crit ethereum.FilterQuery
+	if args.Filter.FromBlock != nil {
+		crit.FromBlock = new(big.Int).SetUint64(uint64(*args.Filter.FromBlock))
+	}
+	if args.Filter.ToBlock != nil {
+		crit.ToBlock = new(big.Int).SetUint64(uint64(*args.Filter.ToBlock))
+	}
+	if crit.FromBlock != nil && crit.ToBlock != nil && crit.FromBlock.Cmp(crit.ToBlock) > 0 {
+		return nil, errInvalidBlockRange
+	}
+	if args.Filter.Addresses != nil {
+		crit.Addresses = *args.Filter.Addresses
+	}
+	if args.Filter.Topics != nil {
+		crit.Topics = *args.Filter.Topics
+	}
+	var (
+		logs = make(chan []*types.Log)
+		out  = make(chan *Log)
+	)
+	sub, err := s.events.SubscribeLogs(crit, logs)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case batch := <-logs:
+				for _, log := range batch {
+					entry := &Log{
+						r:           s.r,
+						transaction: &Transaction{r: s.r, hash: log.TxHash},
+						log:         log,
+					}
+					select {
+					case out <- entry:
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
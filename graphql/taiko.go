@@ -0,0 +1,287 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native" // register the call tracer
+)
+
+const (
+	callTraceTimeout = 5 * time.Second // Maximum execution time of a traced transaction
+	callTraceReexec  = uint64(32)      // Maximum blocks re-executed to regenerate the state
+)
+
+var errTracingUnsupported = errors.New("call tracing is not supported by the backend")
+
+// L1Origin is the L1 origin of a Taiko L2 block.
+type L1Origin struct {
+	origin *rawdb.L1Origin
+}
+
+func (o *L1Origin) BlockID(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*o.origin.BlockID)
+}
+
+func (o *L1Origin) L2BlockHash(ctx context.Context) common.Hash {
+	return o.origin.L2BlockHash
+}
+
+func (o *L1Origin) L1BlockHeight(ctx context.Context) hexutil.Big {
+	if o.origin.L1BlockHeight == nil {
+		return hexutil.Big{}
+	}
+	return hexutil.Big(*o.origin.L1BlockHeight)
+}
+
+func (o *L1Origin) L1BlockHash(ctx context.Context) common.Hash {
+	return o.origin.L1BlockHash
+}
+
+// L1Origin returns the L1 origin of the block, or nil if the chain is not a
+// Taiko chain or the block wasn't inserted by the L2 driver.
+func (b *Block) L1Origin(ctx context.Context) (*L1Origin, error) {
+	if !b.r.backend.ChainConfig().Taiko {
+		return nil, nil
+	}
+	if err := chargeQuery(ctx, costL1Origin); err != nil {
+		return nil, err
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	origin, err := rawdb.ReadL1Origin(b.r.backend.ChainDb(), header.Number)
+	if err != nil || origin == nil {
+		return nil, err
+	}
+	// The origin is stored by number, make sure it belongs to this very block
+	if origin.L2BlockHash != header.Hash() {
+		return nil, nil
+	}
+	return &L1Origin{origin}, nil
+}
+
+// FeeDistribution is the split of the base fees of a Taiko block between the
+// treasury and the block's coinbase.
+type FeeDistribution struct {
+	treasury    common.Address
+	sharingPctg uint8
+	total       *big.Int
+	treasuryFee *big.Int
+	coinbaseFee *big.Int
+}
+
+func (f *FeeDistribution) Treasury(ctx context.Context) common.Address {
+	return f.treasury
+}
+
+func (f *FeeDistribution) BasefeeSharingPctg(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(f.sharingPctg)
+}
+
+func (f *FeeDistribution) TotalBaseFee(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*f.total)
+}
+
+func (f *FeeDistribution) TreasuryFee(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*f.treasuryFee)
+}
+
+func (f *FeeDistribution) CoinbaseFee(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*f.coinbaseFee)
+}
+
+// FeeDistribution returns how the base fees paid in the block were distributed,
+// or nil if the chain is not a Taiko chain or the block has no base fee.
+func (b *Block) FeeDistribution(ctx context.Context) (*FeeDistribution, error) {
+	config := b.r.backend.ChainConfig()
+	if !config.Taiko {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return nil, nil
+	}
+	if err := chargeQuery(ctx, costFeeDistribution); err != nil {
+		return nil, err
+	}
+	receipts, err := b.resolveReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dist := &FeeDistribution{
+		treasury:    core.TreasuryAddress(config),
+		total:       new(big.Int),
+		treasuryFee: new(big.Int),
+		coinbaseFee: new(big.Int),
+	}
+	if config.IsOntake(header.Number) {
+		dist.sharingPctg = core.DecodeOntakeExtraData(header.Extra)
+	}
+	// Mirror the state transition, which splits the fee of every transaction
+	// individually. The anchor transaction doesn't pay any base fee.
+	for i, receipt := range receipts {
+		if i == 0 {
+			continue
+		}
+		var (
+			fee      = new(big.Int).Mul(header.BaseFee, new(big.Int).SetUint64(receipt.GasUsed))
+			coinbase = new(big.Int).Div(new(big.Int).Mul(fee, big.NewInt(int64(dist.sharingPctg))), big.NewInt(100))
+		)
+		dist.total.Add(dist.total, fee)
+		dist.coinbaseFee.Add(dist.coinbaseFee, coinbase)
+		dist.treasuryFee.Add(dist.treasuryFee, fee.Sub(fee, coinbase))
+	}
+	return dist, nil
+}
+
+// IsAnchor returns whether the transaction is the anchor transaction of a
+// Taiko block, which is always the first one.
+func (t *Transaction) IsAnchor(ctx context.Context) bool {
+	tx, block := t.resolve(ctx)
+	if tx == nil || block == nil {
+		return false
+	}
+	return t.r.backend.ChainConfig().Taiko && t.index == 0
+}
+
+// callFrame is the output of the native call tracer.
+type callFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to"`
+	Value        *hexutil.Big    `json:"value"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output"`
+	Error        string          `json:"error"`
+	RevertReason string          `json:"revertReason"`
+	Calls        []*callFrame    `json:"calls"`
+}
+
+// CallFrame is a single call within the call trace of a transaction.
+type CallFrame struct {
+	frame *callFrame
+}
+
+func (c *CallFrame) Type(ctx context.Context) string {
+	return c.frame.Type
+}
+
+func (c *CallFrame) From(ctx context.Context) common.Address {
+	return c.frame.From
+}
+
+func (c *CallFrame) To(ctx context.Context) *common.Address {
+	return c.frame.To
+}
+
+func (c *CallFrame) Value(ctx context.Context) *hexutil.Big {
+	return c.frame.Value
+}
+
+func (c *CallFrame) Gas(ctx context.Context) hexutil.Uint64 {
+	return c.frame.Gas
+}
+
+func (c *CallFrame) GasUsed(ctx context.Context) hexutil.Uint64 {
+	return c.frame.GasUsed
+}
+
+func (c *CallFrame) Input(ctx context.Context) hexutil.Bytes {
+	return c.frame.Input
+}
+
+func (c *CallFrame) Output(ctx context.Context) hexutil.Bytes {
+	return c.frame.Output
+}
+
+func (c *CallFrame) Error(ctx context.Context) *string {
+	if c.frame.Error == "" {
+		return nil
+	}
+	return &c.frame.Error
+}
+
+func (c *CallFrame) RevertReason(ctx context.Context) *string {
+	if c.frame.RevertReason == "" {
+		return nil
+	}
+	return &c.frame.RevertReason
+}
+
+func (c *CallFrame) Calls(ctx context.Context) []*CallFrame {
+	ret := make([]*CallFrame, 0, len(c.frame.Calls))
+	for _, call := range c.frame.Calls {
+		ret = append(ret, &CallFrame{call})
+	}
+	return ret
+}
+
+// CallTrace re-executes the transaction with the native call tracer. Pending
+// transactions have no trace.
+func (t *Transaction) CallTrace(ctx context.Context) (*CallFrame, error) {
+	tx, block := t.resolve(ctx)
+	if tx == nil || block == nil {
+		return nil, nil
+	}
+	backend, ok := t.r.backend.(tracers.Backend)
+	if !ok {
+		return nil, errTracingUnsupported
+	}
+	if err := chargeQuery(ctx, costCallTrace); err != nil {
+		return nil, err
+	}
+	var (
+		tracer  = "callTracer"
+		timeout = callTraceTimeout.String()
+		reexec  = callTraceReexec
+	)
+	res, err := tracers.NewAPI(backend).TraceTransaction(ctx, t.hash, &tracers.TraceConfig{
+		Tracer:  &tracer,
+		Timeout: &timeout,
+		Reexec:  &reexec,
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := res.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected call tracer result %T", res)
+	}
+	frame := new(callFrame)
+	if err := json.Unmarshal(raw, frame); err != nil {
+		return nil, err
+	}
+	return &CallFrame{frame}, nil
+}
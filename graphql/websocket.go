@@ -0,0 +1,345 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	gqlErrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// The handler implements the graphql-transport-ws protocol as used by the
+// graphql-ws library: https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const wsSubprotocol = "graphql-transport-ws"
+
+const (
+	wsReadLimit        = 1024 * 1024
+	wsWriteTimeout     = 10 * time.Second
+	wsInitTimeout      = 10 * time.Second
+	wsMaxSubscriptions = 100
+)
+
+// Message types of the graphql-transport-ws protocol.
+const (
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+)
+
+// Close codes of the graphql-transport-ws protocol.
+const (
+	wsCloseBadRequest      = 4400
+	wsCloseUnauthorized    = 4401
+	wsCloseInitTimeout     = 4408
+	wsCloseSubscriberTaken = 4409
+	wsCloseTooManyInits    = 4429
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type wsSubscribePayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// wsHandler serves GraphQL operations over WebSocket. Subscriptions are
+// executed against the subscription schema, queries and mutations against the
+// regular one.
+type wsHandler struct {
+	schema       *graphql.Schema
+	subscription *graphql.Schema
+	upgrader     websocket.Upgrader
+}
+
+func newWSHandler(schema, subscription *graphql.Schema, origins []string) *wsHandler {
+	return &wsHandler{
+		schema:       schema,
+		subscription: subscription,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			Subprotocols:    []string{wsSubprotocol},
+			CheckOrigin:     wsOriginValidator(origins),
+		},
+	}
+}
+
+// wsOriginValidator verifies the origin of browser connections against the
+// CORS domains configured for GraphQL.
+func wsOriginValidator(origins []string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowed := range origins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		// Same origin requests are allowed, like for plain HTTP
+		if i := strings.Index(origin, "://"); i >= 0 && strings.EqualFold(origin[i+3:], r.Host) {
+			return true
+		}
+		log.Warn("Rejected GraphQL WebSocket connection", "origin", origin)
+		return false
+	}
+}
+
+// isWebsocket checks the header of an http request for a websocket upgrade request.
+func isWebsocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("GraphQL WebSocket upgrade failed", "err", err)
+		return
+	}
+	if conn.Subprotocol() != wsSubprotocol {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseProtocolError, "unsupported subprotocol"), time.Now().Add(wsWriteTimeout))
+		conn.Close()
+		return
+	}
+	conn.SetReadLimit(wsReadLimit)
+
+	// Queries and mutations are subject to the same timeout as over plain HTTP.
+	// Subscriptions are not, they last as long as the connection.
+	timeout, ok := rpc.ContextRequestTimeout(r.Context())
+	if !ok {
+		timeout = 0
+	} else if timeout <= 0 {
+		timeout = time.Nanosecond // already expired
+	}
+	newWSConn(h, conn, timeout).serve(context.Background())
+}
+
+// wsOperation is an operation running on a connection.
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+// wsConn is a single GraphQL WebSocket connection.
+type wsConn struct {
+	h       *wsHandler
+	conn    *websocket.Conn
+	timeout time.Duration // Timeout of queries and mutations, if positive
+
+	writeMu sync.Mutex // serializes writes to the connection
+
+	subsMu sync.Mutex
+	subs   map[string]*wsOperation // active operations by id
+	wg     sync.WaitGroup
+}
+
+func newWSConn(h *wsHandler, conn *websocket.Conn, timeout time.Duration) *wsConn {
+	return &wsConn{
+		h:       h,
+		conn:    conn,
+		timeout: timeout,
+		subs:    make(map[string]*wsOperation),
+	}
+}
+
+// serve reads and dispatches the client messages until the connection fails.
+func (c *wsConn) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		c.conn.Close()
+		c.wg.Wait()
+	}()
+
+	var acked bool
+	c.conn.SetReadDeadline(time.Now().Add(wsInitTimeout))
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if !acked {
+				if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+					c.close(wsCloseInitTimeout, "Connection initialisation timeout")
+				}
+			}
+			return
+		}
+		switch msg.Type {
+		case wsConnectionInit:
+			if acked {
+				c.close(wsCloseTooManyInits, "Too many initialisation requests")
+				return
+			}
+			acked = true
+			c.conn.SetReadDeadline(time.Time{})
+			c.write(&wsMessage{Type: wsConnectionAck})
+
+		case wsPing:
+			c.write(&wsMessage{Type: wsPong})
+
+		case wsPong:
+
+		case wsSubscribe:
+			if !acked {
+				c.close(wsCloseUnauthorized, "Unauthorized")
+				return
+			}
+			var payload wsSubscribePayload
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+				c.close(wsCloseBadRequest, "Invalid subscribe message")
+				return
+			}
+			if !c.start(ctx, msg.ID, &payload) {
+				return
+			}
+
+		case wsComplete:
+			c.stop(msg.ID)
+
+		default:
+			c.close(wsCloseBadRequest, "Invalid message type")
+			return
+		}
+	}
+}
+
+// start runs a new operation, streaming its results until it's done or the
+// client completes it. It returns false if the connection has to be closed.
+func (c *wsConn) start(ctx context.Context, id string, payload *wsSubscribePayload) bool {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	if _, ok := c.subs[id]; ok {
+		c.close(wsCloseSubscriberTaken, "Subscriber for "+id+" already exists")
+		return false
+	}
+	if len(c.subs) >= wsMaxSubscriptions {
+		c.write(&wsMessage{ID: id, Type: wsError, Payload: json.RawMessage(`[{"message":"too many subscriptions"}]`)})
+		return true
+	}
+	// Subscriptions are only valid against the subscription schema, anything
+	// else is executed by the regular one.
+	ctx, cancel := context.WithCancel(ctx)
+
+	var responses <-chan interface{}
+	if len(c.h.subscription.ValidateWithVariables(payload.Query, payload.Variables)) == 0 {
+		var err error
+		if responses, err = c.h.subscription.Subscribe(ctx, payload.Query, payload.OperationName, payload.Variables); err != nil {
+			cancel()
+			enc, _ := json.Marshal([]map[string]string{{"message": err.Error()}})
+			c.write(&wsMessage{ID: id, Type: wsError, Payload: enc})
+			return true
+		}
+	} else {
+		result := make(chan interface{}, 1)
+		go func() {
+			defer close(result)
+
+			ctx := ctx
+			if c.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.timeout)
+				defer cancel()
+			}
+			response := c.h.schema.Exec(withQueryBudget(ctx, maxQueryCost), payload.Query, payload.OperationName, payload.Variables)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				response = &graphql.Response{Errors: []*gqlErrors.QueryError{{Message: "request timed out"}}}
+			}
+			result <- response
+		}()
+		responses = result
+	}
+	op := &wsOperation{cancel: cancel}
+	c.subs[id] = op
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for resp := range responses {
+			enc, err := json.Marshal(resp)
+			if err != nil {
+				log.Warn("Failed to encode GraphQL response", "err", err)
+				continue
+			}
+			if err := c.write(&wsMessage{ID: id, Type: wsNext, Payload: enc}); err != nil {
+				// Tear down the connection, but keep draining the responses
+				// until the operation notices the cancellation.
+				c.conn.Close()
+				cancel()
+			}
+		}
+		// Report completion unless the client completed the operation itself
+		c.subsMu.Lock()
+		active := c.subs[id] == op
+		if active {
+			delete(c.subs, id)
+		}
+		c.subsMu.Unlock()
+
+		cancel()
+		if active {
+			c.write(&wsMessage{ID: id, Type: wsComplete})
+		}
+	}()
+	return true
+}
+
+// stop cancels the operation with the given id, if it's still running.
+func (c *wsConn) stop(id string) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	if op, ok := c.subs[id]; ok {
+		delete(c.subs, id)
+		op.cancel()
+	}
+}
+
+func (c *wsConn) write(msg *wsMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteJSON(msg)
+}
+
+func (c *wsConn) close(code int, reason string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -219,13 +220,70 @@ func (s *TxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCT
 	return content
 }
 
-// Status returns the number of pending and queued transaction in the pool.
-func (s *TxPoolAPI) Status() map[string]hexutil.Uint {
+// Status returns the number of pending and queued transaction in the pool. If a
+// transaction hash is given, it returns the lifecycle status of that transaction
+// instead, see TransactionLifecycle.
+func (s *TxPoolAPI) Status(ctx context.Context, hash *common.Hash) (interface{}, error) {
+	if hash != nil {
+		return s.lifecycle(ctx, *hash)
+	}
 	pending, queue := s.b.Stats()
 	return map[string]hexutil.Uint{
 		"pending": hexutil.Uint(pending),
 		"queued":  hexutil.Uint(queue),
+	}, nil
+}
+
+// TransactionLifecycle is the status of a single transaction as seen by the pool:
+// pending, queued, included, dropped, replaced or unknown. Dropped and replaced
+// transactions carry the reason, pending ones the reason they were last skipped
+// by the block builder, if any.
+type TransactionLifecycle struct {
+	Status      string          `json:"status"`
+	Reason      string          `json:"reason,omitempty"`
+	ReplacedBy  *common.Hash    `json:"replacedBy,omitempty"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber,omitempty"`
+	SkipReason  string          `json:"skipReason,omitempty"`
+	Time        *hexutil.Uint64 `json:"time,omitempty"`
+}
+
+// lifecycle assembles the lifecycle status of a transaction from the chain, the
+// pool and the pool's recent events.
+func (s *TxPoolAPI) lifecycle(ctx context.Context, hash common.Hash) (*TransactionLifecycle, error) {
+	found, _, _, blockNumber, _, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return &TransactionLifecycle{Status: "included", BlockNumber: (*hexutil.Uint64)(&blockNumber)}, nil
+	}
+	var (
+		status, event = s.b.TxPoolStatus(hash)
+		result        = new(TransactionLifecycle)
+	)
+	if event != nil {
+		time := hexutil.Uint64(event.Time.Unix())
+		result.Time = &time
+	}
+	switch {
+	case status == txpool.TxStatusPending, status == txpool.TxStatusQueued:
+		result.Status = "pending"
+		if status == txpool.TxStatusQueued {
+			result.Status, result.Reason = "queued", "nonce gap"
+		}
+		if event != nil && event.Kind == txpool.TxEventSkipped {
+			result.SkipReason = event.Reason
+		} else {
+			result.Time = nil
+		}
+	case event != nil && event.Kind == txpool.TxEventReplaced:
+		result.Status, result.Reason, result.ReplacedBy = "replaced", event.Reason, &event.ReplacedBy
+	case event != nil && event.Kind == txpool.TxEventDropped:
+		result.Status, result.Reason = "dropped", event.Reason
+	default:
+		result.Status, result.Time = "unknown", nil
 	}
+	return result, nil
 }
 
 // Inspect retrieves the content of the transaction pool and flattens it into an
@@ -1739,6 +1797,20 @@ func (s *TransactionAPI) sign(addr common.Address, tx *types.Transaction) (*type
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+	return submitTransaction(ctx, b, tx, false)
+}
+
+// SubmitPrivateTransaction is a helper function that submits tx to txPool without
+// propagating it to the network and logs a message.
+func SubmitPrivateTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+	return submitTransaction(ctx, b, tx, true)
+}
+
+func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, private bool) (common.Hash, error) {
+	// CHANGE(taiko): reject blob transactions upfront on networks not including them
+	if tx.Type() == types.BlobTxType && b.ChainConfig().BlobTxsDisabled() {
+		return common.Hash{}, txpool.ErrBlobTxsDisabled
+	}
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
 	if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
@@ -1748,7 +1820,11 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
-	if err := b.SendTx(ctx, tx); err != nil {
+	send := b.SendTx
+	if private {
+		send = b.SendPrivateTx
+	}
+	if err := send(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
@@ -1761,9 +1837,9 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 
 	if tx.To() == nil {
 		addr := crypto.CreateAddress(from, tx.Nonce())
-		log.Info("Submitted contract creation", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "contract", addr.Hex(), "value", tx.Value())
+		log.Info("Submitted contract creation", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "contract", addr.Hex(), "value", tx.Value(), "private", private)
 	} else {
-		log.Info("Submitted transaction", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value())
+		log.Info("Submitted transaction", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value(), "private", private)
 	}
 	return tx.Hash(), nil
 }
@@ -1832,6 +1908,55 @@ func (s *TransactionAPI) SendRawTransaction(ctx context.Context, input hexutil.B
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// SendPrivateRawTransaction adds the signed transaction to the transaction pool
+// without propagating it to the network. The transaction is only included by the
+// block builder of this node, and is dropped if not included within a configured
+// number of blocks.
+func (s *TransactionAPI) SendPrivateRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	return SubmitPrivateTransaction(ctx, s.b, tx)
+}
+
+// PrivateTransactionStatus is the status of a privately submitted transaction.
+type PrivateTransactionStatus struct {
+	Status      string          `json:"status"`
+	Expiry      hexutil.Uint64  `json:"expiry"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber,omitempty"`
+}
+
+// GetPrivateTransactionStatus returns the status of a privately submitted
+// transaction: pending or queued in the pool, included in a block, expired or
+// dropped from the pool otherwise. It returns null if the transaction was not
+// submitted privately or is not tracked anymore.
+func (s *TransactionAPI) GetPrivateTransactionStatus(ctx context.Context, hash common.Hash) (*PrivateTransactionStatus, error) {
+	info := s.b.PrivateTx(hash)
+	if info == nil {
+		return nil, nil
+	}
+	status := &PrivateTransactionStatus{Expiry: hexutil.Uint64(info.Expiry)}
+	found, _, _, blockNumber, _, err := s.b.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case found:
+		status.Status = "included"
+		status.BlockNumber = (*hexutil.Uint64)(&blockNumber)
+	case info.Status == txpool.TxStatusPending:
+		status.Status = "pending"
+	case info.Status == txpool.TxStatusQueued:
+		status.Status = "queued"
+	case info.Expired:
+		status.Status = "expired"
+	default:
+		status.Status = "dropped"
+	}
+	return status, nil
+}
+
 // Sign calculates an ECDSA signature for:
 // keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -0,0 +1,252 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	responseCacheHitMeter   = metrics.NewRegisteredMeter("rpc/cache/hit", nil)
+	responseCacheMissMeter  = metrics.NewRegisteredMeter("rpc/cache/miss", nil)
+	responseCachePurgeMeter = metrics.NewRegisteredMeter("rpc/cache/purge", nil)
+)
+
+// cachePolicy decides whether a call may be cached, given its parameters and the
+// finalized header. It returns nil if the call isn't cacheable, or a function
+// checking its result, which returns the highest block number the result depends
+// on if it may be cached.
+type cachePolicy func(ctx context.Context, c *ResponseCache, params []json.RawMessage, finalized *types.Header) func(result json.RawMessage) (uint64, bool)
+
+// responseCachePolicies are the policies of the cached methods.
+var responseCachePolicies = map[string]cachePolicy{
+	"eth_getBlockByNumber":      cacheBlockByNumber,
+	"eth_getBlockByHash":        cacheByResultBlock("number", "hash"),
+	"eth_getTransactionReceipt": cacheByResultBlock("blockNumber", "blockHash"),
+	"eth_getLogs":               cacheLogs,
+}
+
+// ResponseCache is a rpc.ResponseCache holding the results of read-only methods,
+// as long as they only depend on blocks at or below the finalized one. Results
+// are keyed on the method and its parameters.
+//
+// The cache is purged when any block it holds results of is rewound or replaced,
+// which can only happen through SetHead.
+type ResponseCache struct {
+	b    Backend
+	size uint64
+
+	mu         sync.RWMutex
+	results    *lru.SizeConstrainedCache[string, json.RawMessage]
+	gen        uint64        // incremented on every purge
+	highest    uint64        // highest block number of the cached results
+	checkpoint *types.Header // finalized header at the last chain head event
+
+	headCh chan core.ChainHeadEvent
+	sub    event.Subscription
+	wg     sync.WaitGroup
+}
+
+// NewResponseCache creates a response cache holding up to size bytes of results.
+func NewResponseCache(b Backend, size uint64) *ResponseCache {
+	c := &ResponseCache{
+		b:       b,
+		size:    size,
+		results: lru.NewSizeConstrainedCache[string, json.RawMessage](size),
+		headCh:  make(chan core.ChainHeadEvent, 16),
+	}
+	c.checkpoint, _ = b.HeaderByNumber(context.Background(), rpc.FinalizedBlockNumber)
+	c.sub = b.SubscribeChainHeadEvent(c.headCh)
+	c.wg.Add(1)
+	go c.loop()
+	return c
+}
+
+// Stop stops tracking the chain.
+func (c *ResponseCache) Stop() {
+	c.sub.Unsubscribe()
+	c.wg.Wait()
+}
+
+// loop purges the cache when the blocks it holds results of are rewound.
+func (c *ResponseCache) loop() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case ev := <-c.headCh:
+			c.update(ev.Block.Header())
+		case <-c.sub.Err():
+			return
+		}
+	}
+}
+
+// update checks the cached results against a new chain head.
+func (c *ResponseCache) update(head *types.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rewound := head.Number.Uint64() < c.highest
+	if cp := c.checkpoint; cp != nil && rawdb.ReadCanonicalHash(c.b.ChainDb(), cp.Number.Uint64()) != cp.Hash() {
+		rewound = true
+	}
+	if rewound {
+		c.results = lru.NewSizeConstrainedCache[string, json.RawMessage](c.size)
+		c.gen++
+		c.highest = 0
+		responseCachePurgeMeter.Mark(1)
+	}
+	c.checkpoint, _ = c.b.HeaderByNumber(context.Background(), rpc.FinalizedBlockNumber)
+}
+
+// Lookup implements rpc.ResponseCache.
+func (c *ResponseCache) Lookup(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, func(json.RawMessage)) {
+	policy := responseCachePolicies[method]
+	if policy == nil {
+		return nil, nil
+	}
+	var key bytes.Buffer
+	key.WriteString(method)
+	if err := json.Compact(&key, params); err != nil {
+		return nil, nil
+	}
+	c.mu.RLock()
+	results, gen := c.results, c.gen
+	c.mu.RUnlock()
+
+	if result, ok := results.Get(key.String()); ok {
+		responseCacheHitMeter.Mark(1)
+		return result, nil
+	}
+	responseCacheMissMeter.Mark(1)
+
+	finalized, _ := c.b.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+	if finalized == nil {
+		return nil, nil
+	}
+	var args []json.RawMessage
+	if len(params) > 0 && json.Unmarshal(params, &args) != nil {
+		return nil, nil
+	}
+	check := policy(ctx, c, args, finalized)
+	if check == nil {
+		return nil, nil
+	}
+	return nil, func(result json.RawMessage) {
+		number, ok := check(result)
+		if !ok {
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// Drop results of calls which raced with a purge.
+		if c.gen != gen {
+			return
+		}
+		c.results.Add(key.String(), bytes.Clone(result))
+		if number > c.highest {
+			c.highest = number
+		}
+	}
+}
+
+// canonicalFinalized reports whether the block is canonical and finalized.
+func (c *ResponseCache) canonicalFinalized(number uint64, hash common.Hash, finalized *types.Header) bool {
+	return number <= finalized.Number.Uint64() && rawdb.ReadCanonicalHash(c.b.ChainDb(), number) == hash
+}
+
+// cacheBlockByNumber caches blocks requested by number if they're finalized.
+func cacheBlockByNumber(ctx context.Context, c *ResponseCache, params []json.RawMessage, finalized *types.Header) func(json.RawMessage) (uint64, bool) {
+	var number rpc.BlockNumber
+	if len(params) == 0 || json.Unmarshal(params[0], &number) != nil {
+		return nil
+	}
+	if number < 0 || uint64(number) > finalized.Number.Uint64() {
+		return nil
+	}
+	return func(result json.RawMessage) (uint64, bool) {
+		return uint64(number), string(result) != "null"
+	}
+}
+
+// cacheByResultBlock caches results which belong to a finalized block, which is
+// identified by the given fields of the result.
+func cacheByResultBlock(numberField, hashField string) cachePolicy {
+	return func(ctx context.Context, c *ResponseCache, params []json.RawMessage, finalized *types.Header) func(json.RawMessage) (uint64, bool) {
+		return func(result json.RawMessage) (uint64, bool) {
+			var fields map[string]json.RawMessage
+			if json.Unmarshal(result, &fields) != nil || fields == nil {
+				return 0, false
+			}
+			var (
+				number hexutil.Uint64
+				hash   common.Hash
+			)
+			if json.Unmarshal(fields[numberField], &number) != nil || json.Unmarshal(fields[hashField], &hash) != nil {
+				return 0, false
+			}
+			return uint64(number), c.canonicalFinalized(uint64(number), hash, finalized)
+		}
+	}
+}
+
+// cacheLogs caches logs of finalized blocks, which must be requested by hash or
+// by a range with explicit bounds.
+func cacheLogs(ctx context.Context, c *ResponseCache, params []json.RawMessage, finalized *types.Header) func(json.RawMessage) (uint64, bool) {
+	var crit struct {
+		BlockHash *common.Hash     `json:"blockHash"`
+		FromBlock *rpc.BlockNumber `json:"fromBlock"`
+		ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	}
+	if len(params) == 0 || json.Unmarshal(params[0], &crit) != nil {
+		return nil
+	}
+	var number uint64
+	switch {
+	case crit.BlockHash != nil:
+		header, _ := c.b.HeaderByHash(ctx, *crit.BlockHash)
+		if header == nil || !c.canonicalFinalized(header.Number.Uint64(), header.Hash(), finalized) {
+			return nil
+		}
+		number = header.Number.Uint64()
+	case crit.FromBlock != nil && crit.ToBlock != nil:
+		if *crit.FromBlock < 0 || *crit.ToBlock < 0 || uint64(*crit.ToBlock) > finalized.Number.Uint64() {
+			return nil
+		}
+		number = uint64(*crit.ToBlock)
+	default:
+		return nil
+	}
+	return func(json.RawMessage) (uint64, bool) {
+		return number, true
+	}
+}
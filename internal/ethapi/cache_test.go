@@ -0,0 +1,134 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestResponseCache(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genesis = &core.Genesis{Config: params.MergedTestChainConfig, Alloc: types.GenesisAlloc{}}
+		backend = newTestBackend(t, 10, genesis, beacon.New(ethash.NewFaker()), func(i int, b *core.BlockGen) {
+			b.SetPoS()
+		})
+		cache  = NewResponseCache(backend, 1024*1024)
+		server = rpc.NewServer()
+	)
+	defer cache.Stop()
+	backend.chain.SetFinalized(backend.chain.GetHeaderByNumber(5))
+
+	server.SetResponseCache(cache)
+	if err := server.RegisterName("eth", NewBlockChainAPI(backend)); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	cached := func(method string, args ...interface{}) bool {
+		params, _ := json.Marshal(args)
+		result, _ := cache.Lookup(context.Background(), method, params)
+		return result != nil
+	}
+	call := func(method string, args ...interface{}) {
+		t.Helper()
+		var result map[string]interface{}
+		if err := client.Call(&result, method, args...); err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+	}
+	block3, block8 := backend.chain.GetHeaderByNumber(3), backend.chain.GetHeaderByNumber(8)
+
+	call("eth_getBlockByNumber", hexutil.Uint64(3), false)
+	call("eth_getBlockByNumber", hexutil.Uint64(8), false)
+	call("eth_getBlockByNumber", "latest", false)
+	call("eth_getBlockByHash", block3.Hash(), true)
+	call("eth_getBlockByHash", block8.Hash(), true)
+
+	for _, test := range []struct {
+		method string
+		args   []interface{}
+		want   bool
+	}{
+		{"eth_getBlockByNumber", []interface{}{hexutil.Uint64(3), false}, true},
+		{"eth_getBlockByNumber", []interface{}{hexutil.Uint64(3), true}, false},
+		{"eth_getBlockByNumber", []interface{}{hexutil.Uint64(8), false}, false},
+		{"eth_getBlockByNumber", []interface{}{"latest", false}, false},
+		{"eth_getBlockByHash", []interface{}{block3.Hash(), true}, true},
+		{"eth_getBlockByHash", []interface{}{block8.Hash(), true}, false},
+	} {
+		if have := cached(test.method, test.args...); have != test.want {
+			t.Errorf("%s %v: cached %t, want %t", test.method, test.args, have, test.want)
+		}
+	}
+	// Rewinding below the cached blocks purges the cache.
+	if err := backend.chain.SetHead(2); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for cached("eth_getBlockByNumber", hexutil.Uint64(3), false) {
+		if time.Now().After(deadline) {
+			t.Fatal("cache not purged after rewind")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResponseCacheLogsPolicy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		genesis = &core.Genesis{Config: params.MergedTestChainConfig, Alloc: types.GenesisAlloc{}}
+		backend = newTestBackend(t, 10, genesis, beacon.New(ethash.NewFaker()), func(i int, b *core.BlockGen) {
+			b.SetPoS()
+		})
+		cache     = NewResponseCache(backend, 1024*1024)
+		finalized = backend.chain.GetHeaderByNumber(5)
+	)
+	defer cache.Stop()
+
+	for i, test := range []struct {
+		crit string
+		want bool
+	}{
+		{`{"fromBlock":"0x1","toBlock":"0x5"}`, true},
+		{`{"fromBlock":"0x1","toBlock":"0x6"}`, false},
+		{`{"fromBlock":"0x1"}`, false},
+		{`{"fromBlock":"0x1","toBlock":"finalized"}`, false},
+		{`{"blockHash":"` + backend.chain.GetHeaderByNumber(2).Hash().Hex() + `"}`, true},
+		{`{"blockHash":"` + backend.chain.GetHeaderByNumber(7).Hash().Hex() + `"}`, false},
+	} {
+		check := cacheLogs(context.Background(), cache, []json.RawMessage{json.RawMessage(test.crit)}, finalized)
+		if have := check != nil; have != test.want {
+			t.Errorf("test %d: cacheable %t, want %t", i, have, test.want)
+		}
+	}
+}
@@ -779,7 +779,34 @@ web3._extend({
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'transactionStatus',
+			call: 'txpool_status',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'queueStatus',
+			call: 'txpool_queueStatus',
+			params: 1,
+			inputFormatter: [null],
+		}),
+		new web3._extend.Method({
+			name: 'export',
+			call: 'txpool_export',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'import',
+			call: 'txpool_import',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'snapshot',
+			call: 'txpool_snapshot',
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
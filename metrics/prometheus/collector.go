@@ -32,12 +32,17 @@ var (
 	typeSummaryTpl         = "# TYPE %s summary\n"
 	keyValueTpl            = "%s %v\n\n"
 	keyQuantileTagValueTpl = "%s {quantile=\"%s\"} %v\n"
+	keyLabelsValueTpl      = "%s{%s} %v\n"
+
+	// summaryQuantiles are the quantiles reported of labeled histograms and timers.
+	summaryQuantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
 )
 
 // collector is a collection of byte buffers that aggregate Prometheus reports
 // for different metric types.
 type collector struct {
-	buff *bytes.Buffer
+	buff   *bytes.Buffer
+	family string // base name of the last labeled metric added
 }
 
 // newCollector creates a new Prometheus metric aggregator.
@@ -50,6 +55,13 @@ func newCollector() *collector {
 // Add adds the metric i to the collector. This method returns an error if the
 // metric type is not supported/known.
 func (c *collector) Add(name string, i any) error {
+	if base, labels := metrics.SplitLabels(name); labels != "" {
+		return c.addLabeled(mutateKey(base), labels, i)
+	}
+	if c.family != "" {
+		c.buff.WriteRune('\n')
+		c.family = ""
+	}
 	switch m := i.(type) {
 	case metrics.Counter:
 		c.addCounter(name, m.Snapshot())
@@ -75,6 +87,30 @@ func (c *collector) Add(name string, i any) error {
 	return nil
 }
 
+// addLabeled adds a metric carrying labels. Metrics of the same base name are
+// reported as one family, so they have to be added consecutively.
+func (c *collector) addLabeled(name, labels string, i any) error {
+	switch m := i.(type) {
+	case metrics.Counter:
+		c.writeLabeledValue(name, labels, m.Snapshot().Count())
+	case metrics.Gauge:
+		c.writeLabeledValue(name, labels, m.Snapshot().Value())
+	case metrics.GaugeFloat64:
+		c.writeLabeledValue(name, labels, m.Snapshot().Value())
+	case metrics.Meter:
+		c.writeLabeledValue(name, labels, m.Snapshot().Count())
+	case metrics.Histogram:
+		snap := m.Snapshot()
+		c.writeLabeledSummary(name, labels, snap.Count(), snap.Percentiles(summaryQuantiles))
+	case metrics.Timer:
+		snap := m.Snapshot()
+		c.writeLabeledSummary(name, labels, snap.Count(), snap.Percentiles(summaryQuantiles))
+	default:
+		return fmt.Errorf("unknown prometheus metric type %T", i)
+	}
+	return nil
+}
+
 func (c *collector) addCounter(name string, m metrics.CounterSnapshot) {
 	c.writeGaugeCounter(name, m.Count())
 }
@@ -134,6 +170,33 @@ func (c *collector) addResettingTimer(name string, m metrics.ResettingTimerSnaps
 	c.buff.WriteRune('\n')
 }
 
+// writeFamilyType writes the type of a labeled metric family, unless the metric
+// continues the family of the previous one.
+func (c *collector) writeFamilyType(name, tpl string) {
+	if c.family == name {
+		return
+	}
+	if c.family != "" {
+		c.buff.WriteRune('\n')
+	}
+	c.family = name
+	c.buff.WriteString(fmt.Sprintf(tpl, name))
+}
+
+func (c *collector) writeLabeledValue(name, labels string, value interface{}) {
+	c.writeFamilyType(name, typeGaugeTpl)
+	c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, name, labels, value))
+}
+
+func (c *collector) writeLabeledSummary(name, labels string, count int64, ps []float64) {
+	c.writeFamilyType(name, typeSummaryTpl)
+	for i, p := range summaryQuantiles {
+		quantile := fmt.Sprintf("%s,quantile=\"%s\"", labels, strconv.FormatFloat(p, 'f', -1, 64))
+		c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, name, quantile, ps[i]))
+	}
+	c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, name+"_count", labels, count))
+}
+
 func (c *collector) writeGaugeInfo(name string, value metrics.GaugeInfoValue) {
 	name = mutateKey(name)
 	c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, name))
@@ -63,3 +63,45 @@ func findFirstDiffPos(a, b string) string {
 	}
 	return ""
 }
+
+func TestCollectorLabeled(t *testing.T) {
+	var (
+		c        = newCollector()
+		registry = metrics.NewRegistry()
+	)
+	metrics.NewRegisteredGauge("test/plain", registry).Update(1)
+	metrics.NewRegisteredGauge(metrics.LabeledName("test/inflight", "method", "eth_call", "transport", "http"), registry).Update(2)
+	metrics.NewRegisteredGauge(metrics.LabeledName("test/inflight", "method", "eth_call", "transport", "ws"), registry).Update(3)
+	metrics.NewRegisteredHistogram(metrics.LabeledName("test/latency", "method", "eth_call"), registry, metrics.NewUniformSample(10)).Update(4)
+
+	for _, name := range []string{
+		`test/inflight{method="eth_call",transport="http"}`,
+		`test/inflight{method="eth_call",transport="ws"}`,
+		`test/latency{method="eth_call"}`,
+		"test/plain",
+	} {
+		if err := c.Add(name, registry.Get(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `# TYPE test_inflight gauge
+test_inflight{method="eth_call",transport="http"} 2
+test_inflight{method="eth_call",transport="ws"} 3
+
+# TYPE test_latency summary
+test_latency{method="eth_call",quantile="0.5"} 4
+test_latency{method="eth_call",quantile="0.75"} 4
+test_latency{method="eth_call",quantile="0.95"} 4
+test_latency{method="eth_call",quantile="0.99"} 4
+test_latency{method="eth_call",quantile="0.999"} 4
+test_latency{method="eth_call",quantile="0.9999"} 4
+test_latency_count{method="eth_call"} 1
+
+# TYPE test_plain gauge
+test_plain 1
+
+`
+	if have := c.buff.String(); have != want {
+		t.Fatalf("unexpected collector output\nhave:\n%s\nwant:\n%s", have, want)
+	}
+}
@@ -14,6 +14,15 @@ type PreBuiltTxList struct {
 	TxList           types.Transactions
 	EstimatedGasUsed uint64
 	BytesLength      uint64
+	Bundles          []*BundleResult `json:"bundles,omitempty"`
+}
+
+// BundleResult is the outcome of trying to include a bundle in a transactions list.
+type BundleResult struct {
+	Hash     common.Hash `json:"hash"`
+	Included bool        `json:"included"`
+	GasUsed  uint64      `json:"gasUsed"`
+	Error    string      `json:"error,omitempty"`
 }
 
 // SealBlockWith mines and seals a block without changing the canonical chain.
@@ -0,0 +1,88 @@
+package miner
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// SnapshotReplay are the arguments of BuildTransactionsLists when replaying a
+// pool snapshot.
+type SnapshotReplay struct {
+	Timestamp    uint64 // Timestamp the lists are built at
+	Beneficiary  common.Address
+	BaseFee      *big.Int
+	GasLimit     uint64
+	MaxBytes     uint64
+	LocalSenders []string
+	MaxLists     uint64
+	MinTip       uint64
+}
+
+// replayChain is a chain with its head fixed to the one a snapshot was taken at.
+type replayChain struct {
+	*core.BlockChain
+	head *types.Header
+}
+
+// CurrentBlock returns the head the snapshot was taken at.
+func (c *replayChain) CurrentBlock() *types.Header {
+	return c.head
+}
+
+// replayBackend is the backend of the worker replaying a snapshot.
+type replayBackend struct {
+	chain *core.BlockChain
+	pool  *txpool.TxPool
+}
+
+func (b *replayBackend) BlockChain() *core.BlockChain { return b.chain }
+func (b *replayBackend) TxPool() *txpool.TxPool       { return b.pool }
+
+// ReplayTxPoolSnapshot builds the transactions lists of a pool snapshot on top of
+// the state of the block it was taken at, which must be available in the chain.
+// The transactions are added to a fresh pool with the given config and their
+// original arrival times, and the lists are built at the fixed timestamp of the
+// replay, so that replaying the same snapshot always builds the same lists.
+func ReplayTxPoolSnapshot(chain *core.BlockChain, config legacypool.Config, snap *txpool.Snapshot, replay *SnapshotReplay) ([]*PreBuiltTxList, error) {
+	head := chain.GetHeaderByHash(snap.Header.Hash)
+	if head == nil {
+		return nil, fmt.Errorf("snapshot head %d [%x] not found", snap.Header.Number, snap.Header.Hash)
+	}
+	if !chain.HasState(head.Root) {
+		return nil, fmt.Errorf("state of snapshot head %d [%x] not available", snap.Header.Number, snap.Header.Hash)
+	}
+	// The replay pool must neither restore nor persist any transactions
+	config.Journal, config.Checkpoint = "", ""
+
+	fixed := &replayChain{BlockChain: chain, head: head}
+	pool, err := txpool.New(config.PriceLimit, fixed, []txpool.SubPool{legacypool.New(config, fixed)})
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	var locals, remotes []*types.Transaction
+	for _, tx := range snap.Txs {
+		if tx.Local {
+			locals = append(locals, tx.Tx)
+		} else {
+			remotes = append(remotes, tx.Tx)
+		}
+	}
+	pool.Add(locals, true, true)
+	pool.Add(remotes, false, true)
+
+	w := newWorker(&DefaultConfig, chain.Config(), chain.Engine(), &replayBackend{chain: chain, pool: pool}, new(event.TypeMux), nil, false)
+	defer w.close()
+
+	w.headHook = func() *types.Header { return head }
+	w.timestampHook = func() uint64 { return replay.Timestamp }
+	return w.BuildTransactionsLists(replay.Beneficiary, replay.BaseFee, replay.GasLimit, replay.MaxBytes, replay.LocalSenders, replay.MaxLists, replay.MinTip)
+}
@@ -0,0 +1,167 @@
+package miner
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newSnapshotWorker creates a worker on top of a fresh chain with the state of
+// the given genesis and an empty pool.
+func newSnapshotWorker(t *testing.T, genesis *core.Genesis) (*worker, *txpool.TxPool) {
+	t.Helper()
+
+	chain := newSnapshotChain(t, genesis)
+	pool, err := txpool.New(testTxPoolConfig.PriceLimit, chain, []txpool.SubPool{legacypool.New(testTxPoolConfig, chain)})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	backend := &testWorkerBackend{chain: chain, txPool: pool, genesis: genesis}
+	w := newWorker(testConfig, genesis.Config, chain.Engine(), backend, new(event.TypeMux), nil, false)
+	t.Cleanup(w.close)
+	return w, pool
+}
+
+// newSnapshotChain creates a fresh chain with the state of the given genesis.
+func newSnapshotChain(t *testing.T, genesis *core.Genesis) *core.BlockChain {
+	t.Helper()
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), &core.CacheConfig{TrieDirtyDisabled: true}, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+	return chain
+}
+
+// Tests that a pool snapshot survives exporting and importing in both formats,
+// and that replaying it builds the lists the pool would have.
+func TestReplayTxPoolSnapshot(t *testing.T) {
+	var (
+		signer = types.LatestSigner(params.TestChainConfig)
+		start  = time.Unix(1700000000, 0)
+	)
+	makeTx := func(nonce uint64, tip int64, arrival time.Duration) *types.Transaction {
+		tx := types.MustSignNewTx(testUserKey, signer, &types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: big.NewInt(10 * params.InitialBaseFee),
+			Gas:       params.TxGas,
+			To:        &testBankAddress,
+			Value:     big.NewInt(1),
+		})
+		tx.SetTime(start.Add(arrival))
+		return tx
+	}
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			testBankAddress: {Balance: testBankFunds},
+			testUserAddress: {Balance: testBankFunds},
+		},
+	}
+	w, pool := newSnapshotWorker(t, genesis)
+
+	// Add executable transactions from both accounts and a queued one, leaving a
+	// nonce gap for the user
+	pool.Add([]*types.Transaction{pendingTxs[0]}, true, true)
+	pool.Add([]*types.Transaction{makeTx(0, params.GWei, 0), makeTx(1, params.GWei, time.Second), makeTx(3, params.GWei, 2*time.Second)}, false, true)
+
+	snap := pool.Snapshot(w.chain.CurrentBlock())
+	if len(snap.Txs) != 4 {
+		t.Fatalf("wrong number of snapshot transactions: have %d, want 4", len(snap.Txs))
+	}
+	for _, tx := range snap.Txs {
+		var (
+			local   = tx.Sender == testBankAddress
+			pending = tx.Tx.Nonce() != 3
+		)
+		if tx.Local != local || tx.Pending != pending {
+			t.Errorf("transaction %x: wrong metadata: local %v pending %v, want local %v pending %v", tx.Tx.Hash(), tx.Local, tx.Pending, local, pending)
+		}
+	}
+	replay := &SnapshotReplay{
+		Timestamp: uint64(start.Unix()),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+		GasLimit:  30_000_000,
+		MaxBytes:  128 * 1024,
+		MaxLists:  1,
+	}
+	w.timestampHook = func() uint64 { return replay.Timestamp }
+	want, err := w.BuildTransactionsLists(replay.Beneficiary, replay.BaseFee, replay.GasLimit, replay.MaxBytes, replay.LocalSenders, replay.MaxLists, replay.MinTip)
+	if err != nil {
+		t.Fatalf("failed to build lists: %v", err)
+	}
+	if len(want) != 1 || len(want[0].TxList) != 3 {
+		t.Fatalf("unexpected lists built from the pool: %v", want)
+	}
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 1, nil)
+	for _, name := range []string{"txpool.rlp", "txpool.jsonl"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := txpool.ExportSnapshot(path, snap); err != nil {
+			t.Fatalf("%s: failed to export snapshot: %v", name, err)
+		}
+		if err := txpool.ExportSnapshot(path, snap); !errors.Is(err, txpool.ErrSnapshotExists) {
+			t.Fatalf("%s: overwrite error mismatch: have %v, want %v", name, err, txpool.ErrSnapshotExists)
+		}
+		imported, err := txpool.ImportSnapshot(path)
+		if err != nil {
+			t.Fatalf("%s: failed to import snapshot: %v", name, err)
+		}
+		if imported.Header != snap.Header || len(imported.Txs) != len(snap.Txs) {
+			t.Fatalf("%s: snapshot mismatch: have %+v, want %+v", name, imported.Header, snap.Header)
+		}
+		for i, tx := range imported.Txs {
+			have, _ := json.Marshal(tx)
+			want, _ := json.Marshal(snap.Txs[i])
+			if string(have) != string(want) {
+				t.Errorf("%s: transaction %d mismatch: have %s, want %s", name, i, have, want)
+			}
+			if !tx.Tx.Time().Equal(snap.Txs[i].Tx.Time()) {
+				t.Errorf("%s: transaction %d: arrival time not restored", name, i)
+			}
+		}
+		// Move the chain replayed on past the snapshot head, the lists must still be
+		// built on top of the latter
+		chain := newSnapshotChain(t, genesis)
+		if _, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("%s: failed to extend chain: %v", name, err)
+		}
+		lists, err := ReplayTxPoolSnapshot(chain, testTxPoolConfig, imported, replay)
+		if err != nil {
+			t.Fatalf("%s: failed to replay snapshot: %v", name, err)
+		}
+		if len(lists) != len(want) {
+			t.Fatalf("%s: wrong number of replayed lists: have %d, want %d", name, len(lists), len(want))
+		}
+		for i := range want {
+			if len(lists[i].TxList) != len(want[i].TxList) {
+				t.Fatalf("%s: list %d: wrong number of transactions: have %d, want %d", name, i, len(lists[i].TxList), len(want[i].TxList))
+			}
+			for j, tx := range lists[i].TxList {
+				if tx.Hash() != want[i].TxList[j].Hash() {
+					t.Errorf("%s: list %d: wrong transaction %d: have %x, want %x", name, i, j, tx.Hash(), want[i].TxList[j].Hash())
+				}
+			}
+			if lists[i].EstimatedGasUsed != want[i].EstimatedGasUsed || lists[i].BytesLength != want[i].BytesLength {
+				t.Errorf("%s: list %d: wrong gas used or size", name, i)
+			}
+		}
+	}
+}
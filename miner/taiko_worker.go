@@ -1,8 +1,6 @@
 package miner
 
 import (
-	"bytes"
-	"compress/zlib"
 	"errors"
 	"fmt"
 	"math/big"
@@ -38,18 +36,26 @@ func (w *worker) BuildTransactionsLists(
 		txsLists    []*PreBuiltTxList
 		currentHead = w.chain.CurrentBlock()
 	)
+	if w.headHook != nil {
+		currentHead = w.headHook()
+	}
 
 	if currentHead == nil {
 		return nil, fmt.Errorf("failed to find current head")
 	}
 
 	// Check if tx pool is empty at first.
-	if len(w.eth.TxPool().Pending(txpool.PendingFilter{MinTip: uint256.NewInt(minTip), BaseFee: uint256.MustFromBig(baseFee), OnlyPlainTxs: true})) == 0 {
+	bundles := w.eth.TxPool().Bundles(currentHead.Number.Uint64() + 1)
+	if len(bundles) == 0 && len(w.eth.TxPool().Pending(txpool.PendingFilter{MinTip: uint256.NewInt(minTip), BaseFee: uint256.MustFromBig(baseFee), OnlyPlainTxs: true})) == 0 {
 		return txsLists, nil
 	}
 
+	timestamp := uint64(time.Now().Unix())
+	if w.timestampHook != nil {
+		timestamp = w.timestampHook()
+	}
 	params := &generateParams{
-		timestamp:     uint64(time.Now().Unix()),
+		timestamp:     timestamp,
 		forceTime:     true,
 		parentHash:    currentHead.Hash(),
 		coinbase:      beneficiary,
@@ -89,14 +95,23 @@ func (w *worker) BuildTransactionsLists(
 			remotes[address] = txs
 		}
 
-		lastTransaction := w.commitL2Transactions(
+		lastTransaction, bundleResults := w.commitL2Transactions(
 			env,
 			firstTransaction,
+			bundles,
 			newTransactionsByPriceAndNonce(signer, locals, baseFee),
 			newTransactionsByPriceAndNonce(signer, remotes, baseFee),
 			maxBytesPerTxList,
 			minTip,
 		)
+		// Bundles included in this list are not to be tried again in the next ones
+		pending := bundles[:0:0]
+		for i, res := range bundleResults {
+			if !res.Included {
+				pending = append(pending, bundles[i])
+			}
+		}
+		bundles = pending
 
 		b, err := encodeAndCompressTxList(env.txs)
 		if err != nil {
@@ -107,6 +122,7 @@ func (w *worker) BuildTransactionsLists(
 			TxList:           env.txs,
 			EstimatedGasUsed: env.header.GasLimit - env.gasPool.Gas(),
 			BytesLength:      uint64(len(b)),
+			Bundles:          bundleResults,
 		}, nil
 	}
 
@@ -125,7 +141,11 @@ func (w *worker) BuildTransactionsLists(
 
 		txsLists = append(txsLists, res)
 	}
-
+	// The transaction overflowing a list starts the next one, it's only skipped
+	// if the last list overflowed.
+	if lastTx != nil {
+		w.eth.TxPool().RecordSkipped(lastTx.Hash(), fmt.Sprintf("compressed list exceeds maxBytesPerTxList %d", maxBytesPerTxList))
+	}
 	return txsLists, nil
 }
 
@@ -236,24 +256,37 @@ func (w *worker) getPendingTxs(localAccounts []string, baseFee *big.Int) (
 }
 
 // commitL2Transactions tries to commit the transactions into the given state.
+// Bundles are committed first, each one only if all its members succeed,
+// returning the outcome of every bundle in order.
 func (w *worker) commitL2Transactions(
 	env *environment,
 	firstTransaction *types.Transaction,
+	bundles []*txpool.Bundle,
 	txsLocal *transactionsByPriceAndNonce,
 	txsRemote *transactionsByPriceAndNonce,
 	maxBytesPerTxList uint64,
 	minTip uint64,
-) *types.Transaction {
+) (*types.Transaction, []*BundleResult) {
 	var (
 		txs             = txsLocal
 		isLocal         = true
 		lastTransaction *types.Transaction
+		bundleResults   = make([]*BundleResult, len(bundles))
 	)
 
 	if firstTransaction != nil {
 		env.txs = append(env.txs, firstTransaction)
 	}
 
+	for i, bundle := range bundles {
+		gasUsed, err := w.commitBundle(env, bundle, maxBytesPerTxList)
+		bundleResults[i] = &BundleResult{Hash: bundle.Hash(), Included: err == nil, GasUsed: gasUsed}
+		if err != nil {
+			log.Trace("Bundle failed, skipped", "hash", bundleResults[i].Hash, "err", err)
+			bundleResults[i].Error = err.Error()
+		}
+	}
+
 loop:
 	for {
 		// If we don't have enough gas for any further transactions then we're done.
@@ -282,6 +315,7 @@ loop:
 
 		if tx.GasTipCapIntCmp(new(big.Int).SetUint64(minTip)) < 0 {
 			log.Trace("Ignoring transaction with low tip", "hash", tx.Hash(), "tip", tx.GasTipCap(), "minTip", minTip)
+			w.eth.TxPool().RecordSkipped(tx.Hash(), fmt.Sprintf("tip %v below minTip %d", tx.GasTipCap(), minTip))
 			txs.Pop()
 			continue
 		}
@@ -321,44 +355,72 @@ loop:
 				continue
 			}
 			if len(b) > int(maxBytesPerTxList) {
-				lastTransaction = env.txs[env.tcount-1]
-				env.txs = env.txs[0 : env.tcount-1]
+				lastTransaction = env.txs[len(env.txs)-1]
+				env.txs = env.txs[:len(env.txs)-1]
 				break loop
 			}
 		default:
 			// Transaction is regarded as invalid, drop all consecutive transactions from
 			// the same sender because of `nonce-too-high` clause.
 			log.Trace("Transaction failed, account skipped", "hash", ltx.Hash, "err", err)
+			w.eth.TxPool().RecordSkipped(ltx.Hash, err.Error())
 			txs.Pop()
 		}
 	}
 
-	return lastTransaction
+	return lastTransaction, bundleResults
 }
 
-// encodeAndCompressTxList encodes and compresses the given transactions list.
-func encodeAndCompressTxList(txs types.Transactions) ([]byte, error) {
-	b, err := rlp.EncodeToBytes(txs)
-	if err != nil {
-		return nil, err
+// commitBundle commits all transactions of a bundle in order, returning the gas
+// they used. If any of them fails, or reverts without being allowed to, or the
+// compressed list outgrows maxBytesPerTxList, the whole bundle is reverted.
+func (w *worker) commitBundle(env *environment, bundle *txpool.Bundle, maxBytesPerTxList uint64) (uint64, error) {
+	// State revisions don't survive the finalisation between transactions, so
+	// the state is checkpointed by copying it.
+	var (
+		snap     = env.state.Copy()
+		gas      = env.gasPool.Gas()
+		gasUsed  = env.header.GasUsed
+		tcount   = env.tcount
+		txs      = len(env.txs)
+		receipts = len(env.receipts)
+	)
+	revert := func() {
+		env.state.StopPrefetcher()
+		env.state = snap
+		env.gasPool.SetGas(gas)
+		env.header.GasUsed = gasUsed
+		env.tcount = tcount
+		env.txs = env.txs[:txs]
+		env.receipts = env.receipts[:receipts]
 	}
+	for _, tx := range bundle.Txs {
+		env.state.SetTxContext(tx.Hash(), env.tcount)
 
-	return compress(b)
-}
-
-// compress compresses the given txList bytes using zlib.
-func compress(txListBytes []byte) ([]byte, error) {
-	var b bytes.Buffer
-	w := zlib.NewWriter(&b)
-	defer w.Close()
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			revert()
+			return 0, fmt.Errorf("transaction %s failed: %w", tx.Hash(), err)
+		}
+		env.tcount++
 
-	if _, err := w.Write(txListBytes); err != nil {
-		return nil, err
+		if receipt := env.receipts[len(env.receipts)-1]; receipt.Status == types.ReceiptStatusFailed && !bundle.CanRevert(tx.Hash()) {
+			revert()
+			return 0, fmt.Errorf("transaction %s reverted", tx.Hash())
+		}
 	}
-
-	if err := w.Flush(); err != nil {
-		return nil, err
+	b, err := encodeAndCompressTxList(env.txs)
+	if err != nil {
+		revert()
+		return 0, err
 	}
+	if len(b) > int(maxBytesPerTxList) {
+		revert()
+		return 0, errors.New("bundle exceeds the transactions list size limit")
+	}
+	return env.header.GasUsed - gasUsed, nil
+}
 
-	return b.Bytes(), nil
+// encodeAndCompressTxList encodes and compresses the given transactions list.
+func encodeAndCompressTxList(txs types.Transactions) ([]byte, error) {
+	return types.CompressTxList(txs)
 }
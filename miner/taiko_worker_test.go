@@ -0,0 +1,140 @@
+package miner
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestBuildTransactionsListsWithBundles(t *testing.T) {
+	w, b := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// Replace the pool of the backend with one maintaining bundles
+	b.txPool.Close()
+	pool, err := txpool.New(testTxPoolConfig.PriceLimit, b.chain, []txpool.SubPool{
+		legacypool.New(testTxPoolConfig, b.chain),
+		bundlepool.New(bundlepool.DefaultConfig, b.chain),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.txPool = pool
+	defer pool.Close()
+
+	var (
+		signer = types.LatestSigner(params.TestChainConfig)
+		revert = common.FromHex("0x60006000fd") // Contract creation reverting right away
+	)
+	makeTx := func(nonce uint64, to *common.Address, data []byte) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.InitialBaseFee),
+			Gas:       100000,
+			To:        to,
+			Value:     big.NewInt(1),
+			Data:      data,
+		})
+	}
+	var (
+		transfer0 = makeTx(0, &testUserAddress, nil)
+		transfer1 = makeTx(1, &testUserAddress, nil)
+		reverting = makeTx(1, nil, revert)
+		allowed   = makeTx(2, nil, revert)
+		bundles   = []*txpool.Bundle{
+			{Txs: []*types.Transaction{transfer0}, MaxBlock: 1},
+			{Txs: []*types.Transaction{reverting}, MaxBlock: 1},
+			{Txs: []*types.Transaction{transfer1, allowed}, MaxBlock: 1, RevertingTxHashes: []common.Hash{allowed.Hash()}},
+		}
+	)
+	for _, bundle := range bundles {
+		if err := pool.AddBundle(bundle); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	lists, err := w.BuildTransactionsLists(testBankAddress, big.NewInt(params.InitialBaseFee), 30_000_000, 128*1024, nil, 2, 0)
+	if err != nil {
+		t.Fatalf("failed to build lists: %v", err)
+	}
+	if len(lists) != 1 {
+		t.Fatalf("wrong number of lists: have %d, want 1", len(lists))
+	}
+	var hashes []common.Hash
+	for _, tx := range lists[0].TxList {
+		hashes = append(hashes, tx.Hash())
+	}
+	want := []common.Hash{transfer0.Hash(), transfer1.Hash(), allowed.Hash()}
+	if len(hashes) != len(want) {
+		t.Fatalf("wrong transactions: have %v, want %v", hashes, want)
+	}
+	for i := range want {
+		if hashes[i] != want[i] {
+			t.Fatalf("wrong transaction %d: have %x, want %x", i, hashes[i], want[i])
+		}
+	}
+	results := lists[0].Bundles
+	if len(results) != len(bundles) {
+		t.Fatalf("wrong number of bundle results: have %d, want %d", len(results), len(bundles))
+	}
+	for i, included := range []bool{true, false, true} {
+		if results[i].Hash != bundles[i].Hash() || results[i].Included != included {
+			t.Errorf("bundle %d: wrong result %+v", i, results[i])
+		}
+	}
+	if results[1].Error == "" || results[1].GasUsed != 0 {
+		t.Errorf("failed bundle reported no error: %+v", results[1])
+	}
+}
+
+// Tests that a transaction overflowing a list is only reported as skipped if it
+// doesn't start another list.
+func TestBuildTransactionsListsOverflowSkip(t *testing.T) {
+	w, b := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	signer := types.LatestSigner(params.TestChainConfig)
+	makeTx := func(nonce uint64) *types.Transaction {
+		data := make([]byte, 200)
+		rand.Read(data) // Incompressible
+		return types.MustSignNewTx(testBankKey, signer, &types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.InitialBaseFee),
+			Gas:       50000,
+			To:        &testUserAddress,
+			Data:      data,
+		})
+	}
+	carried, overflowing := makeTx(1), makeTx(2)
+	for _, err := range b.txPool.Add([]*types.Transaction{carried, overflowing}, true, true) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// Every list only fits a single large transaction
+	lists, err := w.BuildTransactionsLists(testBankAddress, big.NewInt(params.InitialBaseFee), 30_000_000, 400, nil, 2, 0)
+	if err != nil {
+		t.Fatalf("failed to build lists: %v", err)
+	}
+	if len(lists) != 2 || len(lists[1].TxList) != 1 || lists[1].TxList[0] != carried {
+		t.Fatalf("wrong lists: %+v", lists)
+	}
+	if event := b.txPool.TxEvent(carried.Hash()); event != nil {
+		t.Fatalf("carried over transaction reported: %+v", event)
+	}
+	if event := b.txPool.TxEvent(overflowing.Hash()); event == nil || event.Kind != txpool.TxEventSkipped {
+		t.Fatalf("overflowing transaction not reported as skipped: %+v", event)
+	}
+}
@@ -240,6 +240,9 @@ type worker struct {
 	skipSealHook func(*task) bool                   // Method to decide whether skipping the sealing.
 	fullTaskHook func()                             // Method to call before pushing the full sealing task.
 	resubmitHook func(time.Duration, time.Duration) // Method to call upon updating resubmitting interval.
+
+	timestampHook func() uint64        // CHANGE(taiko): Method to fix the timestamp of built transactions lists.
+	headHook      func() *types.Header // CHANGE(taiko): Method to fix the head transactions lists are built on.
 }
 
 func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(header *types.Header) bool, init bool) *worker {
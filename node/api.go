@@ -179,6 +179,10 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			responseSizeLimit:      api.node.config.ResponseMaxSize,
+			access:                 api.node.rpcAccess,
+			audit:                  api.node.rpcAudit,
+			cache:                  api.node.rpcCache,
 		},
 	}
 	if cors != nil {
@@ -257,6 +261,10 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			responseSizeLimit:      api.node.config.ResponseMaxSize,
+			access:                 api.node.rpcAccess,
+			audit:                  api.node.rpcAudit,
+			cache:                  api.node.rpcCache,
 		},
 	}
 	if apis != nil {
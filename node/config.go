@@ -204,9 +204,29 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// ResponseMaxSize is the maximum number of bytes returned from a single rpc call,
+	// zero meaning unlimited. Streamed results are cut off once they exceed it.
+	ResponseMaxSize int `toml:",omitempty"`
+
+	// RPCAccess restricts the methods served over HTTP and WebSocket, and limits the
+	// rate of requests per client IP. It doesn't apply to the authenticated endpoints.
+	RPCAccess rpc.AccessPolicy `toml:",omitempty"`
+
+	// AuthRPCAccess restricts the methods served over the authenticated endpoints,
+	// and limits the rate of requests per client IP and JWT subject.
+	AuthRPCAccess rpc.AccessPolicy `toml:",omitempty"`
+
+	// RPCAudit enables the audit log of the calls served over HTTP and WebSocket.
+	RPCAudit rpc.AuditPolicy `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
+	// JWTSecretsDir is the path to a directory of additional named keys accepted
+	// for authentication: hex-encoded HS256 secrets or PEM-encoded ES256/EdDSA
+	// public keys. The directory is reloaded whenever it changes.
+	JWTSecretsDir string `toml:",omitempty"`
+
 	// EnablePersonal enables the deprecated personal namespace.
 	EnablePersonal bool `toml:"-"`
 
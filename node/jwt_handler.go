@@ -21,22 +21,20 @@ import (
 	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 const jwtExpiryTimeout = 60 * time.Second
 
 type jwtHandler struct {
-	keyFunc func(token *jwt.Token) (interface{}, error)
-	next    http.Handler
+	keys *jwtKeyStore
+	next http.Handler
 }
 
 // newJWTHandler creates a http.Handler with jwt authentication support.
-func newJWTHandler(secret []byte, next http.Handler) http.Handler {
+func newJWTHandler(keys *jwtKeyStore, next http.Handler) http.Handler {
 	return &jwtHandler{
-		keyFunc: func(token *jwt.Token) (interface{}, error) {
-			return secret, nil
-		},
+		keys: keys,
 		next: next,
 	}
 }
@@ -45,7 +43,7 @@ func newJWTHandler(secret []byte, next http.Handler) http.Handler {
 func (handler *jwtHandler) ServeHTTP(out http.ResponseWriter, r *http.Request) {
 	var (
 		strToken string
-		claims   jwt.RegisteredClaims
+		claims   jwtClaims
 	)
 	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
 		strToken = strings.TrimPrefix(auth, "Bearer ")
@@ -54,18 +52,14 @@ func (handler *jwtHandler) ServeHTTP(out http.ResponseWriter, r *http.Request) {
 		http.Error(out, "missing token", http.StatusUnauthorized)
 		return
 	}
-	// We explicitly set only HS256 allowed, and also disables the
-	// claim-check: the RegisteredClaims internally requires 'iat' to
-	// be no later than 'now', but we allow for a bit of drift.
-	token, err := jwt.ParseWithClaims(strToken, &claims, handler.keyFunc,
-		jwt.WithValidMethods([]string{"HS256"}),
-		jwt.WithoutClaimsValidation())
+	// We explicitly set only the supported algorithms allowed, and also
+	// disable the claim-check: the RegisteredClaims internally requires
+	// 'iat' to be no later than 'now', but we allow for a bit of drift.
+	key, err := handler.keys.verify(strToken, &claims)
 
 	switch {
 	case err != nil:
 		http.Error(out, err.Error(), http.StatusUnauthorized)
-	case !token.Valid:
-		http.Error(out, "invalid token", http.StatusUnauthorized)
 	case !claims.VerifyExpiresAt(time.Now(), false): // optional
 		http.Error(out, "token is expired", http.StatusUnauthorized)
 	case claims.IssuedAt == nil:
@@ -75,6 +69,18 @@ func (handler *jwtHandler) ServeHTTP(out http.ResponseWriter, r *http.Request) {
 	case time.Until(claims.IssuedAt.Time) > jwtExpiryTimeout:
 		http.Error(out, "future token", http.StatusUnauthorized)
 	default:
-		handler.next.ServeHTTP(out, r)
+		// Tokens signed by a named key are attributed to the key, unless
+		// they carry a subject themselves.
+		ctx, subject := r.Context(), claims.Subject
+		if subject == "" {
+			subject = key.name
+		}
+		if subject != "" {
+			ctx = rpc.NewContextWithAuthSubject(ctx, subject)
+		}
+		if claims.Namespaces != nil {
+			ctx = rpc.NewContextWithAuthNamespaces(ctx, claims.Namespaces)
+		}
+		handler.next.ServeHTTP(out, r.WithContext(ctx))
 	}
 }
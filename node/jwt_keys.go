@@ -0,0 +1,269 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwtReloadDelay is the time waited after a change in the secrets directory
+// before reloading it, so that bursts of changes are picked up at once.
+const jwtReloadDelay = 500 * time.Millisecond
+
+// jwtAlgorithms are the signing algorithms accepted for authentication.
+var jwtAlgorithms = []string{"HS256", "ES256", "EdDSA"}
+
+var errNoJWTKey = errors.New("no key for token")
+
+// jwtKey is a key accepted for authenticating tokens.
+type jwtKey struct {
+	name string      // file name without extension, empty for the default secret
+	alg  string      // signing algorithm verified by the key
+	key  interface{} // []byte, *ecdsa.PublicKey or ed25519.PublicKey
+}
+
+// jwtClaims are the claims of an authentication token.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+
+	// Namespaces restricts the RPC namespaces the token grants access to.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// jwtKeyStore holds the keys accepted by the JWT handler: the default secret and
+// optionally the named keys of a directory, which is reloaded when it changes.
+// Tokens may select a named key by setting its name as the key id ("kid"),
+// otherwise all keys of the token's algorithm are tried.
+type jwtKeyStore struct {
+	defaultKey *jwtKey
+
+	mu   sync.RWMutex
+	keys map[string]*jwtKey // named keys of the directory
+
+	dir     string
+	watcher *fsnotify.Watcher
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newJWTKeyStore creates a key store accepting the given HS256 secret.
+func newJWTKeyStore(secret []byte) *jwtKeyStore {
+	store := &jwtKeyStore{keys: make(map[string]*jwtKey)}
+	if len(secret) != 0 {
+		store.defaultKey = &jwtKey{alg: "HS256", key: secret}
+	}
+	return store
+}
+
+// watchDir loads the named keys of the directory, and reloads them whenever the
+// directory changes until the store is closed.
+func (s *jwtKeyStore) watchDir(dir string) error {
+	keys, err := loadJWTKeys(dir)
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+	s.setKeys(keys)
+	s.dir, s.watcher, s.quit = dir, watcher, make(chan struct{})
+
+	s.wg.Add(1)
+	go s.loop()
+	return nil
+}
+
+// loop reloads the keys after changes in the directory.
+func (s *jwtKeyStore) loop() {
+	defer s.wg.Done()
+
+	var (
+		reload  = time.NewTimer(0)
+		pending bool
+	)
+	<-reload.C
+	defer reload.Stop()
+
+	for {
+		select {
+		case <-s.watcher.Events:
+			if !pending {
+				reload.Reset(jwtReloadDelay)
+				pending = true
+			}
+		case err := <-s.watcher.Errors:
+			log.Warn("JWT secrets directory watcher failed", "dir", s.dir, "err", err)
+		case <-reload.C:
+			pending = false
+			keys, err := loadJWTKeys(s.dir)
+			if err != nil {
+				log.Error("Failed to reload JWT secrets, keeping previous ones", "dir", s.dir, "err", err)
+				continue
+			}
+			s.setKeys(keys)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// close stops watching the secrets directory.
+func (s *jwtKeyStore) close() {
+	if s.watcher == nil {
+		return
+	}
+	close(s.quit)
+	s.wg.Wait()
+	s.watcher.Close()
+}
+
+func (s *jwtKeyStore) setKeys(keys map[string]*jwtKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = keys
+	log.Info("Loaded JWT secrets", "dir", s.dir, "keys", len(keys))
+}
+
+// candidates returns the keys that may have signed a token with the given
+// algorithm and key id.
+func (s *jwtKeyStore) candidates(alg, kid string) []*jwtKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid != "" {
+		if key := s.keys[kid]; key != nil && key.alg == alg {
+			return []*jwtKey{key}
+		}
+		return nil
+	}
+	var keys []*jwtKey
+	if s.defaultKey != nil && s.defaultKey.alg == alg {
+		keys = append(keys, s.defaultKey)
+	}
+	for _, key := range s.keys {
+		if key.alg == alg {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// verify checks the signature of the token, returning the key that signed it.
+// The claims are parsed, but not validated.
+func (s *jwtKeyStore) verify(token string, claims *jwtClaims) (*jwtKey, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods(jwtAlgorithms), jwt.WithoutClaimsValidation())
+
+	unverified, _, err := parser.ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, err
+	}
+	kid, _ := unverified.Header["kid"].(string)
+	keys := s.candidates(unverified.Method.Alg(), kid)
+	if len(keys) == 0 {
+		return nil, errNoJWTKey
+	}
+	for _, key := range keys {
+		*claims = jwtClaims{}
+		_, err = parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+			return key.key, nil
+		})
+		if err == nil {
+			return key, nil
+		}
+	}
+	return nil, err
+}
+
+// loadJWTKeys reads the named keys of a directory. Every file holds one key,
+// named after the file without its extension: either a hex encoded 32 byte
+// HS256 secret, or a PEM encoded P-256 (ES256) or Ed25519 (EdDSA) public key.
+func loadJWTKeys(dir string) (map[string]*jwtKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*jwtKey)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		// Follow symlinks, secrets mounted by Kubernetes or Docker are links
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if keys[name] != nil {
+			return nil, fmt.Errorf("duplicate JWT key %q", name)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseJWTKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT key %s: %v", entry.Name(), err)
+		}
+		key.name = name
+		keys[name] = key
+	}
+	return keys, nil
+}
+
+// parseJWTKey decodes a secret or public key.
+func parseJWTKey(data []byte) (*jwtKey, error) {
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+			if key.Curve != elliptic.P256() {
+				return nil, fmt.Errorf("unsupported curve %s", key.Curve.Params().Name)
+			}
+			return &jwtKey{alg: "ES256", key: key}, nil
+		}
+		if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+			return &jwtKey{alg: "EdDSA", key: key}, nil
+		}
+		return nil, errors.New("unsupported public key")
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid secret length %d", len(secret))
+	}
+	return &jwtKey{alg: "HS256", key: secret}, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// keyAuth creates tokens signed by the given key, with optional key id and
+// namespace restriction.
+func keyAuth(method jwt.SigningMethod, key interface{}, kid string, namespaces []string) rpc.HTTPAuth {
+	return func(h http.Header) error {
+		claims := jwt.MapClaims{"iat": &jwt.NumericDate{Time: time.Now()}}
+		if namespaces != nil {
+			claims["namespaces"] = namespaces
+		}
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			return fmt.Errorf("failed to create JWT token: %w", err)
+		}
+		h.Set("Authorization", "Bearer "+s)
+		return nil
+	}
+}
+
+func writePublicKey(t *testing.T, path string, key interface{}) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJWTSecretsDir(t *testing.T) {
+	dir := t.TempDir()
+
+	// Populate the directory with a secret and public keys of either kind
+	driverSecret := make([]byte, 32)
+	crand.Read(driverSecret)
+	if err := os.WriteFile(filepath.Join(dir, "driver.hex"), []byte(hexutil.Encode(driverSecret)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	proposerKey, _ := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	writePublicKey(t, filepath.Join(dir, "proposer.pem"), &proposerKey.PublicKey)
+
+	proverPub, proverKey, _ := ed25519.GenerateKey(crand.Reader)
+	writePublicKey(t, filepath.Join(dir, "prover.pem"), proverPub)
+
+	conf := &Config{
+		AuthAddr:      "127.0.0.1",
+		AuthPort:      0,
+		JWTSecret:     filepath.Join(t.TempDir(), "jwt_secret"),
+		JWTSecretsDir: dir,
+	}
+	node, err := New(conf)
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs([]rpc.API{
+		{Namespace: "engine", Service: helloRPC("hello engine"), Authenticated: true},
+		{Namespace: rpc.TaikoAuth, Service: helloRPC("hello taiko"), Authenticated: true},
+	})
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start test node: %v", err)
+	}
+	defer node.Close()
+
+	call := func(auth rpc.HTTPAuth, method string) error {
+		cl, err := rpc.DialOptions(context.Background(), node.HTTPAuthEndpoint(), rpc.WithHTTPAuth(auth))
+		if err != nil {
+			return err
+		}
+		defer cl.Close()
+		var res string
+		return cl.Call(&res, method)
+	}
+	otherSecret := make([]byte, 32)
+	crand.Read(otherSecret)
+
+	for _, tt := range []struct {
+		name   string
+		auth   rpc.HTTPAuth
+		method string
+		ok     bool
+	}{
+		{"hs256 by kid", keyAuth(jwt.SigningMethodHS256, driverSecret, "driver", nil), "engine_helloWorld", true},
+		{"hs256 without kid", keyAuth(jwt.SigningMethodHS256, driverSecret, "", nil), "engine_helloWorld", true},
+		{"hs256 wrong kid", keyAuth(jwt.SigningMethodHS256, driverSecret, "proposer", nil), "engine_helloWorld", false},
+		{"hs256 unknown", keyAuth(jwt.SigningMethodHS256, otherSecret, "", nil), "engine_helloWorld", false},
+		{"es256", keyAuth(jwt.SigningMethodES256, proposerKey, "proposer", nil), "engine_helloWorld", true},
+		{"eddsa", keyAuth(jwt.SigningMethodEdDSA, proverKey, "", nil), "engine_helloWorld", true},
+		{"scoped allowed", keyAuth(jwt.SigningMethodES256, proposerKey, "", []string{rpc.TaikoAuth}), "taikoAuth_helloWorld", true},
+		{"scoped denied", keyAuth(jwt.SigningMethodES256, proposerKey, "", []string{rpc.TaikoAuth}), "engine_helloWorld", false},
+	} {
+		if err := call(tt.auth, tt.method); (err == nil) != tt.ok {
+			t.Errorf("%s: expected success %v, got error %v", tt.name, tt.ok, err)
+		}
+	}
+
+	// Add a secret and make sure it's picked up without restart
+	lateSecret := make([]byte, 32)
+	crand.Read(lateSecret)
+	if err := os.WriteFile(filepath.Join(dir, "late.hex"), []byte(hexutil.Encode(lateSecret)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lateAuth := keyAuth(jwt.SigningMethodHS256, lateSecret, "late", nil)
+	waitFor := func(ok bool) {
+		deadline := time.Now().Add(5 * time.Second)
+		for (call(lateAuth, "engine_helloWorld") == nil) != ok {
+			if time.Now().After(deadline) {
+				t.Fatalf("key reload timed out, expecting success %v", ok)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	waitFor(true)
+
+	// Remove it again and check it's no longer accepted
+	if err := os.Remove(filepath.Join(dir, "late.hex")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(false)
+}
+
+func TestLoadJWTKeysInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "short.hex"), []byte("0x1234"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadJWTKeys(dir); err == nil {
+		t.Fatal("short secret accepted")
+	}
+	// Only P-256 keys are supported for ECDSA
+	os.Remove(filepath.Join(dir, "short.hex"))
+	key, _ := ecdsa.GenerateKey(elliptic.P384(), crand.Reader)
+	writePublicKey(t, filepath.Join(dir, "p384.pem"), &key.PublicKey)
+	if _, err := loadJWTKeys(dir); err == nil {
+		t.Fatal("P-384 key accepted")
+	}
+}
+
+// Tests that keys mounted as symlinks, the way Kubernetes and Docker secrets are,
+// get loaded, while linked directories are skipped.
+func TestLoadJWTKeysSymlinks(t *testing.T) {
+	dir := t.TempDir()
+
+	// Mimic a Kubernetes secret volume: key -> ..data/key, ..data -> ..timestamp
+	data := filepath.Join(dir, "..2024_01_01")
+	if err := os.Mkdir(data, 0700); err != nil {
+		t.Fatal(err)
+	}
+	secret := make([]byte, 32)
+	crand.Read(secret)
+	if err := os.WriteFile(filepath.Join(data, "driver.hex"), []byte(hexutil.Encode(secret)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Base(data), filepath.Join(dir, "..data")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "driver.hex"), filepath.Join(dir, "driver.hex")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Base(data), filepath.Join(dir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := loadJWTKeys(dir)
+	if err != nil {
+		t.Fatalf("failed to load keys: %v", err)
+	}
+	if len(keys) != 1 || keys["driver"] == nil {
+		t.Fatalf("unexpected keys loaded: %v", keys)
+	}
+	if !bytes.Equal(keys["driver"].key.([]byte), secret) {
+		t.Fatal("symlinked secret mismatch")
+	}
+}
@@ -55,14 +55,19 @@ type Node struct {
 	state         int           // Tracks state of node lifecycle
 
 	lock          sync.Mutex
-	lifecycles    []Lifecycle // All registered backends, services, and auxiliary services that have a lifecycle
-	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
-	http          *httpServer //
-	ws            *httpServer //
-	httpAuth      *httpServer //
-	wsAuth        *httpServer //
-	ipc           *ipcServer  // Stores information about the ipc http server
-	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	lifecycles    []Lifecycle        // All registered backends, services, and auxiliary services that have a lifecycle
+	rpcAPIs       []rpc.API          // List of APIs currently provided by the node
+	http          *httpServer        //
+	ws            *httpServer        //
+	httpAuth      *httpServer        //
+	wsAuth        *httpServer        //
+	ipc           *ipcServer         // Stores information about the ipc http server
+	inprocHandler *rpc.Server        // In-process RPC request handler to process the API requests
+	rpcAccess     *rpc.AccessControl // Access control shared by the HTTP and WebSocket servers
+	authAccess    *rpc.AccessControl // Access control shared by the authenticated servers
+	rpcCache      rpc.ResponseCache  // Response cache shared by the HTTP and WebSocket servers
+	rpcAudit      *rpc.AuditLog      // Audit log shared by the HTTP and WebSocket servers
+	jwtKeys       *jwtKeyStore       // Keys of the authenticated endpoints, if loaded from a directory
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 }
@@ -103,6 +108,7 @@ func New(conf *Config) (*Node, error) {
 	}
 	server := rpc.NewServer()
 	server.SetBatchLimits(conf.BatchRequestLimit, conf.BatchResponseMaxSize)
+	server.SetResponseSizeLimit(conf.ResponseMaxSize)
 	node := &Node{
 		config:        conf,
 		inprocHandler: server,
@@ -146,6 +152,21 @@ func New(conf *Config) (*Node, error) {
 	if err := validatePrefix("WebSocket", conf.WSPathPrefix); err != nil {
 		return nil, err
 	}
+	if !conf.RPCAccess.IsZero() {
+		if node.rpcAccess, err = rpc.NewAccessControl(conf.RPCAccess); err != nil {
+			return nil, err
+		}
+	}
+	if !conf.AuthRPCAccess.IsZero() {
+		if node.authAccess, err = rpc.NewAccessControl(conf.AuthRPCAccess); err != nil {
+			return nil, fmt.Errorf("invalid authenticated RPC access policy: %v", err)
+		}
+	}
+	if !conf.RPCAudit.IsZero() {
+		if node.rpcAudit, err = rpc.NewAuditLog(conf.RPCAudit); err != nil {
+			return nil, err
+		}
+	}
 
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
@@ -239,6 +260,11 @@ func (n *Node) doClose(errs []error) error {
 	if err := n.accman.Close(); err != nil {
 		errs = append(errs, err)
 	}
+	if n.rpcAudit != nil {
+		if err := n.rpcAudit.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if n.keyDirTemp {
 		if err := os.RemoveAll(n.keyDir); err != nil {
 			errs = append(errs, err)
@@ -407,6 +433,10 @@ func (n *Node) startRPC() error {
 	rpcConfig := rpcEndpointConfig{
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
+		responseSizeLimit:      n.config.ResponseMaxSize,
+		access:                 n.rpcAccess,
+		audit:                  n.rpcAudit,
+		cache:                  n.rpcCache,
 	}
 
 	initHttp := func(server *httpServer, port int) error {
@@ -443,17 +473,19 @@ func (n *Node) startRPC() error {
 		return nil
 	}
 
-	initAuth := func(port int, secret []byte) error {
+	initAuth := func(port int, keys *jwtKeyStore) error {
 		// Enable auth via HTTP
 		server := n.httpAuth
 		if err := server.setListenAddr(n.config.AuthAddr, port); err != nil {
 			return err
 		}
 		sharedConfig := rpcEndpointConfig{
-			jwtSecret:              secret,
+			jwtKeys:                keys,
 			batchItemLimit:         engineAPIBatchItemLimit,
 			batchResponseSizeLimit: engineAPIBatchResponseSizeLimit,
 			httpBodyLimit:          engineAPIBodyLimit,
+			access:                 n.authAccess,
+			audit:                  n.rpcAudit,
 		}
 		err := server.enableRPC(allAPIs, httpConfig{
 			CorsAllowedOrigins: DefaultAuthCors,
@@ -504,7 +536,14 @@ func (n *Node) startRPC() error {
 		if err != nil {
 			return err
 		}
-		if err := initAuth(n.config.AuthPort, jwtSecret); err != nil {
+		keys := newJWTKeyStore(jwtSecret)
+		if n.config.JWTSecretsDir != "" {
+			if err := keys.watchDir(n.config.JWTSecretsDir); err != nil {
+				return fmt.Errorf("failed to load JWT secrets: %w", err)
+			}
+			n.jwtKeys = keys
+		}
+		if err := initAuth(n.config.AuthPort, keys); err != nil {
 			return err
 		}
 	}
@@ -535,6 +574,10 @@ func (n *Node) stopRPC() {
 	n.wsAuth.stop()
 	n.ipc.stop()
 	n.stopInProc()
+	if n.jwtKeys != nil {
+		n.jwtKeys.close()
+		n.jwtKeys = nil
+	}
 }
 
 // startInProc registers all RPC APIs on the inproc server.
@@ -593,6 +636,18 @@ func (n *Node) RegisterAPIs(apis []rpc.API) {
 	n.rpcAPIs = append(n.rpcAPIs, apis...)
 }
 
+// SetResponseCache sets the cache serving the results of method calls on the HTTP
+// and WebSocket endpoints.
+func (n *Node) SetResponseCache(cache rpc.ResponseCache) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != initializingState {
+		panic("can't set response cache on running/stopped node")
+	}
+	n.rpcCache = cache
+}
+
 // getAPIs return two sets of APIs, both the ones that do not require
 // authentication, and the complete set
 func (n *Node) getAPIs() (unauthenticated, all []rpc.API) {
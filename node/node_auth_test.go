@@ -235,3 +235,59 @@ func offsetTimeAuth(secret [32]byte, offset time.Duration) rpc.HTTPAuth {
 		return nil
 	}
 }
+
+// Tests that the access policy of the public endpoints doesn't restrict the
+// authenticated ones, which have a policy of their own.
+func TestAuthEndpointsAccess(t *testing.T) {
+	var secret [32]byte
+	if _, err := crand.Read(secret[:]); err != nil {
+		t.Fatalf("failed to create jwt secret: %v", err)
+	}
+	jwtPath := path.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(jwtPath, []byte(hexutil.Encode(secret[:])), 0600); err != nil {
+		t.Fatalf("failed to prepare jwt secret file: %v", err)
+	}
+	conf := &Config{
+		HTTPHost:    "127.0.0.1",
+		AuthAddr:    "127.0.0.1",
+		JWTSecret:   jwtPath,
+		HTTPModules: []string{"eth", "engine"},
+		RPCAccess: rpc.AccessPolicy{
+			Allow:   []string{"eth_*"},
+			IPQuota: rpc.Quota{Rate: 0.001, Burst: 1},
+		},
+		AuthRPCAccess: rpc.AccessPolicy{
+			Deny: []string{"eth_*"},
+		},
+	}
+	node, err := New(conf)
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs([]rpc.API{
+		{Namespace: "engine", Service: helloRPC("hello engine"), Authenticated: true},
+		{Namespace: "eth", Service: helloRPC("hello eth"), Authenticated: true},
+	})
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start test node: %v", err)
+	}
+	defer node.Close()
+
+	ctx := context.Background()
+	auth, err := rpc.DialOptions(ctx, node.HTTPAuthEndpoint(), rpc.WithHTTPAuth(NewJWTAuth(secret)))
+	if err != nil {
+		t.Fatalf("failed to dial auth endpoint: %v", err)
+	}
+	defer auth.Close()
+
+	// Repeated engine calls pass neither the public allowlist nor its IP quota
+	var x string
+	for i := 0; i < 3; i++ {
+		if err := auth.CallContext(ctx, &x, "engine_helloWorld"); err != nil {
+			t.Fatalf("call %d to authenticated endpoint failed: %v", i, err)
+		}
+	}
+	if err := auth.CallContext(ctx, &x, "eth_helloWorld"); err == nil {
+		t.Fatal("authenticated endpoint served method denied by its policy")
+	}
+}
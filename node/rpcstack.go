@@ -53,10 +53,15 @@ type wsConfig struct {
 }
 
 type rpcEndpointConfig struct {
-	jwtSecret              []byte // optional JWT secret
+	jwtSecret              []byte       // optional JWT secret
+	jwtKeys                *jwtKeyStore // optional JWT keys, replacing the secret
 	batchItemLimit         int
 	batchResponseSizeLimit int
+	responseSizeLimit      int
 	httpBodyLimit          int
+	access                 *rpc.AccessControl // optional method access control
+	cache                  rpc.ResponseCache  // optional response cache
+	audit                  *rpc.AuditLog      // optional audit log
 }
 
 type rpcHandler struct {
@@ -172,7 +177,7 @@ func (h *httpServer) start() error {
 	}
 	// Log http endpoint.
 	h.log.Info("HTTP server started",
-		"endpoint", listener.Addr(), "auth", (h.httpConfig.jwtSecret != nil),
+		"endpoint", listener.Addr(), "auth", (h.httpConfig.jwtKeyStore() != nil),
 		"prefix", h.httpConfig.prefix,
 		"cors", strings.Join(h.httpConfig.CorsAllowedOrigins, ","),
 		"vhosts", strings.Join(h.httpConfig.Vhosts, ","),
@@ -198,10 +203,8 @@ func (h *httpServer) start() error {
 func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// check if ws request and serve if ws enabled
 	ws := h.wsHandler.Load().(*rpcHandler)
-	if ws != nil && isWebsocket(r) {
-		if checkPath(r, h.wsConfig.prefix) {
-			ws.ServeHTTP(w, r)
-		}
+	if ws != nil && isWebsocket(r) && checkPath(r, h.wsConfig.prefix) {
+		ws.ServeHTTP(w, r)
 		return
 	}
 
@@ -305,6 +308,10 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
 	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
+	srv.SetResponseSizeLimit(config.responseSizeLimit)
+	srv.SetAccessControl(config.access)
+	srv.SetAuditLog(config.audit)
+	srv.SetResponseCache(config.cache)
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
@@ -313,7 +320,7 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	}
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret),
+		Handler: newHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtKeyStore()),
 		server:  srv,
 	})
 	return nil
@@ -340,6 +347,10 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
 	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
+	srv.SetResponseSizeLimit(config.responseSizeLimit)
+	srv.SetAccessControl(config.access)
+	srv.SetAuditLog(config.audit)
+	srv.SetResponseCache(config.cache)
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
@@ -348,7 +359,7 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	}
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
-		Handler: NewWSHandlerStack(srv.WebsocketHandler(config.Origins), config.jwtSecret),
+		Handler: newWSHandlerStack(srv.WebsocketHandler(config.Origins), config.jwtKeyStore()),
 		server:  srv,
 	})
 	return nil
@@ -392,21 +403,49 @@ func isWebsocket(r *http.Request) bool {
 		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
 }
 
+// jwtKeyStore returns the keys authenticating requests, or nil if the endpoint
+// is unauthenticated.
+func (c *rpcEndpointConfig) jwtKeyStore() *jwtKeyStore {
+	if c.jwtKeys != nil {
+		return c.jwtKeys
+	}
+	if len(c.jwtSecret) != 0 {
+		return newJWTKeyStore(c.jwtSecret)
+	}
+	return nil
+}
+
 // NewHTTPHandlerStack returns wrapped http-related handlers
 func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, jwtSecret []byte) http.Handler {
+	var keys *jwtKeyStore
+	if len(jwtSecret) != 0 {
+		keys = newJWTKeyStore(jwtSecret)
+	}
+	return newHTTPHandlerStack(srv, cors, vhosts, keys)
+}
+
+func newHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, keys *jwtKeyStore) http.Handler {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
 	handler = newVHostHandler(vhosts, handler)
-	if len(jwtSecret) != 0 {
-		handler = newJWTHandler(jwtSecret, handler)
+	if keys != nil {
+		handler = newJWTHandler(keys, handler)
 	}
 	return newGzipHandler(handler)
 }
 
 // NewWSHandlerStack returns a wrapped ws-related handler.
 func NewWSHandlerStack(srv http.Handler, jwtSecret []byte) http.Handler {
+	var keys *jwtKeyStore
 	if len(jwtSecret) != 0 {
-		return newJWTHandler(jwtSecret, srv)
+		keys = newJWTKeyStore(jwtSecret)
+	}
+	return newWSHandlerStack(srv, keys)
+}
+
+func newWSHandlerStack(srv http.Handler, keys *jwtKeyStore) http.Handler {
+	if keys != nil {
+		return newJWTHandler(keys, srv)
 	}
 	return srv
 }
@@ -434,6 +473,12 @@ type virtualHostHandler struct {
 	next   http.Handler
 }
 
+// NewVHostHandler returns a handler rejecting the requests whose host is not one
+// of the given virtual hosts.
+func NewVHostHandler(vhosts []string, next http.Handler) http.Handler {
+	return newVHostHandler(vhosts, next)
+}
+
 func newVHostHandler(vhosts []string, next http.Handler) http.Handler {
 	vhostMap := make(map[string]struct{})
 	for _, allowedHost := range vhosts {
@@ -380,6 +380,10 @@ type ChainConfig struct {
 	// CHANGE(taiko): Taiko network flag.
 	Taiko       bool     `json:"taiko"`
 	OntakeBlock *big.Int `json:"ontakeBlock,omitempty"` // Ontake switch block (nil = no fork, 0 = already activated)
+
+	// CHANGE(taiko): DisableBlobTxs disables blob transactions on networks whose
+	// blocks don't include them. Always set for Taiko networks.
+	DisableBlobTxs bool `json:"disableBlobTxs,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -601,6 +605,13 @@ func (c *ChainConfig) IsOntake(num *big.Int) bool {
 	return isBlockForked(c.OntakeBlock, num)
 }
 
+// CHANGE(taiko): BlobTxsDisabled returns whether blob transactions are neither
+// pooled nor exchanged with peers, as the blocks of the network can't include
+// them.
+func (c *ChainConfig) BlobTxsDisabled() bool {
+	return c.Taiko || c.DisableBlobTxs
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64, time uint64) *ConfigCompatError {
@@ -32,6 +32,14 @@ const (
 	// considered probably final and its rotated bits are calculated.
 	BloomConfirms = 256
 
+	// LogIndexBlocks is the number of blocks a single log index section contains.
+	// Blocks are indexed one by one to keep the index up to date with the head.
+	LogIndexBlocks uint64 = 1
+
+	// LogIndexConfirms is the number of confirmation blocks before a log index
+	// section is processed. Reorgs are rolled back by the indexer itself.
+	LogIndexConfirms = 0
+
 	// CHTFrequency is the block frequency for creating CHTs
 	CHTFrequency = 32768
 
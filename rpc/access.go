@@ -0,0 +1,235 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"path"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxQuotaBuckets is the number of clients tracked by a quota before buckets
+// which refilled completely are dropped.
+const maxQuotaBuckets = 65536
+
+// AccessPolicy configures the methods served by a server and the rate at which
+// clients may call them. Method patterns are matched using path.Match, e.g.
+// "debug_*" matches all methods of the debug namespace. The zero value serves
+// all methods without limits.
+type AccessPolicy struct {
+	Allow []string `toml:",omitempty"` // Methods served, all if empty
+	Deny  []string `toml:",omitempty"` // Methods refused, takes precedence over Allow
+
+	IPQuota      Quota `toml:",omitempty"` // Quota of every client IP address
+	SubjectQuota Quota `toml:",omitempty"` // Quota of every JWT subject
+
+	Weights map[string]int `toml:",omitempty"` // Quota cost of method calls, 1 if unset
+}
+
+// Quota is a token bucket which refills at Rate tokens per second, holding at
+// most Burst tokens. Every call draws its weight from the bucket. A zero rate
+// disables the quota.
+type Quota struct {
+	Rate  float64 `toml:",omitempty"`
+	Burst int     `toml:",omitempty"`
+}
+
+// IsZero reports whether the policy doesn't restrict anything.
+func (p *AccessPolicy) IsZero() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0 && p.IPQuota.Rate == 0 && p.SubjectQuota.Rate == 0
+}
+
+// AccessControl enforces an access policy. It can be shared by multiple servers,
+// in which case the quotas apply across all of them.
+type AccessControl struct {
+	allow    []string
+	deny     []string
+	weights  map[string]int
+	ips      *quotaBuckets
+	subjects *quotaBuckets
+}
+
+// NewAccessControl validates the policy and creates the enforcer for it.
+func NewAccessControl(policy AccessPolicy) (*AccessControl, error) {
+	for _, pattern := range append(append([]string{}, policy.Allow...), policy.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid method pattern %q: %v", pattern, err)
+		}
+	}
+	ac := &AccessControl{
+		allow:   policy.Allow,
+		deny:    policy.Deny,
+		weights: policy.Weights,
+	}
+	var err error
+	if ac.ips, err = newQuotaBuckets(policy.IPQuota); err != nil {
+		return nil, fmt.Errorf("invalid IP quota: %v", err)
+	}
+	if ac.subjects, err = newQuotaBuckets(policy.SubjectQuota); err != nil {
+		return nil, fmt.Errorf("invalid subject quota: %v", err)
+	}
+	for method, weight := range policy.Weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("negative weight %d for method %s", weight, method)
+		}
+		for _, q := range []*quotaBuckets{ac.ips, ac.subjects} {
+			if q != nil && weight > q.burst {
+				return nil, fmt.Errorf("weight %d of method %s exceeds quota burst %d", weight, method, q.burst)
+			}
+		}
+	}
+	return ac, nil
+}
+
+// allowed reports whether the method may be served.
+func (ac *AccessControl) allowed(method string) bool {
+	for _, pattern := range ac.deny {
+		if ok, _ := path.Match(pattern, method); ok {
+			return false
+		}
+	}
+	if len(ac.allow) == 0 {
+		return true
+	}
+	for _, pattern := range ac.allow {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// weight returns the quota cost of a method call.
+func (ac *AccessControl) weight(method string) int {
+	if weight, ok := ac.weights[method]; ok {
+		return weight
+	}
+	return 1
+}
+
+// check verifies that the client issuing the call may invoke the method, and
+// charges the call to its quotas.
+func (ac *AccessControl) check(ctx context.Context, method string) error {
+	if ac == nil {
+		return nil
+	}
+	if !ac.allowed(method) {
+		rpcDeniedMeter.Mark(1)
+		return &methodNotAllowedError{method: method}
+	}
+	var (
+		info   = PeerInfoFromContext(ctx)
+		weight = ac.weight(method)
+		now    = time.Now()
+	)
+	if ac.ips != nil && info.RemoteAddr != "" {
+		host, _, err := net.SplitHostPort(info.RemoteAddr)
+		if err != nil {
+			host = info.RemoteAddr
+		}
+		if !ac.ips.take(host, weight, now) {
+			rpcIPLimitedMeter.Mark(1)
+			return &rateLimitedError{}
+		}
+	}
+	if ac.subjects != nil && info.AuthSubject != "" {
+		if !ac.subjects.take(info.AuthSubject, weight, now) {
+			rpcSubjectLimitedMeter.Mark(1)
+			return &rateLimitedError{}
+		}
+	}
+	return nil
+}
+
+// checkAuthNamespaces verifies that the token the client authenticated with grants
+// access to the namespace of the called method. Service discovery is always
+// granted, it only lists the methods of the namespaces granted.
+func checkAuthNamespaces(ctx context.Context, msg *jsonrpcMessage) error {
+	namespaces := PeerInfoFromContext(ctx).AuthNamespaces
+	if namespaces == nil || msg.Method == discoverMethod {
+		return nil
+	}
+	namespace := msg.namespace()
+	for _, allowed := range namespaces {
+		if allowed == namespace {
+			return nil
+		}
+	}
+	rpcDeniedMeter.Mark(1)
+	return &methodNotAllowedError{method: msg.Method}
+}
+
+// quotaBuckets tracks the token buckets of the clients of a quota.
+type quotaBuckets struct {
+	limit rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*rate.Limiter
+}
+
+// newQuotaBuckets creates the buckets of a quota, or nil if it's disabled.
+func newQuotaBuckets(quota Quota) (*quotaBuckets, error) {
+	if quota.Rate == 0 {
+		return nil, nil
+	}
+	if quota.Rate < 0 || quota.Burst < 0 {
+		return nil, fmt.Errorf("negative rate %v or burst %d", quota.Rate, quota.Burst)
+	}
+	burst := quota.Burst
+	if burst == 0 {
+		burst = int(math.Ceil(quota.Rate))
+	}
+	return &quotaBuckets{
+		limit:   rate.Limit(quota.Rate),
+		burst:   burst,
+		buckets: make(map[string]*rate.Limiter),
+	}, nil
+}
+
+// take draws tokens from the bucket of the given client, reporting whether it
+// held enough of them.
+func (q *quotaBuckets) take(key string, tokens int, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	bucket := q.buckets[key]
+	if bucket == nil {
+		if len(q.buckets) >= maxQuotaBuckets {
+			q.prune(now)
+		}
+		bucket = rate.NewLimiter(q.limit, q.burst)
+		q.buckets[key] = bucket
+	}
+	return bucket.AllowN(now, tokens)
+}
+
+// prune drops the buckets which refilled completely. They're equivalent to the
+// fresh buckets created on the next call of their clients.
+func (q *quotaBuckets) prune(now time.Time) {
+	for key, bucket := range q.buckets {
+		if bucket.TokensAt(now) >= float64(q.burst) {
+			delete(q.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessControlMethods(t *testing.T) {
+	t.Parallel()
+
+	ac, err := NewAccessControl(AccessPolicy{
+		Allow: []string{"test_*", "rpc_modules"},
+		Deny:  []string{"test_echo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	server.SetAccessControl(ac)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	for _, tt := range []struct {
+		method  string
+		allowed bool
+	}{
+		{"test_null", true},
+		{"rpc_modules", true},
+		{"test_echo", false},
+		{"nftest_echo", false},
+	} {
+		var (
+			result interface{}
+			args   []interface{}
+		)
+		if tt.method == "nftest_echo" || tt.method == "test_echo" {
+			args = []interface{}{1}
+		}
+		err := client.Call(&result, tt.method, args...)
+		var rpcErr Error
+		denied := errors.As(err, &rpcErr) && rpcErr.Error() == (&methodNotAllowedError{tt.method}).Error()
+		if denied == tt.allowed {
+			t.Errorf("%s: allowed %v, have error %v", tt.method, tt.allowed, err)
+		}
+	}
+}
+
+func TestAccessControlInvalidPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []AccessPolicy{
+		{Allow: []string{"eth_["}},
+		{IPQuota: Quota{Rate: -1}},
+		{IPQuota: Quota{Rate: 1, Burst: 5}, Weights: map[string]int{"debug_traceTransaction": 10}},
+	} {
+		if _, err := NewAccessControl(policy); err == nil {
+			t.Errorf("policy %+v accepted", policy)
+		}
+	}
+}
+
+func TestAccessControlIPQuota(t *testing.T) {
+	t.Parallel()
+
+	// The bucket refills far slower than the test runs
+	ac, err := NewAccessControl(AccessPolicy{
+		IPQuota: Quota{Rate: 0.001, Burst: 5},
+		Weights: map[string]int{"test_echo": 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	server.SetAccessControl(ac)
+	defer server.Stop()
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var result interface{}
+	if err := client.Call(&result, "test_echo", "x", 1); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	if err := client.Call(&result, "test_null"); err != nil {
+		t.Fatalf("second call failed: %v", err)
+	}
+	// Only one token remains, not enough for the weighted method
+	err = client.Call(&result, "test_echo", "x", 1)
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != errcodeLimitExceeded {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if err := client.Call(&result, "test_null"); err != nil {
+		t.Fatalf("last call failed: %v", err)
+	}
+	if err := client.Call(&result, "test_null"); err == nil {
+		t.Fatal("expected rate limit error after exhausting the quota")
+	}
+}
+
+func TestAccessControlSubjectQuota(t *testing.T) {
+	t.Parallel()
+
+	ac, err := NewAccessControl(AccessPolicy{
+		SubjectQuota: Quota{Rate: 0.001, Burst: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	server.SetAccessControl(ac)
+	defer server.Stop()
+
+	// Emulate an authenticating handler in front of the server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := r.Header.Get("X-Subject")
+		server.ServeHTTP(w, r.WithContext(NewContextWithAuthSubject(r.Context(), subject)))
+	}))
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetHeader("X-Subject", "alice")
+
+	var info PeerInfo
+	if err := client.Call(&info, "test_peerInfo"); err != nil {
+		t.Fatal(err)
+	}
+	if info.AuthSubject != "alice" {
+		t.Errorf("wrong auth subject %q", info.AuthSubject)
+	}
+	if err := client.Call(&info, "test_peerInfo"); err == nil {
+		t.Fatal("expected rate limit error for exhausted subject")
+	}
+	// Other subjects have their own quota
+	ctx := NewContextWithHeaders(context.Background(), http.Header{"X-Subject": {"bob"}})
+	if err := client.CallContext(ctx, &info, "test_peerInfo"); err != nil {
+		t.Fatalf("call of other subject failed: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"path"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/exp/slog"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// maxAuditParamsSize is the size at which logged parameters are truncated.
+const maxAuditParamsSize = 4096
+
+// DefaultAuditRedact are the methods whose parameters are always redacted from the
+// audit log, as they carry passwords, keys or data to be signed.
+var DefaultAuditRedact = []string{"personal_*", "account_*", "eth_sign*"}
+
+// AuditPolicy configures the audit log of the calls served by a server. Method
+// patterns are matched using path.Match, like those of AccessPolicy.
+type AuditPolicy struct {
+	File       string             `toml:",omitempty"` // Log file, auditing is disabled if empty
+	JSON       bool               `toml:",omitempty"` // Write JSON lines instead of logfmt
+	MaxSize    int                `toml:",omitempty"` // Size in megabytes at which the file is rotated, 100 if zero
+	MaxBackups int                `toml:",omitempty"` // Number of rotated files retained, all if zero
+	Sampling   map[string]float64 `toml:",omitempty"` // Fraction of calls logged per namespace, "*" for the rest, all if unset
+	Redact     []string           `toml:",omitempty"` // Methods whose parameters are redacted, besides DefaultAuditRedact
+}
+
+// IsZero reports whether the policy doesn't enable auditing.
+func (p *AuditPolicy) IsZero() bool {
+	return p.File == ""
+}
+
+// AuditLog records the method calls served by a server: the method, parameters,
+// caller, latency, response size and error code. It can be shared by multiple
+// servers.
+type AuditLog struct {
+	log      log.Logger
+	out      io.Closer
+	sampling map[string]float64
+	redact   []string
+}
+
+// NewAuditLog validates the policy and opens the log file.
+func NewAuditLog(policy AuditPolicy) (*AuditLog, error) {
+	if policy.File == "" {
+		return nil, errors.New("no audit log file")
+	}
+	out := &lumberjack.Logger{
+		Filename:   policy.File,
+		MaxSize:    policy.MaxSize,
+		MaxBackups: policy.MaxBackups,
+	}
+	handler := log.LogfmtHandler(out)
+	if policy.JSON {
+		handler = log.JSONHandler(out)
+	}
+	return newAuditLog(handler, out, policy)
+}
+
+func newAuditLog(handler slog.Handler, out io.Closer, policy AuditPolicy) (*AuditLog, error) {
+	redact := append(append([]string{}, DefaultAuditRedact...), policy.Redact...)
+	for _, pattern := range redact {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid method pattern %q: %v", pattern, err)
+		}
+	}
+	for namespace, rate := range policy.Sampling {
+		if rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("sampling rate %v of namespace %s not within [0, 1]", rate, namespace)
+		}
+	}
+	return &AuditLog{
+		log:      log.NewLogger(handler),
+		out:      out,
+		sampling: policy.Sampling,
+		redact:   redact,
+	}, nil
+}
+
+// Close closes the log file.
+func (a *AuditLog) Close() error {
+	if a.out == nil {
+		return nil
+	}
+	return a.out.Close()
+}
+
+// sampled decides whether a call of the namespace is logged.
+func (a *AuditLog) sampled(namespace string) bool {
+	rate, ok := a.sampling[namespace]
+	if !ok {
+		if rate, ok = a.sampling["*"]; !ok {
+			return true
+		}
+	}
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// redacted reports whether the parameters of the method are omitted.
+func (a *AuditLog) redacted(method string) bool {
+	for _, pattern := range a.redact {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// auditEntry is the record of a call being served.
+type auditEntry struct {
+	a     *AuditLog
+	ctx   []interface{}
+	start time.Time
+}
+
+// begin starts the record of a call, returning nil if the call isn't sampled.
+func (a *AuditLog) begin(ctx context.Context, msg *jsonrpcMessage) *auditEntry {
+	if a == nil || !a.sampled(msg.namespace()) {
+		return nil
+	}
+	info := PeerInfoFromContext(ctx)
+	ip, _, err := net.SplitHostPort(info.RemoteAddr)
+	if err != nil {
+		ip = info.RemoteAddr
+	}
+	params := string(msg.Params)
+	switch {
+	case a.redacted(msg.Method):
+		params = "[redacted]"
+	case len(params) > maxAuditParamsSize:
+		params = params[:maxAuditParamsSize] + "...(truncated)"
+	}
+	return &auditEntry{
+		a: a,
+		ctx: []interface{}{
+			"method", msg.Method,
+			"params", params,
+			"transport", info.Transport,
+			"ip", ip,
+			"subject", info.AuthSubject,
+		},
+		start: time.Now(),
+	}
+}
+
+// finish completes the record with the response. The record of a streamed result
+// is completed once the result has been written.
+func (e *auditEntry) finish(resp *jsonrpcMessage) {
+	switch {
+	case e == nil:
+	case resp == nil:
+		e.end(0, nil)
+	default:
+		resp.onWritten(e.end)
+	}
+}
+
+// end writes the record.
+func (e *auditEntry) end(size int, err *jsonError) {
+	code := 0
+	if err != nil {
+		code = err.Code
+	}
+	e.a.log.Info("RPC call", append(e.ctx, "duration", time.Since(e.start), "size", size, "code", code)...)
+}
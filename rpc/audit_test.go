@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	audit, err := newAuditLog(log.JSONHandler(&out), nil, AuditPolicy{
+		Sampling: map[string]float64{"nftest": 0},
+		Redact:   []string{"test_echo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newStreamTestServer(0)
+	server.SetAuditLog(audit)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	var items json.RawMessage
+	client.Call(nil, "test_echo", "secret", 1)
+	client.Call(nil, "test_returnError")
+	client.Call(nil, "nftest_echo", "x", 1)
+	client.Call(&items, "stream_items", 3)
+	client.Call(nil, "test_repeat", "x", 2)
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid entry %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	want := []struct {
+		method string
+		params string
+		size   int
+		code   int
+	}{
+		{"test_echo", "[redacted]", len(`{"String":"secret","Int":1,"Args":null}`), 0},
+		{"test_returnError", "", 0, 444},
+		{"stream_items", "[3]", len(items), 0},
+		{"test_repeat", `["x",2]`, len(`"xx"`), 0},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("wrong number of entries %d, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e["method"] != w.method || e["params"] != w.params || e["size"] != float64(w.size) || e["code"] != float64(w.code) {
+			t.Errorf("entry %d: wrong record %v", i, e)
+		}
+		if e["transport"] != "ipc" {
+			t.Errorf("entry %d: wrong transport %v", i, e["transport"])
+		}
+	}
+}
+
+func TestAuditLogInvalidPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []AuditPolicy{
+		{Redact: []string{"eth_["}},
+		{Sampling: map[string]float64{"eth": 2}},
+	} {
+		if _, err := newAuditLog(log.DiscardHandler(), nil, policy); err == nil {
+			t.Errorf("policy %+v accepted", policy)
+		}
+	}
+}
@@ -88,6 +88,13 @@ type Client struct {
 	// config fields
 	batchItemLimit       int
 	batchResponseMaxSize int
+	responseSizeLimit    int
+	access               *AccessControl
+	cache                ResponseCache
+	audit                *AuditLog
+
+	// pool, if non-nil, routes all requests to the clients of multiple endpoints.
+	pool *endpointPool
 
 	// writeConn is used for writing to the connection on the caller's goroutine. It should
 	// only be accessed outside of dispatch, with the write lock held. The write lock is
@@ -120,6 +127,10 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx = context.WithValue(ctx, clientContextKey{}, c)
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, conn.peerInfo())
 	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize)
+	handler.access = c.access
+	handler.responseSizeLimit = c.responseSizeLimit
+	handler.cache = c.cache
+	handler.audit = c.audit
 	return &clientConn{conn, handler}
 }
 
@@ -247,6 +258,10 @@ func initClient(conn ServerCodec, services *serviceRegistry, cfg *clientConfig)
 		idgen:                cfg.idgen,
 		batchItemLimit:       cfg.batchItemLimit,
 		batchResponseMaxSize: cfg.batchResponseLimit,
+		responseSizeLimit:    cfg.responseSizeLimit,
+		access:               cfg.access,
+		cache:                cfg.cache,
+		audit:                cfg.audit,
 		writeConn:            conn,
 		close:                make(chan struct{}),
 		closing:              make(chan struct{}),
@@ -276,6 +291,9 @@ func initClient(conn ServerCodec, services *serviceRegistry, cfg *clientConfig)
 // subscription an error is returned. Otherwise a new service is created and added to the
 // service collection this client provides to the server.
 func (c *Client) RegisterName(name string, receiver interface{}) error {
+	if c.pool != nil {
+		return errPoolRegisterName
+	}
 	return c.services.registerName(name, receiver)
 }
 
@@ -296,6 +314,10 @@ func (c *Client) SupportedModules() (map[string]string, error) {
 
 // Close closes the client, aborting any in-flight requests.
 func (c *Client) Close() {
+	if c.pool != nil {
+		c.pool.close()
+		return
+	}
 	if c.isHTTP {
 		return
 	}
@@ -310,6 +332,10 @@ func (c *Client) Close() {
 // This method only works for clients using HTTP, it doesn't have
 // any effect for clients using another transport.
 func (c *Client) SetHeader(key, value string) {
+	if c.pool != nil {
+		c.pool.setHeader(key, value)
+		return
+	}
 	if !c.isHTTP {
 		return
 	}
@@ -338,6 +364,9 @@ func (c *Client) CallContext(ctx context.Context, result interface{}, method str
 	if result != nil && reflect.TypeOf(result).Kind() != reflect.Ptr {
 		return fmt.Errorf("call result parameter must be pointer or nil interface: %v", result)
 	}
+	if c.pool != nil {
+		return c.pool.call(ctx, result, method, args...)
+	}
 	msg, err := c.newMessage(method, args...)
 	if err != nil {
 		return err
@@ -397,6 +426,9 @@ func (c *Client) BatchCall(b []BatchElem) error {
 //
 // Note that batch calls may not be executed atomically on the server side.
 func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
+	if c.pool != nil {
+		return c.pool.batchCall(ctx, b)
+	}
 	var (
 		msgs = make([]*jsonrpcMessage, len(b))
 		byID = make(map[string]int, len(b))
@@ -468,6 +500,9 @@ func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
 
 // Notify sends a notification, i.e. a method call that doesn't expect a response.
 func (c *Client) Notify(ctx context.Context, method string, args ...interface{}) error {
+	if c.pool != nil {
+		return c.pool.notify(ctx, method, args...)
+	}
 	op := new(requestOp)
 	msg, err := c.newMessage(method, args...)
 	if err != nil {
@@ -513,6 +548,9 @@ func (c *Client) Subscribe(ctx context.Context, namespace string, channel interf
 	if chanVal.IsNil() {
 		panic("channel given to Subscribe must not be nil")
 	}
+	if c.pool != nil {
+		return c.pool.subscribe(ctx, namespace, channel, args...)
+	}
 	if c.isHTTP {
 		return nil, ErrNotificationsUnsupported
 	}
@@ -542,6 +580,9 @@ func (c *Client) Subscribe(ctx context.Context, namespace string, channel interf
 // transport. When this returns false, Subscribe and related methods will return
 // ErrNotificationsUnsupported.
 func (c *Client) SupportsSubscriptions() bool {
+	if c.pool != nil {
+		return c.pool.supportsSubscriptions()
+	}
 	return !c.isHTTP
 }
 
@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -41,6 +42,15 @@ type clientConfig struct {
 	idgen              func() ID
 	batchItemLimit     int
 	batchResponseLimit int
+	responseSizeLimit  int
+	access             *AccessControl
+	cache              ResponseCache
+	audit              *AuditLog
+
+	// Endpoint pool options
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	maxHeadLag          uint64
 }
 
 func (cfg *clientConfig) initHeaders() {
@@ -142,3 +152,28 @@ func WithBatchResponseSizeLimit(sizeLimit int) ClientOption {
 		cfg.batchResponseLimit = sizeLimit
 	})
 }
+
+// WithHealthCheckInterval configures how often a client created by DialEndpoints
+// checks the health of its endpoints.
+func WithHealthCheckInterval(interval time.Duration) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.healthCheckInterval = interval
+	})
+}
+
+// WithHealthCheckTimeout configures the time an endpoint of a client created by
+// DialEndpoints has to answer a health check before it's considered unhealthy.
+func WithHealthCheckTimeout(timeout time.Duration) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.healthCheckTimeout = timeout
+	})
+}
+
+// WithMaxHeadLag configures the number of blocks an endpoint of a client created by
+// DialEndpoints may fall behind the most advanced endpoint before it's considered
+// unhealthy.
+func WithMaxHeadLag(blocks uint64) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.maxHeadLag = blocks
+	})
+}
@@ -105,5 +105,11 @@ For more information about subscriptions, see https://github.com/ethereum/go-eth
 In any method handler, an instance of rpc.Client can be accessed through the
 ClientFromContext method. Using this client instance, server-to-client method calls can be
 performed on the RPC connection.
+
+# Service Discovery
+
+The server describes the methods of its registered services in an OpenRPC document,
+which is returned by the "rpc.discover" method. Parameter and result schemas are derived
+from the Go types of the methods, following the encoding rules of package json.
 */
 package rpc
@@ -0,0 +1,220 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	durableBackoffMin = 100 * time.Millisecond
+	durableBackoffMax = 10 * time.Second
+)
+
+// ResubscribeHook is called when a durable subscription delivers the first
+// notification after it was re-established. It receives the last notification
+// delivered before the subscription failed, nil if there was none, and the first
+// one delivered afterwards. Notifications sent in between were missed.
+//
+// The hook runs on the forwarding goroutine of the subscription, before the first
+// notification is sent on the subscription channel.
+type ResubscribeHook func(last, first interface{})
+
+// DurableSubscription is a subscription which is re-established whenever it fails,
+// e.g. because the connection to the server was lost. It's created by the Client's
+// SubscribeDurable method.
+type DurableSubscription struct {
+	client    *Client
+	namespace string
+	args      []interface{}
+	channel   reflect.Value
+	hook      ResubscribeHook
+
+	err       chan error
+	quit      chan struct{}
+	done      chan struct{}
+	unsubOnce sync.Once
+}
+
+// SubscribeDurable is like Subscribe, but transparently re-issues the subscription
+// when it fails. Resubscription is retried with backoff until it succeeds, the
+// client is closed, or the server refuses it. The hook, if non-nil, is notified of
+// the gap in notifications caused by every resubscription.
+//
+// The context argument only applies to the initial subscription request.
+func (c *Client) SubscribeDurable(ctx context.Context, namespace string, channel interface{}, hook ResubscribeHook, args ...interface{}) (*DurableSubscription, error) {
+	chanVal := reflect.ValueOf(channel)
+	if chanVal.Kind() != reflect.Chan || chanVal.Type().ChanDir()&reflect.SendDir == 0 {
+		panic(fmt.Sprintf("channel argument of SubscribeDurable has type %T, need writable channel", channel))
+	}
+	if chanVal.IsNil() {
+		panic("channel given to SubscribeDurable must not be nil")
+	}
+	s := &DurableSubscription{
+		client:    c,
+		namespace: namespace,
+		args:      args,
+		channel:   chanVal,
+		hook:      hook,
+		err:       make(chan error, 1),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	sub, in, err := s.subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go s.loop(sub, in)
+	return s, nil
+}
+
+// Err returns the subscription error channel. It receives a value when the
+// subscription has ended for good: nil if the client was closed, otherwise the
+// error the server refused resubscription with.
+//
+// The error channel is closed when Unsubscribe is called on the subscription.
+func (s *DurableSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription and closes the error channel. It can safely
+// be called more than once.
+func (s *DurableSubscription) Unsubscribe() {
+	s.unsubOnce.Do(func() {
+		close(s.quit)
+		<-s.done
+		close(s.err)
+	})
+}
+
+// subscribe issues the subscription request, delivering notifications on a new
+// channel.
+func (s *DurableSubscription) subscribe(ctx context.Context) (*ClientSubscription, reflect.Value, error) {
+	in := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, s.channel.Type().Elem()), 0)
+	sub, err := s.client.Subscribe(ctx, s.namespace, in.Interface(), s.args...)
+	return sub, in, err
+}
+
+// loop forwards the notifications of the active subscription, re-establishing
+// it whenever it fails.
+func (s *DurableSubscription) loop(sub *ClientSubscription, in reflect.Value) {
+	defer close(s.done)
+
+	var (
+		last    interface{}
+		resumed bool
+	)
+	for {
+		err, quit := s.forward(sub, in, &last, &resumed)
+		if quit {
+			sub.Unsubscribe()
+			return
+		}
+		if err == nil {
+			s.err <- nil // client closed
+			return
+		}
+		log.Debug("RPC subscription failed, resubscribing", "namespace", s.namespace, "err", err)
+		if sub, in, err = s.resubscribe(); sub == nil {
+			if err != nil {
+				s.err <- err
+			}
+			return
+		}
+		resumed = true
+	}
+}
+
+// forward delivers the notifications of a subscription until it fails or the
+// durable subscription is unsubscribed.
+func (s *DurableSubscription) forward(sub *ClientSubscription, in reflect.Value, last *interface{}, resumed *bool) (error, bool) {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.quit)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.Err())},
+		{Dir: reflect.SelectRecv, Chan: in},
+	}
+	for {
+		chosen, recv, _ := reflect.Select(cases)
+		switch chosen {
+		case 0: // <-s.quit
+			return nil, true
+
+		case 1: // <-sub.Err()
+			if recv.IsNil() {
+				return nil, false
+			}
+			return recv.Interface().(error), false
+
+		case 2: // <-in
+			if *resumed {
+				if s.hook != nil {
+					s.hook(*last, recv.Interface())
+				}
+				*resumed = false
+			}
+			send := []reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.quit)},
+				{Dir: reflect.SelectSend, Chan: s.channel, Send: recv},
+			}
+			if chosen, _, _ := reflect.Select(send); chosen == 0 {
+				return nil, true
+			}
+			*last = recv.Interface()
+		}
+	}
+}
+
+// resubscribe re-establishes the subscription, backing off between attempts. It
+// returns a nil subscription if it gave up, along with the error to report, if any.
+func (s *DurableSubscription) resubscribe() (*ClientSubscription, reflect.Value, error) {
+	wait := durableBackoffMin
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+		sub, in, err := s.subscribe(ctx)
+		cancel()
+
+		var rpcErr Error
+		switch {
+		case err == nil:
+			log.Debug("RPC subscription re-established", "namespace", s.namespace)
+			return sub, in, nil
+		case errors.Is(err, ErrClientQuit):
+			return nil, reflect.Value{}, nil
+		case errors.As(err, &rpcErr):
+			return nil, reflect.Value{}, err // refused by the server
+		}
+		log.Debug("RPC resubscription failed", "namespace", s.namespace, "err", err, "retry", wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.quit:
+			timer.Stop()
+			return nil, reflect.Value{}, nil
+		}
+		if wait *= 2; wait > durableBackoffMax {
+			wait = durableBackoffMax
+		}
+	}
+}
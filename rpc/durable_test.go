@@ -0,0 +1,124 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// dialDroppable attaches an in-process connection to the server, like DialInProc,
+// and returns a function which drops the current connection.
+func dialDroppable(server *Server) (*Client, func()) {
+	conns := make(chan net.Conn, 10)
+	c, _ := newClient(context.Background(), new(clientConfig), func(context.Context) (ServerCodec, error) {
+		p1, p2 := net.Pipe()
+		conns <- p1
+		go server.ServeCodec(NewCodec(p1), 0)
+		return NewCodec(p2), nil
+	})
+	return c, func() { (<-conns).Close() }
+}
+
+func TestDurableSubscription(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client, drop := dialDroppable(server)
+	defer client.Close()
+
+	type gap struct{ last, first interface{} }
+	var (
+		nc   = make(chan int)
+		gaps = make(chan gap, 1)
+		hook = func(last, first interface{}) { gaps <- gap{last, first} }
+	)
+	sub, err := client.SubscribeDurable(context.Background(), "nftest", nc, hook, "someSubscription", 2, 10)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, want := range []int{10, 11} {
+		if val := <-nc; val != want {
+			t.Fatalf("value mismatch: got %d, want %d", val, want)
+		}
+	}
+	// Drop the connection, the subscription should be re-established and
+	// deliver the notifications of the new server-side subscription.
+	drop()
+	for _, want := range []int{10, 11} {
+		select {
+		case val := <-nc:
+			if val != want {
+				t.Fatalf("value mismatch after resubscribe: got %d, want %d", val, want)
+			}
+		case err := <-sub.Err():
+			t.Fatal("subscription ended:", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscription not re-established within 5s")
+		}
+	}
+	select {
+	case g := <-gaps:
+		if g.last != 11 || g.first != 10 {
+			t.Fatalf("wrong gap: last %v, first %v", g.last, g.first)
+		}
+	default:
+		t.Fatal("resubscribe hook not called")
+	}
+}
+
+func TestDurableSubscriptionClientClose(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+
+	nc := make(chan int)
+	sub, err := client.SubscribeDurable(context.Background(), "nftest", nc, nil, "someSubscription", 1, 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	<-nc
+	client.Close()
+
+	select {
+	case err := <-sub.Err():
+		if err != nil {
+			t.Fatal("Err returned a non-nil error after client close:", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not ended within 5s after client close")
+	}
+	sub.Unsubscribe()
+	if _, ok := <-sub.Err(); ok {
+		t.Fatal("error channel not closed after unsubscribe")
+	}
+}
+
+func TestDurableSubscriptionRefused(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	nc := make(chan int)
+	if _, err := client.SubscribeDurable(context.Background(), "nftest", nc, nil, "noSuchSubscription"); err == nil {
+		t.Fatal("no error for unknown subscription")
+	}
+}
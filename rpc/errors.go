@@ -55,12 +55,15 @@ var (
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
 	_ Error = new(internalServerError)
+	_ Error = new(methodNotAllowedError)
+	_ Error = new(rateLimitedError)
 )
 
 const (
 	errcodeDefault          = -32000
 	errcodeTimeout          = -32002
 	errcodeResponseTooLarge = -32003
+	errcodeLimitExceeded    = -32005
 	errcodePanic            = -32603
 	errcodeMarshalError     = -32603
 
@@ -71,6 +74,7 @@ const (
 	errMsgTimeout          = "request timed out"
 	errMsgResponseTooLarge = "response too large"
 	errMsgBatchTooLarge    = "batch too large"
+	errMsgRateLimited      = "rate limit exceeded"
 )
 
 type methodNotFoundError struct{ method string }
@@ -81,6 +85,20 @@ func (e *methodNotFoundError) Error() string {
 	return fmt.Sprintf("the method %s does not exist/is not available", e.method)
 }
 
+type methodNotAllowedError struct{ method string }
+
+func (e *methodNotAllowedError) ErrorCode() int { return -32601 }
+
+func (e *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("the method %s is not allowed", e.method)
+}
+
+type rateLimitedError struct{}
+
+func (e *rateLimitedError) ErrorCode() int { return errcodeLimitExceeded }
+
+func (e *rateLimitedError) Error() string { return errMsgRateLimited }
+
 type notificationsUnsupportedError struct{}
 
 func (e notificationsUnsupportedError) Error() string {
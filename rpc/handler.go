@@ -62,6 +62,10 @@ type handler struct {
 	allowSubscribe       bool
 	batchRequestLimit    int
 	batchResponseMaxSize int
+	responseSizeLimit    int            // maximum size of a call result, 0 if unlimited
+	access               *AccessControl // nil if unrestricted
+	cache                ResponseCache  // nil if results aren't cached
+	audit                *AuditLog      // nil if calls aren't audited
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -225,6 +229,23 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 				break
 			}
 			resp := h.handleCallMsg(cp, msg)
+			if resp != nil && resp.stream != nil {
+				// Batch responses are written at once, so streamed results have to be
+				// encoded upfront. Stop as soon as they exceed the batch size limit.
+				limit, batchLimited := h.responseSizeLimit, false
+				if h.batchResponseMaxSize != 0 {
+					if left := h.batchResponseMaxSize - responseBytes + 1; limit == 0 || left < limit {
+						limit, batchLimited = left, true
+					}
+				}
+				resp = resp.encodeStream(cp.ctx, limit)
+				if batchLimited && resp.Error != nil && resp.Error.Code == errcodeResponseTooLarge {
+					callBuffer.respondWithError(cp.ctx, h.conn, errResponseTooLarge)
+					break
+				}
+			} else if resp != nil && h.responseSizeLimit != 0 && len(resp.Result) > h.responseSizeLimit {
+				resp = resp.errorResponse(errResponseTooLarge)
+			}
 			callBuffer.pushResponse(resp)
 			if resp != nil && h.batchResponseMaxSize != 0 {
 				responseBytes += len(resp.Result)
@@ -300,7 +321,7 @@ func (h *handler) handleNonBatchCall(cp *callProc, msg *jsonrpcMessage) {
 	h.addSubscriptions(cp.notifiers)
 	if answer != nil {
 		responded.Do(func() {
-			h.conn.writeJSON(cp.ctx, answer, false)
+			h.writeResponse(cp.ctx, answer)
 		})
 	}
 	for _, n := range cp.notifiers {
@@ -308,6 +329,19 @@ func (h *handler) handleNonBatchCall(cp *callProc, msg *jsonrpcMessage) {
 	}
 }
 
+// writeResponse writes the response of a non-batch call, enforcing the response
+// size limit.
+func (h *handler) writeResponse(ctx context.Context, resp *jsonrpcMessage) {
+	switch {
+	case resp.stream != nil:
+		h.conn.writeStream(ctx, resp, h.responseSizeLimit)
+	case h.responseSizeLimit != 0 && len(resp.Result) > h.responseSizeLimit:
+		h.conn.writeJSON(ctx, resp.errorResponse(errResponseTooLarge), true)
+	default:
+		h.conn.writeJSON(ctx, resp, false)
+	}
+}
+
 // close cancels all requests except for inflightReq and waits for
 // call goroutines to shut down.
 func (h *handler) close(err error, inflightReq *requestOp) {
@@ -462,12 +496,16 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 	start := time.Now()
 	switch {
 	case msg.isNotification():
+		entry := h.audit.begin(ctx.ctx, msg)
 		h.handleCall(ctx, msg)
+		entry.finish(nil)
 		h.log.Debug("Served "+msg.Method, "duration", time.Since(start))
 		return nil
 
 	case msg.isCall():
+		entry := h.audit.begin(ctx.ctx, msg)
 		resp := h.handleCall(ctx, msg)
+		entry.finish(resp)
 		var ctx []interface{}
 		ctx = append(ctx, "reqid", idForLog{msg.ID}, "duration", time.Since(start))
 		if resp.Error != nil {
@@ -491,6 +529,12 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if err := checkAuthNamespaces(cp.ctx, msg); err != nil {
+		return msg.errorResponse(err)
+	}
+	if err := h.access.check(cp.ctx, msg.Method); err != nil {
+		return msg.errorResponse(err)
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -508,22 +552,33 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
-	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	if callb == h.unsubscribeCb {
+		return h.runCachedMethod(cp.ctx, msg, callb, args)
+	}
+	var (
+		start     = time.Now()
+		transport = PeerInfoFromContext(cp.ctx).Transport
+		inflight  = inflightGauge(msg.Method, transport)
+	)
+	inflight.Inc(1)
+	answer := h.runCachedMethod(cp.ctx, msg, callb, args)
+	inflight.Dec(1)
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
-	if callb != h.unsubscribeCb {
-		rpcRequestGauge.Inc(1)
-		if answer.Error != nil {
-			failedRequestGauge.Inc(1)
-		} else {
-			successfulRequestGauge.Inc(1)
-		}
-		rpcServingTimer.UpdateSince(start)
-		updateServeTimeHistogram(msg.Method, answer.Error == nil, time.Since(start))
+	rpcRequestGauge.Inc(1)
+	if answer.Error != nil {
+		failedRequestGauge.Inc(1)
+	} else {
+		successfulRequestGauge.Inc(1)
 	}
+	rpcServingTimer.UpdateSince(start)
+	updateServeTimeHistogram(msg.Method, answer.Error == nil, time.Since(start))
 
+	// Latency and size of streamed results are known once they're written.
+	answer.onWritten(func(size int, err *jsonError) {
+		updateCallHistograms(msg.Method, transport, time.Since(start), size)
+	})
 	return answer
 }
 
@@ -560,6 +615,28 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	return h.runMethod(ctx, msg, callb, args)
 }
 
+// runCachedMethod serves a method call from the response cache, or runs the method
+// and offers its result to the cache.
+func (h *handler) runCachedMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+	if h.cache == nil || callb == h.unsubscribeCb {
+		return h.runMethod(ctx, msg, callb, args)
+	}
+	cached, store := h.cache.Lookup(ctx, msg.Method, msg.Params)
+	if cached != nil {
+		return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: cached}
+	}
+	answer := h.runMethod(ctx, msg, callb, args)
+	if store != nil && answer.Error == nil {
+		if answer.stream != nil {
+			// Streamed results are stored once they were written.
+			answer.store = store
+		} else {
+			store(answer.Result)
+		}
+	}
+	return answer
+}
+
 // runMethod runs the Go callback for an RPC method.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
 	result, err := callb.call(ctx, msg.Method, args)
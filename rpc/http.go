@@ -58,6 +58,10 @@ func (hc *httpConn) writeJSON(context.Context, interface{}, bool) error {
 	panic("writeJSON called on httpConn")
 }
 
+func (hc *httpConn) writeStream(context.Context, *jsonrpcMessage, int) error {
+	panic("writeStream called on httpConn")
+}
+
 func (hc *httpConn) peerInfo() PeerInfo {
 	panic("peerInfo called on httpConn")
 }
@@ -250,7 +254,8 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 type httpServerConn struct {
 	io.Reader
 	io.Writer
-	r *http.Request
+	r       *http.Request
+	aborted bool // a streamed response was cut off
 }
 
 func (s *Server) newHTTPServerConn(r *http.Request, w http.ResponseWriter) ServerCodec {
@@ -291,7 +296,15 @@ func (s *Server) newHTTPServerConn(r *http.Request, w http.ResponseWriter) Serve
 	dec := json.NewDecoder(conn)
 	dec.UseNumber()
 
-	return NewFuncCodec(conn, encoder, dec.Decode)
+	codec := NewFuncCodec(conn, encoder, dec.Decode).(*jsonCodec)
+	codec.stream = func(write func(io.Writer) error) error {
+		err := write(conn)
+		if errors.Is(err, errStreamAborted) {
+			conn.aborted = true
+		}
+		return err
+	}
+	return codec
 }
 
 // Close does nothing and always returns nil.
@@ -323,6 +336,8 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	connInfo.HTTP.Host = r.Host
 	connInfo.HTTP.Origin = r.Header.Get("Origin")
 	connInfo.HTTP.UserAgent = r.Header.Get("User-Agent")
+	connInfo.AuthSubject = authSubjectFromContext(r.Context())
+	connInfo.AuthNamespaces = authNamespacesFromContext(r.Context())
 	ctx := r.Context()
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, connInfo)
 
@@ -333,6 +348,13 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	codec := s.newHTTPServerConn(r, w)
 	defer codec.close()
 	s.serveSingleRequest(ctx, codec)
+
+	// Closing the codec doesn't affect the HTTP connection. If part of a streamed
+	// result was sent already, abort the response instead, so that the client sees
+	// an incomplete transfer rather than a truncated body.
+	if codec.(*jsonCodec).conn.(*httpServerConn).aborted {
+		panic(http.ErrAbortHandler)
+	}
 }
 
 // validateRequest returns a non-zero response code and error message if the
@@ -66,6 +66,10 @@ type jsonrpcMessage struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 	Error   *jsonError      `json:"error,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
+
+	stream  JSONStreamer                   // result of a response, encoded while it's written
+	store   func(json.RawMessage)          // stores the streamed result in the response cache
+	written func(size int, err *jsonError) // reports the written result, see onWritten
 }
 
 func (msg *jsonrpcMessage) isNotification() bool {
@@ -113,6 +117,9 @@ func (msg *jsonrpcMessage) errorResponse(err error) *jsonrpcMessage {
 }
 
 func (msg *jsonrpcMessage) response(result interface{}) *jsonrpcMessage {
+	if stream, ok := result.(JSONStreamer); ok && !isNilStreamer(result) {
+		return &jsonrpcMessage{Version: vsn, ID: msg.ID, stream: stream}
+	}
 	enc, err := json.Marshal(result)
 	if err != nil {
 		return msg.errorResponse(&internalServerError{errcodeMarshalError, err.Error()})
@@ -120,6 +127,43 @@ func (msg *jsonrpcMessage) response(result interface{}) *jsonrpcMessage {
 	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: enc}
 }
 
+// encodeStream encodes the streamed result of a response into memory.
+func (msg *jsonrpcMessage) encodeStream(ctx context.Context, limit int) *jsonrpcMessage {
+	enc, err := marshalStream(ctx, msg.stream, limit)
+	if err != nil {
+		resp := msg.errorResponse(err)
+		if msg.written != nil {
+			msg.written(0, resp.Error)
+		}
+		return resp
+	}
+	if msg.store != nil {
+		msg.store(enc)
+	}
+	if msg.written != nil {
+		msg.written(len(enc), nil)
+	}
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: enc}
+}
+
+// onWritten calls fn with the size of the result and the error of the response
+// once it has been encoded, which is deferred until it's written for streamed
+// results.
+func (msg *jsonrpcMessage) onWritten(fn func(size int, err *jsonError)) {
+	switch {
+	case msg.stream == nil:
+		fn(len(msg.Result), msg.Error)
+	case msg.written == nil:
+		msg.written = fn
+	default:
+		prev := msg.written
+		msg.written = func(size int, err *jsonError) {
+			prev(size, err)
+			fn(size, err)
+		}
+	}
+}
+
 func errorMessage(err error) *jsonrpcMessage {
 	msg := &jsonrpcMessage{Version: vsn, ID: null, Error: &jsonError{
 		Code:    errcodeDefault,
@@ -184,11 +228,15 @@ type jsonCodec struct {
 	decode  decodeFunc       // decoder to allow multiple transports
 	encMu   sync.Mutex       // guards the encoder
 	encode  encodeFunc       // encoder to allow multiple transports
+	stream  streamFunc       // incremental writer, nil if unsupported by the transport
 	conn    deadlineCloser
 }
 
 type encodeFunc = func(v interface{}, isErrorResponse bool) error
 
+// streamFunc calls write with a writer for a single message.
+type streamFunc = func(write func(w io.Writer) error) error
+
 type decodeFunc = func(v interface{}) error
 
 // NewFuncCodec creates a codec which uses the given functions to read and write. If conn
@@ -217,7 +265,11 @@ func NewCodec(conn Conn) ServerCodec {
 	encode := func(v interface{}, isErrorResponse bool) error {
 		return enc.Encode(v)
 	}
-	return NewFuncCodec(conn, encode, dec.Decode)
+	codec := NewFuncCodec(conn, encode, dec.Decode).(*jsonCodec)
+	codec.stream = func(write func(io.Writer) error) error {
+		return write(conn)
+	}
+	return codec
 }
 
 func (c *jsonCodec) peerInfo() PeerInfo {
@@ -259,6 +311,30 @@ func (c *jsonCodec) writeJSON(ctx context.Context, v interface{}, isErrorRespons
 	return c.encode(v, isErrorResponse)
 }
 
+func (c *jsonCodec) writeStream(ctx context.Context, msg *jsonrpcMessage, limit int) error {
+	c.encMu.Lock()
+	defer c.encMu.Unlock()
+
+	if c.stream == nil {
+		// The transport can't write messages incrementally, encode them upfront.
+		resp := msg.encodeStream(ctx, limit)
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(defaultWriteTimeout)
+		}
+		c.conn.SetWriteDeadline(deadline)
+		return c.encode(resp, resp.Error != nil)
+	}
+	err := c.stream(func(w io.Writer) error {
+		return writeStreamResponse(ctx, &deadlineWriter{ctx, c.conn, w}, msg, limit)
+	})
+	if errors.Is(err, errStreamAborted) {
+		// Part of the result was sent already, cut the response off.
+		c.conn.Close()
+	}
+	return err
+}
+
 func (c *jsonCodec) close() {
 	c.closer.Do(func() {
 		close(c.closeCh)
@@ -32,8 +32,37 @@ var (
 	serveTimeHistName = "rpc/duration"
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	// Names of the metrics labeled by method and transport.
+	inflightGaugeName = "rpc/inflight"
+	latencyHistName   = "rpc/latency"
+	sizeHistName      = "rpc/response/size"
+
+	rpcDeniedMeter         = metrics.NewRegisteredMeter("rpc/rejected/denied", nil)
+	rpcIPLimitedMeter      = metrics.NewRegisteredMeter("rpc/rejected/ratelimit/ip", nil)
+	rpcSubjectLimitedMeter = metrics.NewRegisteredMeter("rpc/rejected/ratelimit/subject", nil)
+
+	rpcStreamAbortedMeter = metrics.NewRegisteredMeter("rpc/stream/aborted", nil)
 )
 
+// inflightGauge returns the gauge of the calls of a method being served over the
+// transport.
+func inflightGauge(method, transport string) metrics.Gauge {
+	return metrics.GetOrRegisterGauge(metrics.LabeledName(inflightGaugeName, "method", method, "transport", transport), nil)
+}
+
+// updateCallHistograms tracks the latency and response size of a call served over
+// the transport. The latency of streamed results includes writing them.
+func updateCallHistograms(method, transport string, elapsed time.Duration, size int) {
+	labels := []string{"method", method, "transport", transport}
+	metrics.GetOrRegisterHistogramLazy(metrics.LabeledName(latencyHistName, labels...), nil, callSampler).Update(elapsed.Nanoseconds())
+	metrics.GetOrRegisterHistogramLazy(metrics.LabeledName(sizeHistName, labels...), nil, callSampler).Update(int64(size))
+}
+
+func callSampler() metrics.Sample {
+	return metrics.ResettingSample(metrics.NewExpDecaySample(1028, 0.015))
+}
+
 // updateServeTimeHistogram tracks the serving time of a remote RPC call.
 func updateServeTimeHistogram(method string, success bool, elapsed time.Duration) {
 	note := "success"
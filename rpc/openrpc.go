@@ -0,0 +1,414 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+const (
+	// discoverMethod is the name of the OpenRPC service discovery method. It
+	// doesn't follow the namespace_method convention, so it's mapped to the
+	// Discover method of the rpc namespace.
+	discoverMethod = "rpc.discover"
+
+	openRPCVersion = "1.2.6"
+)
+
+// OpenRPCDocument is an OpenRPC service description, as returned by rpc.discover.
+// See https://spec.open-rpc.org for the specification.
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []*OpenRPCMethod  `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+// OpenRPCInfo holds the metadata of an OpenRPC document.
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes a method of an OpenRPC document.
+type OpenRPCMethod struct {
+	Name           string                      `json:"name"`
+	Description    string                      `json:"description,omitempty"`
+	Params         []*OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor   `json:"result"`
+	ParamStructure string                      `json:"paramStructure"`
+}
+
+// OpenRPCContentDescriptor describes a parameter or the result of a method.
+type OpenRPCContentDescriptor struct {
+	Name     string      `json:"name"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+// OpenRPCComponents holds the schemas referenced by the methods of a document.
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas"`
+}
+
+// JSONSchema is the subset of JSON Schema used to describe the values of the API.
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+}
+
+var (
+	hexUintSchema  = &JSONSchema{Title: "hex encoded unsigned integer", Type: "string", Pattern: "^0x(0|[1-9a-f][0-9a-f]*)$"}
+	hexBytesSchema = &JSONSchema{Title: "hex encoded bytes", Type: "string", Pattern: "^0x[0-9a-f]*$"}
+	hashSchema     = &JSONSchema{Title: "32 byte hex value", Type: "string", Pattern: "^0x[0-9a-f]{64}$"}
+	addressSchema  = &JSONSchema{Title: "address", Type: "string", Pattern: "^0x[0-9a-fA-F]{40}$"}
+
+	blockNumberSchema = &JSONSchema{
+		Title: "block number or tag",
+		OneOf: []*JSONSchema{
+			hexUintSchema,
+			{Type: "string", Enum: []string{"earliest", "latest", "safe", "finalized", "pending"}},
+		},
+	}
+	blockNumberOrHashSchema = &JSONSchema{
+		Title: "block number, tag or hash",
+		OneOf: []*JSONSchema{
+			blockNumberSchema,
+			hashSchema,
+			{
+				Type: "object",
+				Properties: map[string]*JSONSchema{
+					"blockNumber":      blockNumberSchema,
+					"blockHash":        hashSchema,
+					"requireCanonical": {Type: "boolean"},
+				},
+			},
+		},
+	}
+
+	// knownSchemas describes the types whose JSON encoding can't be derived from
+	// their Go definition.
+	knownSchemas = map[reflect.Type]*JSONSchema{
+		reflect.TypeOf(hexutil.Big{}):          hexUintSchema,
+		reflect.TypeOf(hexutil.Uint64(0)):      hexUintSchema,
+		reflect.TypeOf(hexutil.Uint(0)):        hexUintSchema,
+		reflect.TypeOf(hexutil.Bytes{}):        hexBytesSchema,
+		reflect.TypeOf(math.HexOrDecimal64(0)): {Title: "hex or decimal unsigned integer", Type: "string"},
+		reflect.TypeOf(math.HexOrDecimal256{}): {Title: "hex or decimal unsigned integer", Type: "string"},
+		reflect.TypeOf(big.Int{}):              {Type: "integer"},
+		reflect.TypeOf(common.Hash{}):          hashSchema,
+		reflect.TypeOf(common.Address{}):       addressSchema,
+		reflect.TypeOf(BlockNumber(0)):         blockNumberSchema,
+		reflect.TypeOf(BlockNumberOrHash{}):    blockNumberOrHashSchema,
+		reflect.TypeOf(ID("")):                 {Title: "subscription ID", Type: "string"},
+	}
+
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	invalidComponentChars = regexp.MustCompile(`[^a-zA-Z0-9.\-_]`)
+)
+
+// Discover returns the OpenRPC document describing the methods the caller may
+// invoke. It's served as rpc.discover.
+func (s *RPCService) Discover(ctx context.Context) *OpenRPCDocument {
+	namespaces := PeerInfoFromContext(ctx).AuthNamespaces
+	visible := func(method string) bool {
+		if s.server.access != nil && !s.server.access.allowed(method) {
+			return false
+		}
+		if namespaces == nil || method == discoverMethod {
+			return true
+		}
+		namespace, _, _ := strings.Cut(method, serviceMethodSeparator)
+		for _, allowed := range namespaces {
+			if allowed == namespace {
+				return true
+			}
+		}
+		return false
+	}
+	return s.server.services.openRPC(visible)
+}
+
+// openRPC generates the OpenRPC document of the registered services, listing the
+// methods accepted by the visible function.
+func (r *serviceRegistry) openRPC(visible func(method string) bool) *OpenRPCDocument {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		g   = newSchemaGenerator()
+		doc = &OpenRPCDocument{
+			OpenRPC: openRPCVersion,
+			Info:    OpenRPCInfo{Title: "Ethereum JSON-RPC API", Version: "1.0"},
+			Methods: []*OpenRPCMethod{},
+		}
+	)
+	// Iterate in order, so the names of the components are deterministic.
+	for _, name := range sortedKeys(r.services) {
+		svc := r.services[name]
+		for _, cbName := range sortedKeys(svc.callbacks) {
+			method := name + serviceMethodSeparator + cbName
+			if method == MetadataApi+serviceMethodSeparator+"discover" {
+				method = discoverMethod
+			}
+			if visible(method) {
+				doc.Methods = append(doc.Methods, g.method(method, svc.callbacks[cbName]))
+			}
+		}
+		if len(svc.subscriptions) > 0 {
+			doc.Methods = append(doc.Methods, g.subscriptionMethods(name, svc.subscriptions, visible)...)
+		}
+	}
+	sort.Slice(doc.Methods, func(i, j int) bool {
+		return doc.Methods[i].Name < doc.Methods[j].Name
+	})
+	doc.Components.Schemas = g.components
+	return doc
+}
+
+// schemaGenerator derives JSON schemas from Go types. Named struct types are
+// added to the components of the document and referenced by the methods, which
+// also takes care of recursive types.
+type schemaGenerator struct {
+	components map[string]*JSONSchema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: make(map[string]*JSONSchema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// method describes a method callback.
+func (g *schemaGenerator) method(name string, cb *callback) *OpenRPCMethod {
+	m := &OpenRPCMethod{
+		Name:           name,
+		Params:         []*OpenRPCContentDescriptor{},
+		ParamStructure: "by-position",
+	}
+	// Trailing pointer arguments may be omitted, see parsePositionalArguments.
+	required := len(cb.argTypes)
+	for required > 0 && cb.argTypes[required-1].Kind() == reflect.Ptr {
+		required--
+	}
+	for i, typ := range cb.argTypes {
+		m.Params = append(m.Params, &OpenRPCContentDescriptor{
+			Name:     fmt.Sprintf("arg%d", i),
+			Required: i < required,
+			Schema:   g.schema(typ),
+		})
+	}
+	result := &JSONSchema{Type: "null"}
+	if fntype := cb.fn.Type(); fntype.NumOut() > 0 && cb.errPos != 0 {
+		result = g.schema(fntype.Out(0))
+	}
+	m.Result = &OpenRPCContentDescriptor{Name: "result", Schema: result}
+	return m
+}
+
+// subscriptionMethods describes the subscribe and unsubscribe methods of a
+// namespace. The parameters following the subscription name depend on the
+// subscription, so they're not described.
+func (g *schemaGenerator) subscriptionMethods(namespace string, subs map[string]*callback, visible func(string) bool) []*OpenRPCMethod {
+	var (
+		subscribe   = namespace + subscribeMethodSuffix
+		unsubscribe = namespace + unsubscribeMethodSuffix
+		names       = sortedKeys(subs)
+		methods     []*OpenRPCMethod
+	)
+
+	if visible(subscribe) {
+		methods = append(methods, &OpenRPCMethod{
+			Name:        subscribe,
+			Description: "Creates a subscription. The parameters following the subscription name depend on the subscription.",
+			Params: []*OpenRPCContentDescriptor{
+				{Name: "subscription", Required: true, Schema: &JSONSchema{Type: "string", Enum: names}},
+			},
+			Result:         &OpenRPCContentDescriptor{Name: "result", Schema: g.schema(reflect.TypeOf(ID("")))},
+			ParamStructure: "by-position",
+		})
+	}
+	if visible(unsubscribe) {
+		methods = append(methods, &OpenRPCMethod{
+			Name: unsubscribe,
+			Params: []*OpenRPCContentDescriptor{
+				{Name: "id", Required: true, Schema: g.schema(reflect.TypeOf(ID("")))},
+			},
+			Result:         &OpenRPCContentDescriptor{Name: "result", Schema: &JSONSchema{Type: "boolean"}},
+			ParamStructure: "by-position",
+		})
+	}
+	return methods
+}
+
+// schema returns the schema of a type, following the rules of package json.
+func (g *schemaGenerator) schema(typ reflect.Type) *JSONSchema {
+	for typ.Kind() == reflect.Ptr {
+		if s, ok := knownSchemas[typ]; ok {
+			return s
+		}
+		typ = typ.Elem()
+	}
+	if s, ok := knownSchemas[typ]; ok {
+		return s
+	}
+	ptr := reflect.PointerTo(typ)
+	switch {
+	case typ.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType):
+		// The encoding is custom and unknown, anything goes.
+		return &JSONSchema{Title: typ.String()}
+	case typ.Implements(textMarshalerType) || ptr.Implements(textMarshalerType):
+		return &JSONSchema{Title: typ.String(), Type: "string"}
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Title: "base64 encoded bytes", Type: "string"}
+		}
+		return &JSONSchema{Type: "array", Items: g.schema(typ.Elem())}
+	case reflect.Array:
+		return &JSONSchema{Type: "array", Items: g.schema(typ.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.schema(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return g.structSchema(typ)
+		}
+		return &JSONSchema{Ref: "#/components/schemas/" + g.component(typ)}
+	default:
+		// Interfaces, functions and channels, e.g. streamed results.
+		return &JSONSchema{}
+	}
+}
+
+// component adds the schema of a named struct type to the components, returning
+// its name.
+func (g *schemaGenerator) component(typ reflect.Type) string {
+	if name, ok := g.names[typ]; ok {
+		return name
+	}
+	base := invalidComponentChars.ReplaceAllString(path.Base(typ.PkgPath())+"."+typ.Name(), "_")
+	name := base
+	for i := 2; g.components[name] != nil; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	// Register the name before generating the schema to terminate recursion.
+	g.names[typ] = name
+	g.components[name] = &JSONSchema{}
+	*g.components[name] = *g.structSchema(typ)
+	return name
+}
+
+// structSchema describes the fields of a struct. Fields of embedded structs are
+// promoted like in package json, but conflicts between them aren't resolved.
+func (g *schemaGenerator) structSchema(typ reflect.Type) *JSONSchema {
+	s := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ftyp := field.Type
+		if field.Anonymous && name == "" {
+			for ftyp.Kind() == reflect.Ptr {
+				ftyp = ftyp.Elem()
+			}
+			if ftyp.Kind() == reflect.Struct {
+				embedded := g.structSchema(ftyp)
+				for name, prop := range embedded.Properties {
+					s.Properties[name] = prop
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if hasTagOption(opts, "string") {
+			s.Properties[name] = &JSONSchema{Type: "string"}
+		} else {
+			s.Properties[name] = g.schema(field.Type)
+		}
+		if !hasTagOption(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// hasTagOption reports whether the comma-separated options of a json struct tag
+// contain the given one.
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type openrpcTestService struct{}
+
+type openrpcTree struct {
+	Value    hexutil.Uint64 `json:"value"`
+	Children []*openrpcTree `json:"children,omitempty"`
+	Skipped  int            `json:"-"`
+	hidden   int
+}
+
+func (openrpcTestService) Lookup(block BlockNumberOrHash, data hexutil.Bytes) (*hexutil.Big, error) {
+	return nil, nil
+}
+
+func (openrpcTestService) Tree(depth *int) *openrpcTree {
+	return nil
+}
+
+func discover(t *testing.T, server *Server) map[string]*OpenRPCMethod {
+	t.Helper()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	var doc OpenRPCDocument
+	if err := client.Call(&doc, "rpc.discover"); err != nil {
+		t.Fatal("discover failed:", err)
+	}
+	if doc.OpenRPC != openRPCVersion {
+		t.Fatalf("wrong OpenRPC version %q", doc.OpenRPC)
+	}
+	methods := make(map[string]*OpenRPCMethod)
+	for _, m := range doc.Methods {
+		methods[m.Name] = m
+	}
+	return methods
+}
+
+func TestOpenRPCDiscover(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.RegisterName("openrpc", new(openrpcTestService)); err != nil {
+		t.Fatal(err)
+	}
+	doc := server.services.openRPC(func(string) bool { return true })
+
+	methods := make(map[string]*OpenRPCMethod)
+	for _, m := range doc.Methods {
+		methods[m.Name] = m
+	}
+	for _, name := range []string{"rpc.discover", "rpc_modules", "test_echo", "nftest_subscribe", "nftest_unsubscribe"} {
+		if methods[name] == nil {
+			t.Errorf("method %s missing", name)
+		}
+	}
+	// Trailing pointer arguments are optional.
+	echo := methods["test_echo"]
+	if len(echo.Params) != 3 || !echo.Params[0].Required || !echo.Params[1].Required || echo.Params[2].Required {
+		t.Fatalf("wrong test_echo params: %+v", echo.Params)
+	}
+	if ref := echo.Result.Schema.Ref; ref != "#/components/schemas/rpc.echoResult" {
+		t.Fatalf("wrong test_echo result: %q", ref)
+	}
+	if sub := methods["nftest_subscribe"]; !reflect.DeepEqual(sub.Params[0].Schema.Enum, []string{"hangSubscription", "someSubscription"}) {
+		t.Fatalf("wrong subscriptions: %v", sub.Params[0].Schema.Enum)
+	}
+	// Known types are described by their encoding.
+	lookup := methods["openrpc_lookup"]
+	if lookup.Params[0].Schema != blockNumberOrHashSchema || lookup.Params[1].Schema != hexBytesSchema {
+		t.Fatalf("wrong openrpc_lookup params")
+	}
+	if lookup.Result.Schema != hexUintSchema {
+		t.Fatalf("wrong openrpc_lookup result")
+	}
+	if methods["test_noArgsRets"].Result.Schema.Type != "null" {
+		t.Fatal("wrong result of method without results")
+	}
+	// Recursive types are referenced.
+	tree := doc.Components.Schemas["rpc.openrpcTree"]
+	if tree == nil {
+		t.Fatal("component of recursive type missing")
+	}
+	want := &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"value":    hexUintSchema,
+			"children": {Type: "array", Items: &JSONSchema{Ref: "#/components/schemas/rpc.openrpcTree"}},
+		},
+		Required: []string{"value"},
+	}
+	if !reflect.DeepEqual(tree, want) {
+		t.Fatalf("wrong schema of recursive type: %+v", tree)
+	}
+}
+
+func TestOpenRPCDiscoverAccess(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+
+	if methods := discover(t, server); methods["test_echo"] == nil {
+		t.Fatal("method test_echo missing")
+	}
+	ac, err := NewAccessControl(AccessPolicy{Deny: []string{"test_*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetAccessControl(ac)
+	methods := discover(t, server)
+	if methods["test_echo"] != nil {
+		t.Fatal("denied method listed")
+	}
+	if methods["nftest_echo"] == nil {
+		t.Fatal("method nftest_echo missing")
+	}
+}
+
+func TestOpenRPCDiscoverAuthNamespaces(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+
+	// Serve a client whose token only grants the test namespace.
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(w, r.WithContext(NewContextWithAuthNamespaces(r.Context(), []string{"test"})))
+	}))
+	defer httpsrv.Close()
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var doc OpenRPCDocument
+	if err := client.Call(&doc, "rpc.discover"); err != nil {
+		t.Fatal("discover failed:", err)
+	}
+	methods := make(map[string]*OpenRPCMethod)
+	for _, m := range doc.Methods {
+		methods[m.Name] = m
+	}
+	if methods["test_echo"] == nil || methods["rpc.discover"] == nil {
+		t.Fatal("granted method missing")
+	}
+	if methods["nftest_echo"] != nil {
+		t.Fatal("method of other namespace listed")
+	}
+}
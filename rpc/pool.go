@@ -0,0 +1,495 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultHealthCheckTimeout  = 3 * time.Second
+	defaultMaxHeadLag          = 8
+
+	// lagProbeInterval is the minimum time between two background probes of the
+	// endpoints behind the highest observed head.
+	lagProbeInterval = time.Second
+)
+
+var (
+	errNoEndpoints       = errors.New("no endpoints")
+	errEndpointSyncing   = errors.New("endpoint is syncing")
+	errEndpointLagging   = errors.New("endpoint is lagging behind")
+	errNoCurrentEndpoint = errors.New("no endpoint reached the observed head")
+	errPoolRegisterName  = errors.New("services can't be registered on a multi-endpoint client")
+)
+
+// endpointPool routes the requests of a client across multiple endpoints.
+//
+// Endpoints are checked periodically and considered healthy if they answer, are
+// not syncing and don't lag more than a few blocks behind the most advanced one.
+// Requests are balanced across the healthy endpoints which reached the highest
+// head observed so far, so that a caller never observes the chain going back.
+// Unhealthy endpoints which reached that head are only used if no healthy one
+// can serve the request. Endpoints behind it are not used until they caught up:
+// they are probed in the background while they're left out, and once more before
+// a request fails because no endpoint at the head could serve it. Heads are
+// learned from the health checks, the probes and the blocks returned by
+// eth_blockNumber, eth_getBlockByNumber and eth_getHeaderByNumber calls.
+//
+// A request which fails with a transport error is retried on the next endpoint,
+// and the failed one is considered unhealthy until it passes a health check.
+// Subscriptions stick to the endpoint they were created on.
+type endpointPool struct {
+	endpoints []*poolEndpoint
+	interval  time.Duration
+	timeout   time.Duration
+	maxLag    uint64
+
+	mu      sync.Mutex
+	options []ClientOption // options of endpoint clients
+	head    uint64         // highest head observed on any endpoint
+	next    int            // round robin position
+	probing bool           // whether the endpoints behind the head are being probed
+	probed  time.Time      // time of the last background probe
+
+	quit      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// poolEndpoint is an endpoint of a pool. Its health fields are guarded by the
+// pool's lock.
+type poolEndpoint struct {
+	url    string
+	client atomic.Pointer[Client] // nil until dialed successfully
+
+	healthy bool
+	head    uint64
+}
+
+// DialEndpoints creates a client which distributes its requests across the given
+// endpoints, failing over between them. See DialOptions for the URL formats and
+// options. The health checks of the endpoints are configured through the
+// WithHealthCheckInterval, WithHealthCheckTimeout and WithMaxHeadLag options.
+//
+// Endpoints which can't be dialed initially are retried on every health check.
+// DialEndpoints fails only if none of them can be dialed.
+func DialEndpoints(ctx context.Context, rawurls []string, options ...ClientOption) (*Client, error) {
+	if len(rawurls) == 0 {
+		return nil, errNoEndpoints
+	}
+	cfg := new(clientConfig)
+	for _, opt := range options {
+		opt.applyOption(cfg)
+	}
+	p := &endpointPool{
+		interval: cfg.healthCheckInterval,
+		timeout:  cfg.healthCheckTimeout,
+		maxLag:   cfg.maxHeadLag,
+		options:  options,
+		quit:     make(chan struct{}),
+	}
+	if p.interval == 0 {
+		p.interval = defaultHealthCheckInterval
+	}
+	if p.timeout == 0 {
+		p.timeout = defaultHealthCheckTimeout
+	}
+	if p.maxLag == 0 {
+		p.maxLag = defaultMaxHeadLag
+	}
+	for _, rawurl := range rawurls {
+		p.endpoints = append(p.endpoints, &poolEndpoint{url: rawurl})
+	}
+	if err := p.check(ctx); err != nil {
+		return nil, err
+	}
+	p.wg.Add(1)
+	go p.loop()
+	return &Client{pool: p}, nil
+}
+
+// loop checks the health of the endpoints until the pool is closed.
+func (p *endpointPool) loop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.check(context.Background())
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// close stops the health checks and closes the clients of all endpoints.
+func (p *endpointPool) close() {
+	p.closeOnce.Do(func() {
+		close(p.quit)
+		p.wg.Wait()
+		for _, ep := range p.endpoints {
+			if client := ep.client.Load(); client != nil {
+				client.Close()
+			}
+		}
+	})
+}
+
+// check dials the endpoints which aren't connected yet and updates the health
+// of all of them. It returns an error if no endpoint could be dialed.
+func (p *endpointPool) check(ctx context.Context) error {
+	var (
+		heads = make([]uint64, len(p.endpoints))
+		errs  = make([]error, len(p.endpoints))
+		wg    sync.WaitGroup
+	)
+	for i, ep := range p.endpoints {
+		wg.Add(1)
+		go func(i int, ep *poolEndpoint) {
+			defer wg.Done()
+			heads[i], errs[i] = p.checkEndpoint(ctx, ep)
+		}(i, ep)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var (
+		best   uint64
+		dialed bool
+	)
+	for i, ep := range p.endpoints {
+		if ep.client.Load() != nil {
+			dialed = true
+		}
+		if errs[i] == nil && heads[i] > best {
+			best = heads[i]
+		}
+	}
+	if !dialed {
+		return errs[0]
+	}
+	for i, ep := range p.endpoints {
+		err := errs[i]
+		if err == nil && heads[i]+p.maxLag < best {
+			err = errEndpointLagging
+		}
+		if heads[i] > ep.head {
+			ep.head = heads[i]
+		}
+		switch {
+		case err == nil && !ep.healthy:
+			log.Info("RPC endpoint is healthy", "url", ep.url, "head", heads[i])
+		case err != nil && ep.healthy:
+			log.Warn("RPC endpoint is unhealthy", "url", ep.url, "head", heads[i], "err", err)
+		}
+		ep.healthy = err == nil
+	}
+	if best > p.head {
+		p.head = best
+	}
+	return nil
+}
+
+// checkEndpoint dials the endpoint if necessary, and returns its head block
+// number. An error is returned if the endpoint is unreachable or syncing.
+func (p *endpointPool) checkEndpoint(ctx context.Context, ep *poolEndpoint) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	client := ep.client.Load()
+	if client == nil {
+		p.mu.Lock()
+		options := p.options
+		p.mu.Unlock()
+
+		var err error
+		if client, err = DialOptions(ctx, ep.url, options...); err != nil {
+			return 0, err
+		}
+		ep.client.Store(client)
+	}
+	var (
+		syncing json.RawMessage
+		head    hexutil.Uint64
+		batch   = []BatchElem{
+			{Method: "eth_syncing", Result: &syncing},
+			{Method: "eth_blockNumber", Result: &head},
+		}
+	)
+	if err := client.BatchCallContext(ctx, batch); err != nil {
+		return 0, err
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return 0, elem.Error
+		}
+	}
+	if string(syncing) != "false" {
+		return uint64(head), errEndpointSyncing
+	}
+	return uint64(head), nil
+}
+
+// observe records a head block number reported by an endpoint.
+func (p *endpointPool) observe(ep *poolEndpoint, head uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if head > ep.head {
+		ep.head = head
+	}
+	if head > p.head {
+		p.head = head
+	}
+}
+
+// candidates returns the endpoints to try for a request in order of preference.
+// Endpoints behind the highest observed head are never returned, but they are
+// probed in the background for having caught up, so that the requests are
+// balanced across them again as soon as possible.
+func (p *endpointPool) candidates(subscription bool) []*poolEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var healthy, down, behind []*poolEndpoint
+	for i := range p.endpoints {
+		ep := p.endpoints[(p.next+i)%len(p.endpoints)]
+		client := ep.client.Load()
+		if client == nil || (subscription && !client.SupportsSubscriptions()) {
+			continue
+		}
+		switch {
+		case ep.head < p.head:
+			behind = append(behind, ep)
+		case ep.healthy:
+			healthy = append(healthy, ep)
+		default:
+			down = append(down, ep)
+		}
+	}
+	p.next = (p.next + 1) % len(p.endpoints)
+
+	if len(behind) > 0 && !p.probing && time.Since(p.probed) >= lagProbeInterval {
+		p.probing, p.probed = true, time.Now()
+		go func() {
+			p.probe(context.Background(), behind)
+
+			p.mu.Lock()
+			p.probing = false
+			p.mu.Unlock()
+		}()
+	}
+	return append(healthy, down...)
+}
+
+// behind returns the endpoints which didn't reach the highest observed head.
+func (p *endpointPool) behind(subscription bool) []*poolEndpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var behind []*poolEndpoint
+	for _, ep := range p.endpoints {
+		client := ep.client.Load()
+		if client == nil || (subscription && !client.SupportsSubscriptions()) {
+			continue
+		}
+		if ep.head < p.head {
+			behind = append(behind, ep)
+		}
+	}
+	return behind
+}
+
+// probe retrieves the heads of the given endpoints, recording the ones which
+// caught up with the highest observed head.
+func (p *endpointPool) probe(ctx context.Context, endpoints []*poolEndpoint) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, ep := range endpoints {
+		wg.Add(1)
+		go func(ep *poolEndpoint) {
+			defer wg.Done()
+
+			var head hexutil.Uint64
+			if err := ep.client.Load().CallContext(ctx, &head, "eth_blockNumber"); err == nil {
+				p.observe(ep, uint64(head))
+			}
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// do performs a request on the preferred endpoint, failing over to the next ones
+// on transport errors. If no endpoint at the head is left to serve the request,
+// the endpoints behind it are probed and retried once they caught up.
+func (p *endpointPool) do(ctx context.Context, subscription bool, fn func(*poolEndpoint, *Client) error) error {
+	if subscription && !p.supportsSubscriptions() {
+		return ErrNotificationsUnsupported
+	}
+	var (
+		tried = make(map[*poolEndpoint]bool)
+		err   = errNoCurrentEndpoint
+	)
+	for retry := false; ; retry = true {
+		for _, ep := range p.candidates(subscription) {
+			if tried[ep] {
+				continue
+			}
+			tried[ep] = true
+			if err = fn(ep, ep.client.Load()); err == nil || !p.failover(ctx, ep, err) {
+				return err
+			}
+		}
+		if retry || ctx.Err() != nil {
+			return err
+		}
+		behind := p.behind(subscription)
+		if len(behind) == 0 {
+			return err
+		}
+		p.probe(ctx, behind)
+	}
+}
+
+// failover reports whether a request that failed with the given error should be
+// retried on another endpoint, marking the endpoint unhealthy if so.
+func (p *endpointPool) failover(ctx context.Context, ep *poolEndpoint, err error) bool {
+	var rpcErr Error
+	if ctx.Err() != nil || errors.As(err, &rpcErr) || errors.Is(err, ErrNoResult) || errors.Is(err, ErrClientQuit) {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ep.healthy {
+		log.Warn("RPC endpoint failed, failing over", "url", ep.url, "err", err)
+		ep.healthy = false
+	}
+	return true
+}
+
+func (p *endpointPool) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	var raw json.RawMessage
+	err := p.do(ctx, false, func(ep *poolEndpoint, client *Client) error {
+		if err := client.CallContext(ctx, &raw, method, args...); err != nil {
+			return err
+		}
+		if head, ok := headOf(method, args, raw); ok {
+			p.observe(ep, head)
+		}
+		return nil
+	})
+	if err != nil || result == nil {
+		return err
+	}
+	return json.Unmarshal(raw, result)
+}
+
+// headOf returns the block number an endpoint must have reached to answer the
+// call with the given result, if the method reveals it.
+func headOf(method string, args []interface{}, raw json.RawMessage) (uint64, bool) {
+	switch method {
+	case "eth_blockNumber":
+		var head hexutil.Uint64
+		if json.Unmarshal(raw, &head) != nil {
+			return 0, false
+		}
+		return uint64(head), true
+
+	case "eth_getBlockByNumber", "eth_getHeaderByNumber":
+		// The pending block is built on top of the head, it isn't part of it
+		if len(args) == 0 {
+			return 0, false
+		}
+		if number, ok := args[0].(BlockNumber); ok && number == PendingBlockNumber {
+			return 0, false
+		}
+		if arg, ok := args[0].(string); ok && arg == "pending" {
+			return 0, false
+		}
+		var block struct {
+			Number *hexutil.Uint64 `json:"number"`
+		}
+		if json.Unmarshal(raw, &block) != nil || block.Number == nil {
+			return 0, false
+		}
+		return uint64(*block.Number), true
+	}
+	return 0, false
+}
+
+func (p *endpointPool) batchCall(ctx context.Context, b []BatchElem) error {
+	return p.do(ctx, false, func(_ *poolEndpoint, client *Client) error {
+		return client.BatchCallContext(ctx, b)
+	})
+}
+
+func (p *endpointPool) notify(ctx context.Context, method string, args ...interface{}) error {
+	return p.do(ctx, false, func(_ *poolEndpoint, client *Client) error {
+		return client.Notify(ctx, method, args...)
+	})
+}
+
+func (p *endpointPool) subscribe(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*ClientSubscription, error) {
+	var sub *ClientSubscription
+	err := p.do(ctx, true, func(_ *poolEndpoint, client *Client) (err error) {
+		sub, err = client.Subscribe(ctx, namespace, channel, args...)
+		return err
+	})
+	return sub, err
+}
+
+// setHeader sets a HTTP header on the clients of all endpoints, including those
+// dialed later on.
+func (p *endpointPool) setHeader(key, value string) {
+	p.mu.Lock()
+	p.options = append(p.options[:len(p.options):len(p.options)], WithHeader(key, value))
+	p.mu.Unlock()
+
+	for _, ep := range p.endpoints {
+		if client := ep.client.Load(); client != nil {
+			client.SetHeader(key, value)
+		}
+	}
+}
+
+// supportsSubscriptions reports whether any endpoint supports subscriptions.
+func (p *endpointPool) supportsSubscriptions() bool {
+	for _, ep := range p.endpoints {
+		if client := ep.client.Load(); client != nil && client.SupportsSubscriptions() {
+			return true
+		}
+	}
+	return false
+}
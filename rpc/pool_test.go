@@ -0,0 +1,311 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// poolTestService mimics the health related methods of the eth namespace.
+type poolTestService struct {
+	name    string
+	head    atomic.Uint64
+	syncing atomic.Bool
+}
+
+func (s *poolTestService) Syncing() interface{} {
+	if s.syncing.Load() {
+		return map[string]hexutil.Uint64{"currentBlock": hexutil.Uint64(s.head.Load())}
+	}
+	return false
+}
+
+func (s *poolTestService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.head.Load())
+}
+
+func (s *poolTestService) GetBlockByNumber(number BlockNumber, full bool) map[string]interface{} {
+	if number == LatestBlockNumber {
+		number = BlockNumber(s.head.Load())
+	}
+	return map[string]interface{}{"number": hexutil.Uint64(number)}
+}
+
+func (s *poolTestService) Name() string {
+	return s.name
+}
+
+type poolTestNode struct {
+	service *poolTestService
+	server  *Server
+	http    *httptest.Server
+}
+
+func newPoolTestNode(t *testing.T, name string, head uint64) *poolTestNode {
+	t.Helper()
+
+	service := &poolTestService{name: name}
+	service.head.Store(head)
+	server := newTestServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	node := &poolTestNode{service: service, server: server}
+	node.http = httptest.NewServer(server)
+	t.Cleanup(func() {
+		node.http.Close()
+		server.Stop()
+	})
+	return node
+}
+
+func dialPool(t *testing.T, urls ...string) *Client {
+	t.Helper()
+
+	client, err := DialEndpoints(context.Background(), urls, WithHealthCheckInterval(time.Hour))
+	if err != nil {
+		t.Fatal("can't dial endpoints:", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// names returns the names of the nodes serving a number of calls.
+func names(t *testing.T, client *Client, calls int) map[string]int {
+	t.Helper()
+
+	served := make(map[string]int)
+	for i := 0; i < calls; i++ {
+		var name string
+		if err := client.Call(&name, "eth_name"); err != nil {
+			t.Fatal("call failed:", err)
+		}
+		served[name]++
+	}
+	return served
+}
+
+func TestPoolBalance(t *testing.T) {
+	a := newPoolTestNode(t, "a", 10)
+	b := newPoolTestNode(t, "b", 10)
+	client := dialPool(t, a.http.URL, b.http.URL)
+
+	if served := names(t, client, 10); served["a"] != 5 || served["b"] != 5 {
+		t.Fatalf("calls not balanced: %v", served)
+	}
+}
+
+func TestPoolFailover(t *testing.T) {
+	a := newPoolTestNode(t, "a", 10)
+	b := newPoolTestNode(t, "b", 10)
+	client := dialPool(t, a.http.URL, b.http.URL)
+
+	a.http.Close()
+	if served := names(t, client, 10); served["b"] != 10 {
+		t.Fatalf("calls not failed over: %v", served)
+	}
+	if ep := client.pool.endpoints[0]; ep.healthy {
+		t.Fatal("failed endpoint still healthy")
+	}
+	// Server errors must not trigger a failover.
+	if err := client.Call(nil, "eth_noSuchMethod"); err == nil {
+		t.Fatal("no error for unknown method")
+	}
+	if ep := client.pool.endpoints[1]; !ep.healthy {
+		t.Fatal("endpoint unhealthy after server error")
+	}
+}
+
+func TestPoolHeadConsistency(t *testing.T) {
+	a := newPoolTestNode(t, "a", 10)
+	b := newPoolTestNode(t, "b", 5)
+	client := dialPool(t, a.http.URL, b.http.URL)
+
+	// b is within the allowed lag, but behind the head observed on a.
+	if served := names(t, client, 10); served["a"] != 10 {
+		t.Fatalf("calls routed to endpoint behind the head: %v", served)
+	}
+	// A head observed through a call must be honored as well.
+	a.service.head.Store(11)
+	b.service.head.Store(11)
+	client.pool.check(context.Background())
+	a.service.head.Store(12)
+	var head hexutil.Uint64
+	for head != 12 {
+		if err := client.Call(&head, "eth_blockNumber"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if served := names(t, client, 10); served["a"] != 10 {
+		t.Fatalf("calls routed to endpoint behind the observed head: %v", served)
+	}
+	// Once b caught up, calls are balanced again.
+	b.service.head.Store(12)
+	client.pool.check(context.Background())
+	if served := names(t, client, 10); served["a"] != 5 || served["b"] != 5 {
+		t.Fatalf("calls not balanced: %v", served)
+	}
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	a := newPoolTestNode(t, "a", 10)
+	b := newPoolTestNode(t, "b", 10)
+	client := dialPool(t, a.http.URL, b.http.URL)
+
+	// A syncing endpoint is avoided.
+	b.service.syncing.Store(true)
+	client.pool.check(context.Background())
+	if served := names(t, client, 10); served["a"] != 10 {
+		t.Fatalf("calls routed to syncing endpoint: %v", served)
+	}
+	// A lagging endpoint is avoided even after the others fall back.
+	b.service.syncing.Store(false)
+	a.service.head.Store(100)
+	client.pool.check(context.Background())
+	if ep := client.pool.endpoints[1]; ep.healthy {
+		t.Fatal("lagging endpoint is healthy")
+	}
+	// Endpoints behind the observed head are not used even if there is no other one.
+	a.http.Close()
+	client.pool.check(context.Background())
+	var name string
+	if err := client.Call(&name, "eth_name"); err == nil {
+		t.Fatalf("call routed to endpoint behind the head: %s", name)
+	}
+	// Once the remaining endpoint caught up, it serves the calls.
+	b.service.head.Store(100)
+	client.pool.check(context.Background())
+	if served := names(t, client, 10); served["b"] != 10 {
+		t.Fatalf("calls not routed to remaining endpoint: %v", served)
+	}
+}
+
+func TestPoolNoCurrentEndpoint(t *testing.T) {
+	a := newPoolTestNode(t, "a", 10)
+	b := newPoolTestNode(t, "b", 10)
+	client := dialPool(t, a.http.URL, b.http.URL)
+
+	// A block returned by number reveals the head of the endpoint serving it.
+	a.service.head.Store(11)
+	for {
+		var block struct {
+			Number hexutil.Uint64 `json:"number"`
+		}
+		if err := client.Call(&block, "eth_getBlockByNumber", "latest", false); err != nil {
+			t.Fatal(err)
+		}
+		if block.Number == 11 {
+			break
+		}
+	}
+	// Unhealthy endpoints at the head are used if no healthy one is.
+	client.pool.endpoints[0].healthy = false
+	if served := names(t, client, 4); served["a"] != 4 {
+		t.Fatalf("calls not routed to unhealthy endpoint at the head: %v", served)
+	}
+	// Endpoints behind the head are never used.
+	client.pool.observe(&poolEndpoint{}, 12)
+	if err := client.Call(nil, "eth_name"); err != errNoCurrentEndpoint {
+		t.Fatalf("wrong error without endpoint at the head: %v", err)
+	}
+}
+
+func TestPoolCatchUp(t *testing.T) {
+	a := newPoolTestNode(t, "a", 10)
+	b := newPoolTestNode(t, "b", 10)
+	client := dialPool(t, a.http.URL, b.http.URL)
+
+	// Observe a new head on a, b caught up without the pool noticing yet.
+	a.service.head.Store(11)
+	var head hexutil.Uint64
+	for head != 11 {
+		if err := client.Call(&head, "eth_blockNumber"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.service.head.Store(11)
+
+	// Endpoints behind the head are probed in the background, the calls are
+	// balanced again once b is known to have caught up.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(100 * time.Millisecond) {
+		if served := names(t, client, 2); served["b"] > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("calls not routed to endpoint which caught up")
+		}
+	}
+	// If the only endpoint at the head fails, the endpoints behind it are probed
+	// before the call fails.
+	a.service.head.Store(12)
+	for head != 12 {
+		if err := client.Call(&head, "eth_blockNumber"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.service.head.Store(12)
+	a.http.Close()
+	if served := names(t, client, 4); served["b"] != 4 {
+		t.Fatalf("calls not failed over to endpoint which caught up: %v", served)
+	}
+}
+
+func TestPoolSubscription(t *testing.T) {
+	a := newPoolTestNode(t, "a", 10)
+	b := newPoolTestNode(t, "b", 10)
+
+	// Subscriptions are unsupported over HTTP.
+	client := dialPool(t, a.http.URL, b.http.URL)
+	if client.SupportsSubscriptions() {
+		t.Fatal("HTTP pool supports subscriptions")
+	}
+	if _, err := client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 1, 0); err != ErrNotificationsUnsupported {
+		t.Fatal("wrong error:", err)
+	}
+	// Subscriptions are served by the websocket endpoints of mixed pools.
+	ws := httptest.NewServer(b.server.WebsocketHandler([]string{"*"}))
+	defer ws.Close()
+	client = dialPool(t, a.http.URL, "ws:"+strings.TrimPrefix(ws.URL, "http:"))
+
+	nc := make(chan int)
+	sub, err := client.Subscribe(context.Background(), "nftest", nc, "someSubscription", 3, 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+	for i := 0; i < 3; i++ {
+		if val := <-nc; val != i {
+			t.Fatalf("value mismatch: got %d, want %d", val, i)
+		}
+	}
+}
+
+func TestPoolDialFailure(t *testing.T) {
+	if _, err := DialEndpoints(context.Background(), nil); err == nil {
+		t.Fatal("no error for empty endpoints")
+	}
+	if _, err := DialEndpoints(context.Background(), []string{"foo://bar"}); err == nil {
+		t.Fatal("no error for undialable endpoints")
+	}
+}
@@ -55,6 +55,10 @@ type Server struct {
 	batchItemLimit     int
 	batchResponseLimit int
 	httpBodyLimit      int
+	responseSizeLimit  int
+	access             *AccessControl
+	cache              ResponseCache
+	audit              *AuditLog
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -83,6 +87,16 @@ func (s *Server) SetBatchLimits(itemLimit, maxResponseSize int) {
 	s.batchResponseLimit = maxResponseSize
 }
 
+// SetResponseSizeLimit sets the maximum size of the result of a single call, zero
+// meaning unlimited. Results streamed by the method are cut off once they exceed
+// the limit.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetResponseSizeLimit(limit int) {
+	s.responseSizeLimit = limit
+}
+
 // SetHTTPBodyLimit sets the size limit for HTTP requests.
 //
 // This method should be called before processing any requests via ServeHTTP.
@@ -90,6 +104,30 @@ func (s *Server) SetHTTPBodyLimit(limit int) {
 	s.httpBodyLimit = limit
 }
 
+// SetAccessControl sets the access control enforced on method calls.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetAccessControl(ac *AccessControl) {
+	s.access = ac
+}
+
+// SetResponseCache sets the cache serving the results of method calls.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetResponseCache(cache ResponseCache) {
+	s.cache = cache
+}
+
+// SetAuditLog sets the log recording the method calls served.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetAuditLog(audit *AuditLog) {
+	s.audit = audit
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -115,6 +153,10 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 		idgen:              s.idgen,
 		batchItemLimit:     s.batchItemLimit,
 		batchResponseLimit: s.batchResponseLimit,
+		responseSizeLimit:  s.responseSizeLimit,
+		access:             s.access,
+		cache:              s.cache,
+		audit:              s.audit,
 	}
 	c := initClient(codec, &s.services, cfg)
 	<-codec.closed()
@@ -150,6 +192,10 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit)
 	h.allowSubscribe = false
+	h.access = s.access
+	h.responseSizeLimit = s.responseSizeLimit
+	h.cache = s.cache
+	h.audit = s.audit
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -222,10 +268,49 @@ type PeerInfo struct {
 		Origin    string
 		Host      string
 	}
+
+	// Subject of the JWT the client authenticated with, if any.
+	AuthSubject string
+
+	// Namespaces the JWT of the client is restricted to, nil if unrestricted.
+	AuthNamespaces []string
 }
 
 type peerInfoContextKey struct{}
 
+type (
+	authSubjectContextKey    struct{}
+	authNamespacesContextKey struct{}
+)
+
+// NewContextWithAuthSubject wraps the given context, adding the subject the client of
+// an HTTP request authenticated as. Servers add it to the PeerInfo of the request.
+func NewContextWithAuthSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, authSubjectContextKey{}, subject)
+}
+
+// authSubjectFromContext returns the subject added by NewContextWithAuthSubject.
+func authSubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(authSubjectContextKey{}).(string)
+	return subject
+}
+
+// NewContextWithAuthNamespaces wraps the given context, restricting the client of an
+// HTTP request to the given namespaces. Servers add them to the PeerInfo of the request
+// and refuse calls of methods of other namespaces.
+func NewContextWithAuthNamespaces(ctx context.Context, namespaces []string) context.Context {
+	if namespaces == nil {
+		namespaces = []string{}
+	}
+	return context.WithValue(ctx, authNamespacesContextKey{}, namespaces)
+}
+
+// authNamespacesFromContext returns the namespaces added by NewContextWithAuthNamespaces.
+func authNamespacesFromContext(ctx context.Context) []string {
+	namespaces, _ := ctx.Value(authNamespacesContextKey{}).([]string)
+	return namespaces
+}
+
 // PeerInfoFromContext returns information about the client's network connection.
 // Use this with the context passed to RPC method handler functions.
 //
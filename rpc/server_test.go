@@ -19,13 +19,19 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"net"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 func TestServerRegisterName(t *testing.T) {
@@ -192,3 +198,109 @@ func TestServerBatchResponseSizeLimit(t *testing.T) {
 		}
 	}
 }
+
+// testResponseCache caches the results of all calls of the given methods.
+type testResponseCache struct {
+	methods map[string]bool
+	mu      sync.Mutex
+	results map[string]json.RawMessage
+	hits    int
+}
+
+func (c *testResponseCache) Lookup(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, func(json.RawMessage)) {
+	if !c.methods[method] {
+		return nil, nil
+	}
+	key := method + string(params)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if result, ok := c.results[key]; ok {
+		c.hits++
+		return result, nil
+	}
+	return nil, func(result json.RawMessage) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.results[key] = append(json.RawMessage{}, result...)
+	}
+}
+
+func TestServerResponseCache(t *testing.T) {
+	cache := &testResponseCache{
+		methods: map[string]bool{"test_echo": true, "stream_items": true},
+		results: make(map[string]json.RawMessage),
+	}
+	server := newStreamTestServer(0)
+	server.SetResponseCache(cache)
+	defer server.Stop()
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		var (
+			echo  echoResult
+			items streamItems
+			rep   string
+		)
+		if err := client.Call(&echo, "test_echo", "x", 1, &echoArgs{S: "y"}); err != nil {
+			t.Fatal(err)
+		}
+		if echo.String != "x" || echo.Args.S != "y" {
+			t.Fatalf("wrong echo result: %+v", echo)
+		}
+		if err := client.Call(&items, "stream_items", 10); err != nil {
+			t.Fatal(err)
+		}
+		if len(items.Items) != 10 {
+			t.Fatalf("wrong number of items: %d", len(items.Items))
+		}
+		if err := client.Call(&rep, "test_repeat", "x", 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cache.hits != 2 || len(cache.results) != 2 {
+		t.Fatalf("wrong cache usage: %d hits, %d results", cache.hits, len(cache.results))
+	}
+	// Streamed results of batches are stored as well.
+	var items streamItems
+	batch := []BatchElem{{Method: "stream_items", Args: []interface{}{5}, Result: &items}}
+	if err := client.BatchCall(batch); err != nil || batch[0].Error != nil {
+		t.Fatal("batch call failed:", err, batch[0].Error)
+	}
+	if err := client.BatchCall(batch); err != nil || batch[0].Error != nil {
+		t.Fatal("batch call failed:", err, batch[0].Error)
+	}
+	if cache.hits != 3 || len(items.Items) != 5 {
+		t.Fatalf("batch result not cached: %d hits, %d items", cache.hits, len(items.Items))
+	}
+}
+
+func TestServerCallMetrics(t *testing.T) {
+	server := newStreamTestServer(0)
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result json.RawMessage
+	if err := client.Call(&result, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(&result, "stream_items", 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{"test_echo", "stream_items"} {
+		for _, name := range []string{inflightGaugeName, latencyHistName, sizeHistName} {
+			name = metrics.LabeledName(name, "method", method, "transport", "ipc")
+			if metrics.DefaultRegistry.Get(name) == nil {
+				t.Errorf("metric %s not registered", name)
+			}
+		}
+	}
+}
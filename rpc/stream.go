@@ -0,0 +1,384 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+)
+
+const (
+	// streamBufferSize is the amount of encoded output buffered before it's written
+	// to the connection.
+	streamBufferSize = 32 * 1024
+
+	// MaxCachedStreamSize is the maximum size of a streamed result retained for
+	// the response cache.
+	MaxCachedStreamSize = 4 * 1024 * 1024
+)
+
+var (
+	errResponseTooLarge = &internalServerError{errcodeResponseTooLarge, errMsgResponseTooLarge}
+	errInvalidStream    = errors.New("invalid JSON stream")
+	errStreamAborted    = errors.New("streamed result aborted")
+)
+
+// JSONStreamer is implemented by method results which are encoded while they're
+// written to the connection, rather than being marshaled into memory at once.
+// Methods returning large results can use it to keep their memory usage bounded.
+//
+// StreamJSON must write exactly one JSON value to the stream. Errors returned by
+// it are reported as a plain error response as long as no part of the result was
+// sent yet. Once it was, the response can't be withdrawn anymore: the connection
+// is closed instead, so that clients never mistake a partial result for a valid
+// one.
+type JSONStreamer interface {
+	StreamJSON(s *JSONStream) error
+}
+
+// StreamFunc is a JSONStreamer implemented by a function. It also implements
+// json.Marshaler, so results can be used outside of the RPC server as well.
+type StreamFunc func(s *JSONStream) error
+
+// StreamJSON implements JSONStreamer.
+func (f StreamFunc) StreamJSON(s *JSONStream) error {
+	return f(s)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f StreamFunc) MarshalJSON() ([]byte, error) {
+	return MarshalStream(f)
+}
+
+// MarshalStream encodes the value of a streamer into memory.
+func MarshalStream(v JSONStreamer) ([]byte, error) {
+	return marshalStream(context.Background(), v, 0)
+}
+
+func marshalStream(ctx context.Context, v JSONStreamer, limit int) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		s   = &JSONStream{ctx: ctx, w: &buf, limit: limit}
+	)
+	err := v.StreamJSON(s)
+	if err == nil {
+		err = s.finish()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// JSONStream encodes a JSON value incrementally. Values are written using
+// BeginObject, Field and EndObject for objects, BeginArray and EndArray for
+// arrays, and Value for everything else.
+//
+// All methods return the first error encountered by the stream, e.g. when the
+// response size limit is exceeded or the connection failed, after which nothing
+// is written anymore.
+type JSONStream struct {
+	ctx     context.Context
+	w       io.Writer
+	prefix  []byte // written before the first byte of the value
+	limit   int    // maximum size of the value, 0 if unlimited
+	size    int
+	capture *bytes.Buffer // copy of the value, nil if not captured
+
+	stack   []streamLevel // open objects and arrays
+	field   bool          // a field name was written, its value is next
+	started bool
+	err     error
+}
+
+type streamLevel struct {
+	end   byte // closing delimiter
+	count int  // number of elements written so far
+}
+
+// Context returns the context of the call the stream encodes the result of.
+func (s *JSONStream) Context() context.Context {
+	return s.ctx
+}
+
+// BeginObject starts a JSON object.
+func (s *JSONStream) BeginObject() error {
+	return s.open('{', '}')
+}
+
+// EndObject closes the innermost JSON object.
+func (s *JSONStream) EndObject() error {
+	return s.close('}')
+}
+
+// BeginArray starts a JSON array.
+func (s *JSONStream) BeginArray() error {
+	return s.open('[', ']')
+}
+
+// EndArray closes the innermost JSON array.
+func (s *JSONStream) EndArray() error {
+	return s.close(']')
+}
+
+// Field writes the name of the next field of the innermost object. It must be
+// followed by its value.
+func (s *JSONStream) Field(name string) error {
+	if s.err != nil {
+		return s.err
+	}
+	level := s.top()
+	if level == nil || level.end != '}' || s.field {
+		return s.fail(errInvalidStream)
+	}
+	enc, err := json.Marshal(name)
+	if err != nil {
+		return s.fail(err)
+	}
+	if level.count > 0 {
+		enc = append([]byte{','}, enc...)
+	}
+	if err := s.write(append(enc, ':')); err != nil {
+		return err
+	}
+	level.count++
+	s.field = true
+	return nil
+}
+
+// Value writes a JSON value. Values implementing JSONStreamer are streamed,
+// all others are marshaled using package json.
+func (s *JSONStream) Value(v interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	if streamer, ok := v.(JSONStreamer); ok && !isNilStreamer(v) {
+		if err := streamer.StreamJSON(s); err != nil {
+			return s.fail(err)
+		}
+		return nil
+	}
+	enc, err := json.Marshal(v)
+	if err != nil {
+		return s.fail(err)
+	}
+	return s.writeValue(enc)
+}
+
+func (s *JSONStream) open(begin, end byte) error {
+	if err := s.writeValue([]byte{begin}); err != nil {
+		return err
+	}
+	s.stack = append(s.stack, streamLevel{end: end})
+	return nil
+}
+
+func (s *JSONStream) close(end byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	level := s.top()
+	if level == nil || level.end != end || s.field {
+		return s.fail(errInvalidStream)
+	}
+	if err := s.write([]byte{end}); err != nil {
+		return err
+	}
+	s.stack = s.stack[:len(s.stack)-1]
+	return nil
+}
+
+// writeValue writes the encoding of a value, or its opening delimiter, preceded
+// by the separator from the previous value. The stream state is only updated once
+// the value was written.
+func (s *JSONStream) writeValue(enc []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	level := s.top()
+	switch {
+	case level == nil:
+		if s.size > 0 {
+			return s.fail(errInvalidStream) // only one top-level value
+		}
+	case level.end == '}':
+		if !s.field {
+			return s.fail(errInvalidStream) // object values need a name
+		}
+	case level.count > 0:
+		enc = append([]byte{','}, enc...)
+	}
+	if err := s.write(enc); err != nil {
+		return err
+	}
+	switch {
+	case level == nil:
+	case level.end == '}':
+		s.field = false
+	default:
+		level.count++
+	}
+	return nil
+}
+
+func (s *JSONStream) top() *streamLevel {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	return &s.stack[len(s.stack)-1]
+}
+
+func (s *JSONStream) write(b []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.limit != 0 && s.size+len(b) > s.limit {
+		return s.fail(errResponseTooLarge)
+	}
+	if err := s.ctx.Err(); err != nil {
+		return s.fail(err)
+	}
+	if !s.started {
+		s.started = true
+		if _, err := s.w.Write(s.prefix); err != nil {
+			return s.fail(err)
+		}
+	}
+	n, err := s.w.Write(b)
+	s.size += n
+	if err != nil {
+		return s.fail(err)
+	}
+	if s.capture != nil {
+		if s.capture.Len()+n > MaxCachedStreamSize {
+			s.capture = nil
+		} else {
+			s.capture.Write(b)
+		}
+	}
+	return nil
+}
+
+func (s *JSONStream) fail(err error) error {
+	if s.err == nil {
+		s.err = err
+	}
+	return s.err
+}
+
+// finish verifies that a complete value was written.
+func (s *JSONStream) finish() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.stack) > 0 {
+		return s.fail(errInvalidStream)
+	}
+	if s.size == 0 {
+		return s.write(null)
+	}
+	return nil
+}
+
+func isNilStreamer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Func, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// writeStreamResponse writes a response whose result is streamed. The first part
+// of the result is buffered: if the result fails before the buffer was flushed to
+// the connection, a plain error response is sent instead. Failures after that
+// return errStreamAborted, leaving the response incomplete for the caller to
+// close the connection.
+func writeStreamResponse(ctx context.Context, w io.Writer, msg *jsonrpcMessage, limit int) error {
+	var (
+		cw     = &countingWriter{w: w}
+		bw     = bufio.NewWriterSize(cw, streamBufferSize)
+		prefix = append(append([]byte(`{"jsonrpc":"2.0","id":`), msg.ID...), `,"result":`...)
+		s      = &JSONStream{ctx: ctx, w: bw, prefix: prefix, limit: limit}
+	)
+	if msg.store != nil {
+		s.capture = new(bytes.Buffer)
+	}
+	err := msg.stream.StreamJSON(s)
+	if err == nil {
+		err = s.finish()
+	}
+	var failure *jsonError
+	switch {
+	case err == nil:
+		if s.capture != nil {
+			msg.store(s.capture.Bytes())
+		}
+		bw.WriteString("}\n")
+	case cw.n == 0:
+		// Nothing was sent yet, replace the partial result by an error response.
+		bw.Reset(cw)
+		resp := msg.errorResponse(err)
+		failure = resp.Error
+		json.NewEncoder(bw).Encode(resp)
+	default:
+		rpcStreamAbortedMeter.Mark(1)
+		failure = errorMessage(err).Error
+	}
+	if msg.written != nil {
+		msg.written(s.size, failure)
+	}
+	if failure != nil && cw.n > 0 {
+		return errStreamAborted
+	}
+	return bw.Flush()
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += n
+	return n, err
+}
+
+// deadlineWriter extends the write deadline of the connection on every write,
+// so that long streams aren't cut off by the write timeout.
+type deadlineWriter struct {
+	ctx  context.Context
+	conn deadlineCloser
+	w    io.Writer
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	deadline, ok := w.ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultWriteTimeout)
+	}
+	w.conn.SetWriteDeadline(deadline)
+	return w.w.Write(b)
+}
@@ -0,0 +1,235 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type streamTestService struct{}
+
+type streamItem struct {
+	N    int    `json:"n"`
+	Data string `json:"data"`
+}
+
+// Items streams an object holding n items.
+func (streamTestService) Items(n int) StreamFunc {
+	return func(s *JSONStream) error {
+		s.BeginObject()
+		s.Field("count")
+		s.Value(n)
+		s.Field("items")
+		s.BeginArray()
+		for i := 0; i < n; i++ {
+			if err := s.Value(&streamItem{N: i, Data: strings.Repeat("x", 100)}); err != nil {
+				return err
+			}
+		}
+		s.EndArray()
+		return s.EndObject()
+	}
+}
+
+// Fail streams n items before failing.
+func (streamTestService) Fail(n int) StreamFunc {
+	return func(s *JSONStream) error {
+		s.BeginArray()
+		for i := 0; i < n; i++ {
+			s.Value(i)
+		}
+		return errors.New("stream failed")
+	}
+}
+
+type streamItems struct {
+	Count int          `json:"count"`
+	Items []streamItem `json:"items"`
+}
+
+func newStreamTestServer(limit int) *Server {
+	server := newTestServer()
+	server.SetResponseSizeLimit(limit)
+	if err := server.RegisterName("stream", new(streamTestService)); err != nil {
+		panic(err)
+	}
+	return server
+}
+
+func TestStreamResponse(t *testing.T) {
+	server := newStreamTestServer(0)
+	defer server.Stop()
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+	wssrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer wssrv.Close()
+
+	clients := map[string]*Client{"inproc": DialInProc(server)}
+	var err error
+	if clients["http"], err = DialHTTP(httpsrv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if clients["ws"], err = DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(wssrv.URL, "http:"), ""); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(streamTestService{}.Items(1000))
+	for name, client := range clients {
+		defer client.Close()
+
+		var res streamItems
+		if err := client.Call(&res, "stream_items", 1000); err != nil {
+			t.Fatalf("%s: call failed: %v", name, err)
+		}
+		if res.Count != 1000 || len(res.Items) != 1000 || res.Items[999].N != 999 {
+			t.Fatalf("%s: wrong result: count %d, items %d", name, res.Count, len(res.Items))
+		}
+		var raw json.RawMessage
+		if err := client.Call(&raw, "stream_items", 1000); err != nil {
+			t.Fatalf("%s: call failed: %v", name, err)
+		}
+		if !reflect.DeepEqual([]byte(raw), want) {
+			t.Fatalf("%s: streamed result differs from marshaled one", name)
+		}
+		// Errors before the first part of the result was sent yield plain error
+		// responses, discarding the partial result.
+		if err := client.Call(&raw, "stream_fail", 10); err == nil || err.Error() != "stream failed" {
+			t.Fatalf("%s: wrong error: %v", name, err)
+		}
+		if err := client.Call(&raw, "stream_fail", 0); err == nil || err.Error() != "stream failed" {
+			t.Fatalf("%s: wrong error: %v", name, err)
+		}
+	}
+}
+
+// Tests that a result failing after part of it was sent isn't delivered as a
+// response, neither as a result nor next to an error.
+func TestStreamResponseAborted(t *testing.T) {
+	server := newStreamTestServer(0)
+	defer server.Stop()
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+	wssrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer wssrv.Close()
+
+	clients := map[string]*Client{"inproc": DialInProc(server)}
+	var err error
+	if clients["http"], err = DialHTTP(httpsrv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if clients["ws"], err = DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(wssrv.URL, "http:"), ""); err != nil {
+		t.Fatal(err)
+	}
+	for name, client := range clients {
+		defer client.Close()
+
+		var raw json.RawMessage
+		err := client.Call(&raw, "stream_fail", 2*streamBufferSize)
+		if err == nil {
+			t.Fatalf("%s: no error for aborted result", name)
+		}
+		if _, ok := err.(Error); ok {
+			t.Fatalf("%s: aborted result delivered as error response: %v", name, err)
+		}
+	}
+	// Over HTTP, the transfer of the response has to fail, rather than ending with
+	// a truncated body.
+	body := `{"jsonrpc":"2.0","id":1,"method":"stream_fail","params":[` + strconv.Itoa(2*streamBufferSize) + `]}`
+	resp, err := http.Post(httpsrv.URL, contentType, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Fatal("http: aborted result transferred completely")
+	}
+}
+
+func TestStreamResponseLimit(t *testing.T) {
+	server := newStreamTestServer(10000)
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var res streamItems
+	if err := client.Call(&res, "stream_items", 10); err != nil {
+		t.Fatal("call failed:", err)
+	}
+	err := client.Call(&res, "stream_items", 1000)
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != errcodeResponseTooLarge {
+		t.Fatal("wrong error for oversized result:", err)
+	}
+	// Plain results are subject to the limit too.
+	if err := client.Call(nil, "test_echo", strings.Repeat("x", 20000), 1, nil); err == nil {
+		t.Fatal("no error for oversized result")
+	}
+}
+
+func TestStreamBatch(t *testing.T) {
+	server := newStreamTestServer(0)
+	server.SetBatchLimits(100, 50000)
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var small, large, other streamItems
+	batch := []BatchElem{
+		{Method: "stream_items", Args: []interface{}{10}, Result: &small},
+		{Method: "stream_items", Args: []interface{}{1000}, Result: &large},
+		{Method: "stream_items", Args: []interface{}{1}, Result: &other},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal("batch call failed:", err)
+	}
+	if batch[0].Error != nil || len(small.Items) != 10 {
+		t.Fatalf("wrong first result: %v", batch[0].Error)
+	}
+	for _, elem := range batch[1:] {
+		if rpcErr, ok := elem.Error.(Error); !ok || rpcErr.ErrorCode() != errcodeResponseTooLarge {
+			t.Fatal("wrong error for result exceeding batch limit:", elem.Error)
+		}
+	}
+}
+
+func TestJSONStreamInvalid(t *testing.T) {
+	tests := []StreamFunc{
+		func(s *JSONStream) error { s.BeginObject(); s.Value(1); return s.EndObject() },
+		func(s *JSONStream) error { s.BeginArray(); return s.EndObject() },
+		func(s *JSONStream) error { s.BeginArray(); return nil },
+		func(s *JSONStream) error { s.Value(1); return s.Value(2) },
+		func(s *JSONStream) error { s.BeginObject(); s.Field("a"); return s.EndObject() },
+	}
+	for i, test := range tests {
+		if _, err := MarshalStream(test); err != errInvalidStream {
+			t.Errorf("test %d: wrong error: %v", i, err)
+		}
+	}
+	enc, err := MarshalStream(StreamFunc(func(s *JSONStream) error { return nil }))
+	if err != nil || string(enc) != "null" {
+		t.Fatalf("wrong empty stream encoding: %s, %v", enc, err)
+	}
+}
@@ -235,6 +235,11 @@ func (c *mockConn) writeJSON(ctx context.Context, msg interface{}, isError bool)
 	return c.enc.Encode(msg)
 }
 
+// writeStream writes a response with a streamed result to the connection.
+func (c *mockConn) writeStream(ctx context.Context, msg *jsonrpcMessage, limit int) error {
+	return c.enc.Encode(msg.encodeStream(ctx, limit))
+}
+
 // Closed returns a channel which is closed when the connection is closed.
 func (c *mockConn) closed() <-chan interface{} { return nil }
 
@@ -53,6 +53,9 @@ type ServerCodec interface {
 type jsonWriter interface {
 	// writeJSON writes a message to the connection.
 	writeJSON(ctx context.Context, msg interface{}, isError bool) error
+	// writeStream writes a response with a streamed result to the connection. The
+	// result may be at most limit bytes long, unless limit is zero.
+	writeStream(ctx context.Context, msg *jsonrpcMessage, limit int) error
 
 	// Closed returns a channel which is closed when the connection is closed.
 	closed() <-chan interface{}
@@ -60,6 +63,17 @@ type jsonWriter interface {
 	remoteAddr() string
 }
 
+// ResponseCache caches the results of method calls. It decides which calls are
+// cacheable, so it's consulted for every call served by the server.
+// Implementations must be safe for concurrent use.
+type ResponseCache interface {
+	// Lookup returns the cached result of a call. On a miss, it returns a function
+	// storing the result of the call if it may be cached, or nil otherwise. Results
+	// streamed by the method are only stored if they're shorter than
+	// MaxCachedStreamSize.
+	Lookup(ctx context.Context, method string, params json.RawMessage) (result json.RawMessage, store func(json.RawMessage))
+}
+
 type BlockNumber int64
 
 const (
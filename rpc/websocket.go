@@ -19,7 +19,9 @@ package rpc
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -61,6 +63,8 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			return
 		}
 		codec := newWebsocketCodec(conn, r.Host, r.Header, wsDefaultReadLimit)
+		codec.(*websocketCodec).info.AuthSubject = authSubjectFromContext(r.Context())
+		codec.(*websocketCodec).info.AuthNamespaces = authNamespacesFromContext(r.Context())
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -292,6 +296,21 @@ func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header, readL
 	encode := func(v interface{}, isErrorResponse bool) error {
 		return conn.WriteJSON(v)
 	}
+	stream := func(write func(io.Writer) error) error {
+		w, err := conn.NextWriter(websocket.TextMessage)
+		if err != nil {
+			return err
+		}
+		if err := write(w); err != nil {
+			// Don't complete the message if the result was cut off, the codec
+			// closes the connection instead.
+			if !errors.Is(err, errStreamAborted) {
+				w.Close()
+			}
+			return err
+		}
+		return w.Close()
+	}
 	wc := &websocketCodec{
 		jsonCodec:    NewFuncCodec(conn, encode, conn.ReadJSON).(*jsonCodec),
 		conn:         conn,
@@ -302,6 +321,7 @@ func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header, readL
 			RemoteAddr: conn.RemoteAddr().String(),
 		},
 	}
+	wc.jsonCodec.stream = stream
 	// Fill in connection details.
 	wc.info.HTTP.Host = host
 	wc.info.HTTP.Origin = req.Get("Origin")
@@ -331,15 +351,27 @@ func (wc *websocketCodec) peerInfo() PeerInfo {
 func (wc *websocketCodec) writeJSON(ctx context.Context, v interface{}, isError bool) error {
 	err := wc.jsonCodec.writeJSON(ctx, v, isError)
 	if err == nil {
-		// Notify pingLoop to delay the next idle ping.
-		select {
-		case wc.pingReset <- struct{}{}:
-		default:
-		}
+		wc.resetPing()
 	}
 	return err
 }
 
+func (wc *websocketCodec) writeStream(ctx context.Context, msg *jsonrpcMessage, limit int) error {
+	err := wc.jsonCodec.writeStream(ctx, msg, limit)
+	if err == nil {
+		wc.resetPing()
+	}
+	return err
+}
+
+// resetPing notifies pingLoop to delay the next idle ping.
+func (wc *websocketCodec) resetPing() {
+	select {
+	case wc.pingReset <- struct{}{}:
+	default:
+	}
+}
+
 // pingLoop sends periodic ping frames when the connection is idle.
 func (wc *websocketCodec) pingLoop() {
 	var pingTimer = time.NewTimer(wsPingInterval)